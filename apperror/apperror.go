@@ -0,0 +1,200 @@
+// Package apperror defines the errors handlers report to clients. Each
+// error has a Kind, which decides the HTTP status, and a stable Code that
+// clients can match on. The errors middleware renders them as a Response.
+package apperror
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Kind classifies an error and decides its HTTP status.
+type Kind int
+
+const (
+	// KindInternal is a failure the client cannot fix. Its cause is
+	// logged but never sent.
+	KindInternal Kind = iota
+	// KindValidation is a request that is malformed or has invalid fields.
+	KindValidation
+	// KindNotFound is a request for something that does not exist.
+	KindNotFound
+	// KindConflict is a request that clashes with the current state, such
+	// as a duplicate or a change the lifecycle does not allow.
+	KindConflict
+	// KindUnprocessable is a well-formed request that breaks a business
+	// rule, such as selling more than is in stock.
+	KindUnprocessable
+	// KindTimeout is a request whose storage calls took too long.
+	KindTimeout
+	// KindUnauthorized is a request without valid credentials.
+	KindUnauthorized
+	// KindForbidden is a request whose caller lacks the role it needs.
+	KindForbidden
+	// KindUnsupportedMediaType is a request body in a format the endpoint
+	// does not accept.
+	KindUnsupportedMediaType
+)
+
+// Status is the HTTP status errors of kind k are reported with.
+func (k Kind) Status() int {
+	switch k {
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindUnprocessable:
+		return http.StatusUnprocessableEntity
+	case KindTimeout:
+		return http.StatusGatewayTimeout
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
+	}
+	return http.StatusInternalServerError
+}
+
+// Codes shared by every endpoint. Conflict and Unprocessable errors carry
+// a code naming the rule they break instead.
+const (
+	CodeValidation   = "validation_failed"
+	CodeNotFound     = "not_found"
+	CodeInternal     = "internal_error"
+	CodeTimeout      = "timeout"
+	CodeUnauthorized = "unauthorized"
+	CodeForbidden    = "forbidden"
+
+	CodeUnsupportedMediaType = "unsupported_media_type"
+)
+
+// FieldError names a request field and what is wrong with it. Field is a
+// JSON path into the body, such as details[0].quantity, or the name of a
+// path or query parameter.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an error meant for the client.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+	// Details carries extra structured data, such as the products that
+	// are short of stock.
+	Details map[string]interface{}
+	// Err is the underlying cause. It is logged, never sent.
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithField adds a field error and returns e.
+func (e *Error) WithField(field, message string) *Error {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+	return e
+}
+
+// WithDetail adds a detail and returns e.
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]interface{})
+	}
+	e.Details[key] = value
+	return e
+}
+
+func NotFound(message string) *Error {
+	return &Error{Kind: KindNotFound, Code: CodeNotFound, Message: message}
+}
+
+func Conflict(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func Validation(message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: CodeValidation, Message: message, Fields: fields}
+}
+
+func Unprocessable(code, message string) *Error {
+	return &Error{Kind: KindUnprocessable, Code: code, Message: message}
+}
+
+func Internal(err error) *Error {
+	return &Error{Kind: KindInternal, Code: CodeInternal, Message: "internal server error", Err: err}
+}
+
+func Unauthorized(message string) *Error {
+	return &Error{Kind: KindUnauthorized, Code: CodeUnauthorized, Message: message}
+}
+
+func Forbidden(message string) *Error {
+	return &Error{Kind: KindForbidden, Code: CodeForbidden, Message: message}
+}
+
+func UnsupportedMediaType(message string) *Error {
+	return &Error{Kind: KindUnsupportedMediaType, Code: CodeUnsupportedMediaType, Message: message}
+}
+
+func Timeout(err error) *Error {
+	return &Error{Kind: KindTimeout, Code: CodeTimeout, Message: "the request took too long", Err: err}
+}
+
+// Reporter is implemented by domain errors that know how they are
+// reported to clients.
+type Reporter interface {
+	AppError() *Error
+}
+
+// As finds the first *Error or Reporter in err's chain.
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	var reporter Reporter
+	if errors.As(err, &reporter) {
+		return reporter.AppError(), true
+	}
+	return nil, false
+}
+
+// Response is the body of every error response.
+type Response struct {
+	Error Body `json:"error"`
+}
+
+// Body describes one error.
+type Body struct {
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	RequestID string                 `json:"request_id"`
+	Fields    []FieldError           `json:"fields,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// NewResponse builds the body reporting e for the request requestID.
+func NewResponse(e *Error, requestID string) Response {
+	return Response{Error: Body{
+		Code:      e.Code,
+		Message:   e.Message,
+		RequestID: requestID,
+		Fields:    e.Fields,
+		Details:   e.Details,
+	}}
+}
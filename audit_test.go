@@ -0,0 +1,145 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mifaabiyyu/go-test.git/auth"
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/controllers"
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+func getAuditLog(t *testing.T, r *gin.Engine, query string) []models.AuditEntry {
+	t.Helper()
+	w := performRequest(t, r, "GET", "/audit"+query, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list controllers.ListResponse[models.AuditEntry]
+	decodeBody(t, w, &list)
+	return list.Data
+}
+
+// changeOf returns the change an entry records for field.
+func changeOf(entry models.AuditEntry, field string) *models.AuditChange {
+	for _, change := range entry.Changes {
+		if change.Field == field {
+			return &change
+		}
+	}
+	return nil
+}
+
+// TestAuditLog changes a customer and a transaction and checks what the
+// audit log says about each change.
+func TestAuditLog(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			r, _ := backend.router(t, testConfig())
+
+			w := performRequestWithHeader(t, r, "POST", "/customers", models.Customer{Name: "Budi", Code: "C001", Email: "budi@example.com"}, "X-User", "sari")
+			assert.Equal(t, http.StatusCreated, w.Code)
+			var customer models.Customer
+			decodeBody(t, w, &customer)
+			customerPath := "/customers/" + customer.ID.Hex()
+
+			w = performRequestWithHeader(t, r, "PUT", customerPath, models.Customer{Name: "Budi", Code: "C001", Email: "budi@example.org"}, "X-User", "sari")
+			assert.Equal(t, http.StatusOK, w.Code)
+			w = performRequestWithHeader(t, r, "DELETE", customerPath, nil, "X-User", "andi")
+			assert.Equal(t, http.StatusOK, w.Code)
+			// A change that fails is not recorded
+			w = performRequest(t, r, "PUT", customerPath, models.Customer{Name: "Budi", Code: "C001", Email: "budi@example.net"})
+			assert.Equal(t, http.StatusNotFound, w.Code)
+
+			entries := getAuditLog(t, r, "?entity_type=customer&entity_id="+customer.ID.Hex())
+			if assert.Len(t, entries, 3) {
+				// Newest first
+				deleted, updated, created := entries[0], entries[1], entries[2]
+
+				assert.Equal(t, models.AuditCreate, created.Action)
+				assert.Equal(t, "sari", created.Actor)
+				assert.Equal(t, customer.ID, created.EntityID)
+				assert.NotEmpty(t, created.RequestID)
+				assert.False(t, created.CreatedAt.IsZero())
+				if change := changeOf(created, "name"); assert.NotNil(t, change) {
+					assert.Empty(t, change.Before)
+					assert.Equal(t, models.AuditValue(`"Budi"`), change.After)
+				}
+
+				assert.Equal(t, models.AuditUpdate, updated.Action)
+				assert.Equal(t, []models.AuditChange{{Field: "email", Before: `"budi@example.com"`, After: `"budi@example.org"`}}, updated.Changes)
+
+				assert.Equal(t, models.AuditDelete, deleted.Action)
+				assert.Equal(t, "andi", deleted.Actor)
+				if assert.Len(t, deleted.Changes, 1) {
+					assert.Equal(t, "deleted_at", deleted.Changes[0].Field)
+					assert.Empty(t, deleted.Changes[0].Before)
+					assert.NotEmpty(t, deleted.Changes[0].After)
+				}
+			}
+
+			var product models.Product
+			decodeBody(t, performRequest(t, r, "POST", "/product", models.Product{Code: "P001", Name: "Kopi", Price: idr(12000), StockQuantity: 10}), &product)
+			w = performRequest(t, r, "POST", "/customers/"+customer.ID.Hex()+"/restore", nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+			w = performRequest(t, r, "POST", "/transaction", transactionRequest{
+				Transaction: models.Transaction{CustomerID: customer.ID},
+				Details:     []models.TransactionDetail{{ProductID: product.ID, Quantity: 2}},
+			})
+			assert.Equal(t, http.StatusCreated, w.Code)
+			var created controllers.CreateTransactionResponse
+			decodeBody(t, w, &created)
+			w = performRequest(t, r, "DELETE", "/transaction/"+created.Transaction.ID.Hex(), nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			// A removed transaction is kept whole, details included
+			entries = getAuditLog(t, r, "?entity_type=transaction&action=delete")
+			if assert.Len(t, entries, 1) {
+				assert.Equal(t, created.Transaction.ID, entries[0].EntityID)
+				for _, change := range entries[0].Changes {
+					assert.NotEmpty(t, change.Before, change.Field)
+					assert.Empty(t, change.After, change.Field)
+				}
+				if change := changeOf(entries[0], "details"); assert.NotNil(t, change) {
+					assert.Contains(t, string(change.Before), product.ID.Hex())
+				}
+			}
+
+			assert.Len(t, getAuditLog(t, r, "?actor=andi"), 1)
+			assert.Len(t, getAuditLog(t, r, "?entity_type=customer&action=restore"), 1)
+			assert.Len(t, getAuditLog(t, r, "?entity_type=product"), 1)
+			assert.Len(t, getAuditLog(t, r, "?date_from="+time.Now().Add(time.Hour).Format(time.RFC3339)), 0)
+			assert.Len(t, getAuditLog(t, r, ""), 7)
+		})
+	}
+}
+
+func TestAuditLogRecordsAuthenticatedActor(t *testing.T) {
+	r, repos := newAuthRouter(t, config.Default())
+	key := issueTestKey(t, repos, "back-office", models.RoleAdmin)
+
+	w := performRequestWithHeader(t, r, "POST", "/payment-method", models.PaymentMethod{Name: "Cash", IsActive: true}, auth.APIKeyHeader, key)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// X-User is only trusted when authentication is disabled
+	w = performRequestWithHeader(t, r, "GET", "/audit?entity_type=payment_method", nil, auth.APIKeyHeader, key)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list controllers.ListResponse[models.AuditEntry]
+	decodeBody(t, w, &list)
+	if assert.Len(t, list.Data, 1) {
+		assert.Equal(t, "back-office", list.Data[0].Actor)
+		assert.Equal(t, models.AuditCreate, list.Data[0].Action)
+	}
+}
+
+func TestAuditLogRejectsUnknownFilters(t *testing.T) {
+	r, _ := newTestRouter()
+
+	w := performRequest(t, r, "GET", "/audit?entity_id=not-an-id", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(t, r, "GET", "/audit?order_by=changes", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// APIKeyPrefix starts every API key, which tells them apart from JWTs.
+const APIKeyPrefix = "gtk_"
+
+// shownPrefixLength is how much of a key is kept in the clear, so that it
+// can be recognised in lists.
+const shownPrefixLength = len(APIKeyPrefix) + 6
+
+// HashAPIKey returns the hex SHA-256 hash a key is stored and looked up
+// by. Keys are 256 random bits, so a fast hash is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIKey reports whether credential looks like an API key.
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
+
+// IssueAPIKey generates a new API key named name with the given roles and
+// stores its hash. The returned key is the only copy; it cannot be
+// recovered later.
+func IssueAPIKey(ctx context.Context, keys repository.APIKeyRepository, name string, roles []models.Role, createdBy string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	var secret [32]byte
+	if _, err := rand.Read(secret[:]); err != nil {
+		return nil, "", err
+	}
+	key := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret[:])
+
+	apiKey := &models.APIKey{
+		ID:        primitive.NewObjectID(),
+		Name:      name,
+		Prefix:    key[:shownPrefixLength],
+		Hash:      HashAPIKey(key),
+		Roles:     roles,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	if err := keys.Create(ctx, apiKey); err != nil {
+		return nil, "", err
+	}
+	return apiKey, key, nil
+}
@@ -0,0 +1,126 @@
+// Package auth authenticates API requests. Integrations use API keys,
+// which are stored hashed; dashboards use JWT bearer tokens signed with
+// locally configured keys.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// APIKeyHeader carries an API key. Keys are also accepted as bearer tokens.
+const APIKeyHeader = "X-API-Key"
+
+var (
+	// ErrNoCredentials is returned when a request carries no credentials.
+	ErrNoCredentials = errors.New("auth: no credentials")
+	// ErrInvalidCredentials is returned, wrapped with the reason, when a
+	// request's credentials are not accepted.
+	ErrInvalidCredentials = errors.New("auth: invalid credentials")
+)
+
+// Method is how a principal authenticated.
+type Method string
+
+const (
+	MethodAPIKey Method = "api_key"
+	MethodJWT    Method = "jwt"
+)
+
+// Principal is who made a request.
+type Principal struct {
+	// Subject is the name of the API key or the sub claim of the JWT.
+	Subject string
+	Method  Method
+	// KeyID is the API key used, if any.
+	KeyID primitive.ObjectID
+	Roles []models.Role
+}
+
+// HasAnyRole reports whether p has at least one of roles.
+func (p *Principal) HasAnyRole(roles ...models.Role) bool {
+	for _, held := range p.Roles {
+		for _, role := range roles {
+			if held == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authenticator resolves request credentials to a Principal.
+type Authenticator struct {
+	keys repository.APIKeyRepository
+	jwt  *JWT
+	now  func() time.Time
+}
+
+// New builds an Authenticator checking API keys against keys and JWTs as
+// cfg describes.
+func New(cfg config.AuthConfig, keys repository.APIKeyRepository) (*Authenticator, error) {
+	jwt, err := NewJWT(cfg.JWT)
+	if err != nil {
+		return nil, err
+	}
+	return &Authenticator{keys: keys, jwt: jwt, now: time.Now}, nil
+}
+
+// Authenticate checks the credentials in header: an X-API-Key header, or
+// an Authorization header with a bearer API key or JWT.
+func (a *Authenticator) Authenticate(ctx context.Context, header http.Header) (*Principal, error) {
+	if key := header.Get(APIKeyHeader); key != "" {
+		return a.authenticateAPIKey(ctx, key)
+	}
+
+	authorization := header.Get("Authorization")
+	if authorization == "" {
+		return nil, ErrNoCredentials
+	}
+	scheme, token, found := strings.Cut(authorization, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, fmt.Errorf("%w: expected a bearer token", ErrInvalidCredentials)
+	}
+	if IsAPIKey(token) {
+		return a.authenticateAPIKey(ctx, token)
+	}
+	return a.authenticateJWT(token)
+}
+
+func (a *Authenticator) authenticateAPIKey(ctx context.Context, key string) (*Principal, error) {
+	apiKey, err := a.keys.FindByHash(ctx, HashAPIKey(key))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+	} else if err != nil {
+		return nil, err
+	}
+	if !apiKey.ActiveAt(a.now()) {
+		return nil, fmt.Errorf("%w: API key is revoked or expired", ErrInvalidCredentials)
+	}
+	return &Principal{Subject: apiKey.Name, Method: MethodAPIKey, KeyID: apiKey.ID, Roles: apiKey.Roles}, nil
+}
+
+func (a *Authenticator) authenticateJWT(token string) (*Principal, error) {
+	if a.jwt == nil {
+		return nil, fmt.Errorf("%w: bearer tokens are not accepted", ErrInvalidCredentials)
+	}
+	claims, err := a.jwt.Verify(token, a.now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	roles := make([]models.Role, len(claims.Roles))
+	for i, role := range claims.Roles {
+		roles[i] = models.Role(role)
+	}
+	return &Principal{Subject: claims.Subject, Method: MethodJWT, Roles: roles}, nil
+}
@@ -0,0 +1,294 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+// clockSkew is how far the clocks of the token issuer and this service
+// may disagree when expiry and not-before times are checked.
+const clockSkew = 30 * time.Second
+
+// Claims are the registered JWT claims the service understands.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	// Roles is a private claim listing the roles of the subject.
+	Roles []string `json:"roles,omitempty"`
+}
+
+// Audience is the aud claim, which is either a single string or an array
+// of strings.
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return errors.New("aud must be a string or an array of strings")
+	}
+	*a = many
+	return nil
+}
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a Audience) contains(audience string) bool {
+	for _, candidate := range a {
+		if candidate == audience {
+			return true
+		}
+	}
+	return false
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// JWT signs and verifies compact JWS tokens with the single algorithm and
+// key configured. Tokens naming any other algorithm are rejected, which
+// rules out "none" and HS256 tokens signed with an RS256 public key.
+type JWT struct {
+	algorithm  string
+	secret     []byte
+	publicKey  *rsa.PublicKey
+	privateKey *rsa.PrivateKey
+	issuer     string
+	audience   string
+	ttl        time.Duration
+}
+
+// NewJWT loads the keys cfg points at. It returns nil when no algorithm is
+// configured, in which case JWTs are not accepted.
+func NewJWT(cfg config.JWTConfig) (*JWT, error) {
+	j := &JWT{
+		algorithm: cfg.Algorithm,
+		issuer:    cfg.Issuer,
+		audience:  cfg.Audience,
+		ttl:       cfg.TTL.Duration,
+	}
+	switch cfg.Algorithm {
+	case "":
+		return nil, nil
+	case config.AlgorithmHS256:
+		j.secret = []byte(cfg.Secret)
+	case config.AlgorithmRS256:
+		publicKey, err := readPublicKey(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		j.publicKey = publicKey
+		if cfg.PrivateKeyFile != "" {
+			privateKey, err := readPrivateKey(cfg.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			j.privateKey = privateKey
+		}
+	default:
+		return nil, fmt.Errorf("auth: unsupported JWT algorithm %q", cfg.Algorithm)
+	}
+	return j, nil
+}
+
+// Issue signs a token for subject with the given roles that expires after
+// the configured TTL, carrying the configured issuer and audience.
+func (j *JWT) Issue(subject string, roles []models.Role, now time.Time) (string, error) {
+	claims := Claims{
+		Subject:   subject,
+		Issuer:    j.issuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(j.ttl).Unix(),
+	}
+	for _, role := range roles {
+		claims.Roles = append(claims.Roles, string(role))
+	}
+	if j.audience != "" {
+		claims.Audience = Audience{j.audience}
+	}
+	return j.Sign(claims)
+}
+
+// Sign encodes and signs claims as they are.
+func (j *JWT) Sign(claims Claims) (string, error) {
+	headerJSON, err := json.Marshal(header{Algorithm: j.algorithm, Type: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := encodeSegment(headerJSON) + "." + encodeSegment(claimsJSON)
+
+	var signature []byte
+	switch j.algorithm {
+	case config.AlgorithmHS256:
+		signature = hmacSHA256(j.secret, signingInput)
+	case config.AlgorithmRS256:
+		if j.privateKey == nil {
+			return "", errors.New("auth: no RS256 private key configured")
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		signature, err = rsa.SignPKCS1v15(rand.Reader, j.privateKey, crypto.SHA256, digest[:])
+		if err != nil {
+			return "", err
+		}
+	}
+	return signingInput + "." + encodeSegment(signature), nil
+}
+
+// Verify checks the signature, expiry, not-before time, issuer and audience
+// of token and returns its claims. Tokens must expire and name a subject.
+func (j *JWT) Verify(token string, now time.Time) (*Claims, error) {
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var h header
+	if err := decodeSegment(segments[0], &h); err != nil {
+		return nil, fmt.Errorf("malformed token header: %w", err)
+	}
+	if h.Algorithm != j.algorithm {
+		return nil, fmt.Errorf("unexpected signing algorithm %q", h.Algorithm)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return nil, errors.New("malformed token signature")
+	}
+	signingInput := segments[0] + "." + segments[1]
+	switch j.algorithm {
+	case config.AlgorithmHS256:
+		if !hmac.Equal(signature, hmacSHA256(j.secret, signingInput)) {
+			return nil, errors.New("invalid token signature")
+		}
+	case config.AlgorithmRS256:
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(j.publicKey, crypto.SHA256, digest[:], signature); err != nil {
+			return nil, errors.New("invalid token signature")
+		}
+	}
+
+	var claims Claims
+	if err := decodeSegment(segments[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+	switch {
+	case claims.Subject == "":
+		return nil, errors.New("token has no subject")
+	case claims.ExpiresAt == 0:
+		return nil, errors.New("token has no expiry")
+	case now.Add(-clockSkew).Unix() >= claims.ExpiresAt:
+		return nil, errors.New("token has expired")
+	case claims.NotBefore != 0 && now.Add(clockSkew).Unix() < claims.NotBefore:
+		return nil, errors.New("token is not valid yet")
+	case j.issuer != "" && claims.Issuer != j.issuer:
+		return nil, errors.New("token has the wrong issuer")
+	case j.audience != "" && !claims.Audience.contains(j.audience):
+		return nil, errors.New("token has the wrong audience")
+	}
+	return &claims, nil
+}
+
+func hmacSHA256(secret []byte, input string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(input))
+	return mac.Sum(nil)
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("auth: read %s: %w", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("auth: %s is not PEM encoded", path)
+	}
+	return block, nil
+}
+
+// readPublicKey reads an RSA public key in PKIX ("PUBLIC KEY") or PKCS #1
+// ("RSA PUBLIC KEY") form.
+func readPublicKey(path string) (*rsa.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("auth: parse %s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("auth: %s is not an RSA public key", path)
+	}
+	return key, nil
+}
+
+// readPrivateKey reads an RSA private key in PKCS #8 ("PRIVATE KEY") or
+// PKCS #1 ("RSA PRIVATE KEY") form.
+func readPrivateKey(path string) (*rsa.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("auth: parse %s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("auth: %s is not an RSA private key", path)
+	}
+	return key, nil
+}
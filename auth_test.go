@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/auth"
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/controllers"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+	"github.com/mifaabiyyu/go-test.git/repository/memory"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// newAuthRouter returns the application router with authentication
+// enabled as cfg describes, backed by a fresh in-memory store.
+func newAuthRouter(t *testing.T, cfg *config.Config) (*gin.Engine, *repository.Repositories) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	repos := memory.NewRepositories()
+	r, err := setupRouter(repos, cfg)
+	if err != nil {
+		t.Fatalf("Failed to set up router: %v", err)
+	}
+	return r, repos
+}
+
+// hs256Config enables authentication with HS256 JWTs.
+func hs256Config() *config.Config {
+	cfg := config.Default()
+	cfg.Auth.JWT.Algorithm = config.AlgorithmHS256
+	cfg.Auth.JWT.Secret = testSecret
+	cfg.Auth.JWT.Issuer = "go-test"
+	cfg.Auth.JWT.Audience = "api"
+	return cfg
+}
+
+// performRequestWithHeader is performRequest with one extra header set.
+func performRequestWithHeader(t *testing.T, r http.Handler, method, path string, body interface{}, key, value string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatalf("Failed to marshal JSON data: %v", err)
+		}
+	}
+
+	req, _ := http.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(key, value)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// issueTestKey stores an API key with the given roles for tests to
+// authenticate with.
+func issueTestKey(t *testing.T, repos *repository.Repositories, name string, roles ...models.Role) string {
+	t.Helper()
+	_, key, err := auth.IssueAPIKey(context.Background(), repos.APIKeys, name, roles, "test", nil)
+	if err != nil {
+		t.Fatalf("Failed to issue API key: %v", err)
+	}
+	return key
+}
+
+func bearer(token string) string {
+	return "Bearer " + token
+}
+
+func TestRoutesRequireCredentials(t *testing.T) {
+	r, _ := newAuthRouter(t, config.Default())
+
+	for _, path := range []string{"/products", "/transactions", "/api-keys", "/no-such-route"} {
+		w := performRequest(t, r, "GET", path, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, path)
+		assert.Equal(t, apperror.CodeUnauthorized, decodeError(t, w).Code, path)
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer", path)
+	}
+
+	w := performRequest(t, r, "DELETE", "/customers/64b7f0c2a1b2c3d4e5f60718", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = performRequestWithHeader(t, r, "GET", "/products", nil, "Authorization", "Basic dXNlcjpwYXNz")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = performRequestWithHeader(t, r, "GET", "/products", nil, auth.APIKeyHeader, "gtk_not-a-real-key")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	// Without a configured algorithm, bearer JWTs are not accepted at all
+	w = performRequestWithHeader(t, r, "GET", "/products", nil, "Authorization", bearer("a.b.c"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestAPIKeyLifecycle issues a key over HTTP with a bootstrap key, uses
+// it, and checks it stops working once revoked.
+func TestAPIKeyLifecycle(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			r, repos := backend.router(t, config.Default())
+			bootstrap := issueTestKey(t, repos, "bootstrap", models.RoleAdmin)
+
+			w := performRequestWithHeader(t, r, "POST", "/api-keys", map[string]interface{}{"name": "pos-terminal", "roles": []string{"admin"}}, auth.APIKeyHeader, bootstrap)
+			assert.Equal(t, http.StatusCreated, w.Code)
+			var issued struct {
+				models.APIKey
+				Key  string `json:"key"`
+				Hash string `json:"hash"`
+			}
+			decodeBody(t, w, &issued)
+			assert.Equal(t, "pos-terminal", issued.Name)
+			assert.Equal(t, "bootstrap", issued.CreatedBy)
+			assert.Equal(t, []models.Role{models.RoleAdmin}, issued.Roles)
+			assert.True(t, auth.IsAPIKey(issued.Key))
+			assert.Equal(t, issued.Key[:len(issued.Prefix)], issued.Prefix)
+			assert.Empty(t, issued.Hash)
+
+			// Keys work both as X-API-Key and as bearer tokens
+			w = performRequestWithHeader(t, r, "GET", "/products", nil, auth.APIKeyHeader, issued.Key)
+			assert.Equal(t, http.StatusOK, w.Code)
+			w = performRequestWithHeader(t, r, "GET", "/products", nil, "Authorization", bearer(issued.Key))
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			// The key's name is recorded as who made a change
+			w = performRequestWithHeader(t, r, "POST", "/product", models.Product{Code: "P001", Name: "Kopi", Price: idr(12000)}, auth.APIKeyHeader, issued.Key)
+			assert.Equal(t, http.StatusCreated, w.Code)
+			var product models.Product
+			decodeBody(t, w, &product)
+			w = performRequestWithHeader(t, r, "GET", "/product/"+product.ID.Hex()+"/history", nil, auth.APIKeyHeader, issued.Key)
+			var history controllers.ListResponse[models.ProductVersion]
+			decodeBody(t, w, &history)
+			if assert.Len(t, history.Data, 1) {
+				assert.Equal(t, "pos-terminal", history.Data[0].User)
+			}
+
+			w = performRequestWithHeader(t, r, "GET", "/api-keys", nil, auth.APIKeyHeader, bootstrap)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.NotContains(t, w.Body.String(), issued.Key)
+			assert.NotContains(t, w.Body.String(), auth.HashAPIKey(issued.Key))
+			var list controllers.ListResponse[models.APIKey]
+			decodeBody(t, w, &list)
+			assert.Len(t, list.Data, 2)
+
+			w = performRequestWithHeader(t, r, "DELETE", "/api-keys/"+issued.ID.Hex(), nil, auth.APIKeyHeader, bootstrap)
+			assert.Equal(t, http.StatusOK, w.Code)
+			var revoked models.APIKey
+			decodeBody(t, w, &revoked)
+			assert.NotNil(t, revoked.RevokedAt)
+
+			w = performRequestWithHeader(t, r, "GET", "/products", nil, auth.APIKeyHeader, issued.Key)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			w = performRequestWithHeader(t, r, "DELETE", "/api-keys/"+issued.ID.Hex(), nil, auth.APIKeyHeader, bootstrap)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+		})
+	}
+}
+
+func TestExpiredAPIKeysAreRejected(t *testing.T) {
+	r, repos := newAuthRouter(t, config.Default())
+	bootstrap := issueTestKey(t, repos, "bootstrap", models.RoleAdmin)
+
+	w := performRequestWithHeader(t, r, "POST", "/api-keys", map[string]interface{}{"name": "old", "roles": []string{"viewer"}, "expires_at": time.Now().Add(-time.Hour)}, auth.APIKeyHeader, bootstrap)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	expired := time.Now().Add(-time.Minute)
+	_, key, err := auth.IssueAPIKey(context.Background(), repos.APIKeys, "old", []models.Role{models.RoleViewer}, "test", &expired)
+	assert.NoError(t, err)
+	w = performRequestWithHeader(t, r, "GET", "/products", nil, auth.APIKeyHeader, key)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestHS256Tokens(t *testing.T) {
+	cfg := hs256Config()
+	r, _ := newAuthRouter(t, cfg)
+	jwt, err := auth.NewJWT(cfg.Auth.JWT)
+	if err != nil {
+		t.Fatalf("Failed to load JWT keys: %v", err)
+	}
+	now := time.Now()
+
+	token, err := jwt.Issue("dashboard", []models.Role{models.RoleViewer}, now)
+	assert.NoError(t, err)
+	w := performRequestWithHeader(t, r, "GET", "/products", nil, "Authorization", bearer(token))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	valid := auth.Claims{Subject: "dashboard", Issuer: "go-test", Audience: auth.Audience{"api"}, ExpiresAt: now.Add(time.Hour).Unix(), Roles: []string{"viewer"}}
+	rejected := map[string]auth.Claims{}
+	expired := valid
+	expired.ExpiresAt = now.Add(-time.Hour).Unix()
+	rejected["expired"] = expired
+	noExpiry := valid
+	noExpiry.ExpiresAt = 0
+	rejected["no expiry"] = noExpiry
+	notYet := valid
+	notYet.NotBefore = now.Add(time.Hour).Unix()
+	rejected["not yet valid"] = notYet
+	wrongIssuer := valid
+	wrongIssuer.Issuer = "someone-else"
+	rejected["wrong issuer"] = wrongIssuer
+	wrongAudience := valid
+	wrongAudience.Audience = auth.Audience{"other"}
+	rejected["wrong audience"] = wrongAudience
+	noSubject := valid
+	noSubject.Subject = ""
+	rejected["no subject"] = noSubject
+
+	for name, claims := range rejected {
+		token, err := jwt.Sign(claims)
+		assert.NoError(t, err, name)
+		w := performRequestWithHeader(t, r, "GET", "/products", nil, "Authorization", bearer(token))
+		assert.Equal(t, http.StatusUnauthorized, w.Code, name)
+	}
+
+	// A token signed with another secret, and one claiming no signature
+	other := hs256Config().Auth.JWT
+	other.Secret = "fedcba9876543210fedcba9876543210"
+	otherJWT, err := auth.NewJWT(other)
+	assert.NoError(t, err)
+	forged, err := otherJWT.Sign(valid)
+	assert.NoError(t, err)
+	w = performRequestWithHeader(t, r, "GET", "/products", nil, "Authorization", bearer(forged))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	claimsJSON, _ := json.Marshal(valid)
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON) + "."
+	w = performRequestWithHeader(t, r, "GET", "/products", nil, "Authorization", bearer(unsigned))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// writeRSAKeys writes a fresh RSA key pair as PEM files and returns their
+// paths.
+func writeRSAKeys(t *testing.T) (publicKeyFile, privateKeyFile string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to encode RSA public key: %v", err)
+	}
+
+	dir := t.TempDir()
+	publicKeyFile = filepath.Join(dir, "public.pem")
+	privateKeyFile = filepath.Join(dir, "private.pem")
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(publicKeyFile, publicPEM, 0o600); err != nil {
+		t.Fatalf("Failed to write public key: %v", err)
+	}
+	if err := os.WriteFile(privateKeyFile, privatePEM, 0o600); err != nil {
+		t.Fatalf("Failed to write private key: %v", err)
+	}
+	return publicKeyFile, privateKeyFile
+}
+
+func TestRS256Tokens(t *testing.T) {
+	publicKeyFile, privateKeyFile := writeRSAKeys(t)
+	cfg := config.Default()
+	cfg.Auth.JWT.Algorithm = config.AlgorithmRS256
+	cfg.Auth.JWT.PublicKeyFile = publicKeyFile
+	cfg.Auth.JWT.PrivateKeyFile = privateKeyFile
+	r, _ := newAuthRouter(t, cfg)
+
+	var out bytes.Buffer
+	assert.NoError(t, issueToken(&out, cfg, []string{"dashboard", "viewer"}))
+	token := bytes.TrimSpace(out.Bytes())
+	w := performRequestWithHeader(t, r, "GET", "/products", nil, "Authorization", bearer(string(token)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// An HS256 token keyed with the public key must not pass as RS256
+	publicPEM, err := os.ReadFile(publicKeyFile)
+	assert.NoError(t, err)
+	hs := config.JWTConfig{Algorithm: config.AlgorithmHS256, Secret: string(publicPEM), TTL: config.Duration{Duration: time.Hour}}
+	hsJWT, err := auth.NewJWT(hs)
+	assert.NoError(t, err)
+	confused, err := hsJWT.Issue("dashboard", []models.Role{models.RoleViewer}, time.Now())
+	assert.NoError(t, err)
+	w = performRequestWithHeader(t, r, "GET", "/products", nil, "Authorization", bearer(confused))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestInvalidJWTKeysFailSetup(t *testing.T) {
+	cfg := config.Default()
+	cfg.Auth.JWT.Algorithm = config.AlgorithmRS256
+	cfg.Auth.JWT.PublicKeyFile = filepath.Join(t.TempDir(), "missing.pem")
+
+	_, err := setupRouter(memory.NewRepositories(), cfg)
+	assert.Error(t, err)
+}
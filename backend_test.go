@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/controllers"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+	"github.com/mifaabiyyu/go-test.git/repository/gormdb"
+	"github.com/mifaabiyyu/go-test.git/repository/memory"
+)
+
+// backends lists the storage backends that every backend test runs
+// against. Each router is backed by a fresh, empty store and configured
+// with cfg; its repositories are returned for seeding.
+var backends = []struct {
+	name   string
+	router func(t *testing.T, cfg *config.Config) (*gin.Engine, *repository.Repositories)
+}{
+	{"memory", newMemoryRouter},
+	{"sqlite", newSQLiteRouter},
+}
+
+// newMemoryRouter returns the application router backed by a fresh
+// in-memory store.
+func newMemoryRouter(t *testing.T, cfg *config.Config) (*gin.Engine, *repository.Repositories) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	repos := memory.NewRepositories()
+	return mustSetupRouter(repos, cfg), repos
+}
+
+// newSQLiteRouter returns the application router backed by a private
+// in-memory SQLite database.
+func newSQLiteRouter(t *testing.T, cfg *config.Config) (*gin.Engine, *repository.Repositories) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	repos := gormdb.NewRepositories(openSQLite(t, cfg))
+	return mustSetupRouter(repos, cfg), repos
+}
+
+// openSQLite points cfg at a private in-memory SQLite database and opens
+// it, for the tests of what only the SQL backend does.
+func openSQLite(t *testing.T, cfg *config.Config) *gorm.DB {
+	t.Helper()
+	cfg.Storage.Driver = config.DriverSQLite
+	cfg.SQL.DSN = ":memory:"
+
+	db, err := gormdb.Open(cfg)
+	if err != nil {
+		t.Fatalf("Failed to open SQLite: %v", err)
+	}
+	return db
+}
+
+func TestTransactionLifecycle(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			r, _ := backend.router(t, testConfig())
+
+			var customer models.Customer
+			decodeBody(t, performRequest(t, r, "POST", "/customers", models.Customer{Name: "Budi", Code: "C001", Email: "budi@example.com"}), &customer)
+			var product models.Product
+			decodeBody(t, performRequest(t, r, "POST", "/product", models.Product{Code: "P001", Name: "Kopi", Price: idr(12000), StockQuantity: 10}), &product)
+			var paymentMethod models.PaymentMethod
+			decodeBody(t, performRequest(t, r, "POST", "/payment-method", models.PaymentMethod{Name: "Cash", IsActive: true}), &paymentMethod)
+
+			w := performRequest(t, r, "POST", "/transaction", transactionRequest{
+				Transaction: models.Transaction{CustomerID: customer.ID},
+				Details:     []models.TransactionDetail{{ProductID: product.ID, Price: idr(12000), Quantity: 2, Subtotal: idr(24000)}},
+				Payments:    []models.TransactionPayment{{PaymentMethodID: paymentMethod.ID, PaidAmount: idr(24000)}},
+			})
+			assert.Equal(t, http.StatusCreated, w.Code)
+			var created controllers.CreateTransactionResponse
+			decodeBody(t, w, &created)
+
+			w = performRequest(t, r, "GET", "/transaction/"+created.Transaction.ID.Hex(), nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+			var transaction models.Transaction
+			decodeBody(t, w, &transaction)
+			assert.Equal(t, customer.ID, transaction.CustomerID)
+			assert.Len(t, transaction.Details, 1)
+			assert.Len(t, transaction.Payments, 1)
+
+			var stocked models.Product
+			decodeBody(t, performRequest(t, r, "GET", "/product/"+product.ID.Hex(), nil), &stocked)
+			assert.Equal(t, 8.0, stocked.StockQuantity)
+
+			// A product still referenced by a transaction detail is only hidden
+			w = performRequest(t, r, "DELETE", "/product/"+product.ID.Hex(), nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+			w = performRequest(t, r, "GET", "/product/"+product.ID.Hex(), nil)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+
+			w = performRequest(t, r, "GET", "/transaction/"+created.Transaction.ID.Hex()+"?embed=product", nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+			decodeBody(t, w, &transaction)
+			if assert.Len(t, transaction.Details, 1) {
+				assert.Equal(t, "Kopi", transaction.Details[0].ProductName)
+			}
+
+			w = performRequest(t, r, "DELETE", "/transaction/"+created.Transaction.ID.Hex(), nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			w = performRequest(t, r, "POST", "/product/"+product.ID.Hex()+"/restore", nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+			decodeBody(t, w, &stocked)
+			assert.Nil(t, stocked.DeletedAt)
+			assert.Equal(t, 10.0, stocked.StockQuantity)
+		})
+	}
+}
+
+func TestSQLiteEnforcesForeignKeys(t *testing.T) {
+	repos := gormdb.NewRepositories(openSQLite(t, testConfig()))
+
+	// The customer does not exist, so the foreign key rejects the insert
+	err := repos.Transactions.Create(context.Background(), &models.Transaction{
+		ID:         primitive.NewObjectID(),
+		CustomerID: primitive.NewObjectID(),
+	})
+	assert.Equal(t, repository.ErrForeignKey, err)
+}
+
+func TestUnitOfWorkRollsBack(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			_, repos := backend.router(t, testConfig())
+			ctx := context.Background()
+
+			customer := models.Customer{ID: primitive.NewObjectID(), Name: "Budi", Code: "C001", Email: "budi@example.com"}
+			failure := errors.New("second write failed")
+			err := repos.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+				if err := repos.Customers.Create(txCtx, &customer); err != nil {
+					return err
+				}
+				return failure
+			})
+			assert.Equal(t, failure, err)
+
+			_, err = repos.Customers.FindByID(ctx, customer.ID)
+			assert.Equal(t, repository.ErrNotFound, err)
+		})
+	}
+}
+
+func TestUnitOfWorkRollbackKeepsOutsideWrites(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			_, repos := backend.router(t, testConfig())
+			ctx := context.Background()
+
+			inside := models.Customer{ID: primitive.NewObjectID(), Name: "Budi", Code: "C001", Email: "budi@example.com"}
+			outside := models.Customer{ID: primitive.NewObjectID(), Name: "Sari", Code: "C002", Email: "sari@example.com"}
+			failure := errors.New("second write failed")
+
+			started := make(chan struct{})
+			done := make(chan error)
+			go func() {
+				done <- repos.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+					if err := repos.Customers.Create(txCtx, &inside); err != nil {
+						return err
+					}
+					close(started)
+					time.Sleep(20 * time.Millisecond)
+					return failure
+				})
+			}()
+
+			// The write waits for the transaction instead of being rolled back with it
+			<-started
+			assert.NoError(t, repos.Customers.Create(ctx, &outside))
+			assert.Equal(t, failure, <-done)
+
+			_, err := repos.Customers.FindByID(ctx, inside.ID)
+			assert.Equal(t, repository.ErrNotFound, err)
+			_, err = repos.Customers.FindByID(ctx, outside.ID)
+			assert.NoError(t, err)
+		})
+	}
+}
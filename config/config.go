@@ -0,0 +1,413 @@
+// config/config.go
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting the API needs at startup. Values are resolved
+// in three layers: built-in defaults, then an optional YAML/TOML file, then
+// environment variables.
+type Config struct {
+	Storage StorageConfig `yaml:"storage" toml:"storage"`
+	Mongo   MongoConfig   `yaml:"mongo" toml:"mongo"`
+	SQL     SQLConfig     `yaml:"sql" toml:"sql"`
+	Server  ServerConfig  `yaml:"server" toml:"server"`
+	Auth    AuthConfig    `yaml:"auth" toml:"auth"`
+	Tax     TaxConfig     `yaml:"tax" toml:"tax"`
+}
+
+// Storage drivers accepted by StorageConfig.Driver.
+const (
+	DriverMongoDB = "mongodb"
+	DriverMySQL   = "mysql"
+	DriverSQLite  = "sqlite"
+)
+
+type StorageConfig struct {
+	Driver           string   `yaml:"driver" toml:"driver"`
+	OperationTimeout Duration `yaml:"operation_timeout" toml:"operation_timeout"`
+}
+
+type MongoConfig struct {
+	URI            string   `yaml:"uri" toml:"uri"`
+	Database       string   `yaml:"database" toml:"database"`
+	ConnectTimeout Duration `yaml:"connect_timeout" toml:"connect_timeout"`
+	MaxPoolSize    uint64   `yaml:"max_pool_size" toml:"max_pool_size"`
+	MinPoolSize    uint64   `yaml:"min_pool_size" toml:"min_pool_size"`
+}
+
+// SQLConfig configures the relational backend used by the mysql and sqlite
+// drivers. DSN is passed to the driver unchanged, e.g.
+// "user:pass@tcp(localhost:3306)/shop?parseTime=true" or "file:shop.db".
+type SQLConfig struct {
+	DSN             string   `yaml:"dsn" toml:"dsn"`
+	MaxOpenConns    int      `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	AutoMigrate     bool     `yaml:"auto_migrate" toml:"auto_migrate"`
+}
+
+type ServerConfig struct {
+	Addr         string   `yaml:"addr" toml:"addr"`
+	ReadTimeout  Duration `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout Duration `yaml:"write_timeout" toml:"write_timeout"`
+}
+
+// AuthConfig decides how requests are authenticated. API keys are always
+// accepted while Enabled; JWT bearer tokens only when JWT.Algorithm is set.
+type AuthConfig struct {
+	Enabled bool      `yaml:"enabled" toml:"enabled"`
+	JWT     JWTConfig `yaml:"jwt" toml:"jwt"`
+}
+
+// JWT signing algorithms accepted by JWTConfig.Algorithm.
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+)
+
+// JWTConfig holds the locally configured keys JWTs are checked with. HS256
+// uses Secret; RS256 uses the PEM public key at PublicKeyFile, and the
+// private key at PrivateKeyFile when tokens are issued with issue-token.
+// Tokens must name Issuer and Audience when they are set.
+type JWTConfig struct {
+	Algorithm      string   `yaml:"algorithm" toml:"algorithm"`
+	Secret         string   `yaml:"secret" toml:"secret"`
+	PublicKeyFile  string   `yaml:"public_key_file" toml:"public_key_file"`
+	PrivateKeyFile string   `yaml:"private_key_file" toml:"private_key_file"`
+	Issuer         string   `yaml:"issuer" toml:"issuer"`
+	Audience       string   `yaml:"audience" toml:"audience"`
+	TTL            Duration `yaml:"ttl" toml:"ttl"`
+}
+
+// Tax rounding modes accepted by TaxConfig.Rounding.
+const (
+	// RoundingLine rounds the tax of every line on its own; the tax of a
+	// transaction is the sum of its lines.
+	RoundingLine = "line"
+	// RoundingTotal rounds the tax once per rate, on the sum of the lines
+	// taxed at it, and spreads it over those lines.
+	RoundingTotal = "total"
+)
+
+// TaxConfig sets the tax charged on transactions. Rates are percentages:
+// Rate applies to every product unless its category has a rate of its own
+// in Categories or is listed in Exempt. With Inclusive, catalogue prices
+// already include the tax.
+type TaxConfig struct {
+	Rate       float64            `yaml:"rate" toml:"rate"`
+	Inclusive  bool               `yaml:"inclusive" toml:"inclusive"`
+	Rounding   string             `yaml:"rounding" toml:"rounding"`
+	Categories map[string]float64 `yaml:"categories" toml:"categories"`
+	Exempt     []string           `yaml:"exempt" toml:"exempt"`
+}
+
+// minSecretLength is the shortest HS256 secret accepted, matching the
+// size of the SHA-256 output.
+const minSecretLength = 32
+
+// Duration is a time.Duration written as "5s" or "1m30s" in config files.
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// Default returns the configuration used when nothing else is provided.
+// Connection strings are intentionally left empty so that they must be supplied.
+func Default() *Config {
+	return &Config{
+		Storage: StorageConfig{
+			Driver:           DriverMongoDB,
+			OperationTimeout: Duration{15 * time.Second},
+		},
+		Mongo: MongoConfig{
+			Database:       "mydatabase",
+			ConnectTimeout: Duration{10 * time.Second},
+			MaxPoolSize:    100,
+		},
+		SQL: SQLConfig{
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: Duration{30 * time.Minute},
+			AutoMigrate:     true,
+		},
+		Server: ServerConfig{
+			Addr:         ":8080",
+			ReadTimeout:  Duration{15 * time.Second},
+			WriteTimeout: Duration{15 * time.Second},
+		},
+		Auth: AuthConfig{
+			Enabled: true,
+			JWT: JWTConfig{
+				TTL: Duration{time.Hour},
+			},
+		},
+		Tax: TaxConfig{
+			Rounding: RoundingLine,
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the file at path (if
+// path is not empty) and the environment, and validates the result.
+// When path is empty the CONFIG_FILE environment variable is consulted.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (cfg *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: read %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".toml":
+		err = toml.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf("config: unsupported file type %q (use .yaml, .yml or .toml)", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("config: parse %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (cfg *Config) loadEnv() error {
+	setString(&cfg.Storage.Driver, "STORAGE_DRIVER")
+	setString(&cfg.Mongo.URI, "MONGO_URI")
+	setString(&cfg.Mongo.Database, "MONGO_DATABASE")
+	setString(&cfg.SQL.DSN, "SQL_DSN")
+	setString(&cfg.Server.Addr, "SERVER_ADDR")
+	setString(&cfg.Auth.JWT.Algorithm, "JWT_ALGORITHM")
+	setString(&cfg.Auth.JWT.Secret, "JWT_SECRET")
+	setString(&cfg.Auth.JWT.PublicKeyFile, "JWT_PUBLIC_KEY_FILE")
+	setString(&cfg.Auth.JWT.PrivateKeyFile, "JWT_PRIVATE_KEY_FILE")
+	setString(&cfg.Auth.JWT.Issuer, "JWT_ISSUER")
+	setString(&cfg.Auth.JWT.Audience, "JWT_AUDIENCE")
+	setString(&cfg.Tax.Rounding, "TAX_ROUNDING")
+
+	durations := map[string]*Duration{
+		"STORAGE_OPERATION_TIMEOUT": &cfg.Storage.OperationTimeout,
+		"MONGO_CONNECT_TIMEOUT":     &cfg.Mongo.ConnectTimeout,
+		"SQL_CONN_MAX_LIFETIME":     &cfg.SQL.ConnMaxLifetime,
+		"SERVER_READ_TIMEOUT":       &cfg.Server.ReadTimeout,
+		"SERVER_WRITE_TIMEOUT":      &cfg.Server.WriteTimeout,
+		"JWT_TTL":                   &cfg.Auth.JWT.TTL,
+	}
+	for key, target := range durations {
+		if value, ok := os.LookupEnv(key); ok {
+			if err := target.UnmarshalText([]byte(value)); err != nil {
+				return fmt.Errorf("config: %s: %w", key, err)
+			}
+		}
+	}
+
+	sizes := map[string]*uint64{
+		"MONGO_MAX_POOL_SIZE": &cfg.Mongo.MaxPoolSize,
+		"MONGO_MIN_POOL_SIZE": &cfg.Mongo.MinPoolSize,
+	}
+	for key, target := range sizes {
+		if value, ok := os.LookupEnv(key); ok {
+			parsed, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("config: %s: %w", key, err)
+			}
+			*target = parsed
+		}
+	}
+
+	ints := map[string]*int{
+		"SQL_MAX_OPEN_CONNS": &cfg.SQL.MaxOpenConns,
+		"SQL_MAX_IDLE_CONNS": &cfg.SQL.MaxIdleConns,
+	}
+	for key, target := range ints {
+		if value, ok := os.LookupEnv(key); ok {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("config: %s: %w", key, err)
+			}
+			*target = parsed
+		}
+	}
+
+	if value, ok := os.LookupEnv("SQL_AUTO_MIGRATE"); ok {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("config: SQL_AUTO_MIGRATE: %w", err)
+		}
+		cfg.SQL.AutoMigrate = parsed
+	}
+
+	if value, ok := os.LookupEnv("AUTH_ENABLED"); ok {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("config: AUTH_ENABLED: %w", err)
+		}
+		cfg.Auth.Enabled = parsed
+	}
+
+	if value, ok := os.LookupEnv("TAX_RATE"); ok {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("config: TAX_RATE: %w", err)
+		}
+		cfg.Tax.Rate = parsed
+	}
+
+	if value, ok := os.LookupEnv("TAX_INCLUSIVE"); ok {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("config: TAX_INCLUSIVE: %w", err)
+		}
+		cfg.Tax.Inclusive = parsed
+	}
+
+	return nil
+}
+
+func setString(target *string, key string) {
+	if value, ok := os.LookupEnv(key); ok {
+		*target = value
+	}
+}
+
+// Validate reports every invalid setting at once.
+func (cfg *Config) Validate() error {
+	var problems []string
+
+	if cfg.Storage.OperationTimeout.Duration <= 0 {
+		problems = append(problems, "storage.operation_timeout must be positive")
+	}
+
+	switch cfg.Storage.Driver {
+	case DriverMongoDB:
+		if cfg.Mongo.URI == "" {
+			problems = append(problems, "mongo.uri is required (set MONGO_URI)")
+		} else if !strings.HasPrefix(cfg.Mongo.URI, "mongodb://") && !strings.HasPrefix(cfg.Mongo.URI, "mongodb+srv://") {
+			problems = append(problems, "mongo.uri must start with mongodb:// or mongodb+srv://")
+		}
+		if cfg.Mongo.Database == "" {
+			problems = append(problems, "mongo.database is required")
+		}
+		if cfg.Mongo.ConnectTimeout.Duration <= 0 {
+			problems = append(problems, "mongo.connect_timeout must be positive")
+		}
+		if cfg.Mongo.MaxPoolSize != 0 && cfg.Mongo.MinPoolSize > cfg.Mongo.MaxPoolSize {
+			problems = append(problems, "mongo.min_pool_size cannot exceed mongo.max_pool_size")
+		}
+	case DriverMySQL, DriverSQLite:
+		if cfg.SQL.DSN == "" {
+			problems = append(problems, "sql.dsn is required (set SQL_DSN)")
+		}
+		if cfg.SQL.MaxOpenConns < 0 || cfg.SQL.MaxIdleConns < 0 {
+			problems = append(problems, "sql connection pool sizes cannot be negative")
+		}
+		if cfg.SQL.ConnMaxLifetime.Duration < 0 {
+			problems = append(problems, "sql.conn_max_lifetime cannot be negative")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("storage.driver %q is not supported (use %s, %s or %s)", cfg.Storage.Driver, DriverMongoDB, DriverMySQL, DriverSQLite))
+	}
+
+	if cfg.Server.Addr == "" {
+		problems = append(problems, "server.addr is required")
+	}
+	if cfg.Server.ReadTimeout.Duration < 0 {
+		problems = append(problems, "server.read_timeout cannot be negative")
+	}
+	if cfg.Server.WriteTimeout.Duration < 0 {
+		problems = append(problems, "server.write_timeout cannot be negative")
+	}
+
+	switch jwt := cfg.Auth.JWT; jwt.Algorithm {
+	case "":
+	case AlgorithmHS256:
+		if len(jwt.Secret) < minSecretLength {
+			problems = append(problems, fmt.Sprintf("auth.jwt.secret must be at least %d bytes for HS256 (set JWT_SECRET)", minSecretLength))
+		}
+	case AlgorithmRS256:
+		if jwt.PublicKeyFile == "" {
+			problems = append(problems, "auth.jwt.public_key_file is required for RS256 (set JWT_PUBLIC_KEY_FILE)")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("auth.jwt.algorithm %q is not supported (use %s or %s)", jwt.Algorithm, AlgorithmHS256, AlgorithmRS256))
+	}
+	if cfg.Auth.JWT.TTL.Duration <= 0 {
+		problems = append(problems, "auth.jwt.ttl must be positive")
+	}
+
+	if !validRate(cfg.Tax.Rate) {
+		problems = append(problems, "tax.rate must be between 0 and 100")
+	}
+	categories := make([]string, 0, len(cfg.Tax.Categories))
+	for category := range cfg.Tax.Categories {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		if !validRate(cfg.Tax.Categories[category]) {
+			problems = append(problems, fmt.Sprintf("tax.categories.%s must be between 0 and 100", category))
+		}
+	}
+	switch cfg.Tax.Rounding {
+	case RoundingLine, RoundingTotal:
+	default:
+		problems = append(problems, fmt.Sprintf("tax.rounding %q is not supported (use %s or %s)", cfg.Tax.Rounding, RoundingLine, RoundingTotal))
+	}
+
+	if len(problems) > 0 {
+		return errors.New("config: invalid configuration: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validRate reports whether rate is a tax rate in percent.
+func validRate(rate float64) bool {
+	return rate >= 0 && rate <= 100
+}
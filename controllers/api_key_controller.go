@@ -0,0 +1,132 @@
+// controllers/api_key_controller.go
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/auth"
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type APIKeyController struct {
+	APIKeys    repository.APIKeyRepository
+	AuditLog   repository.AuditRepository
+	UnitOfWork repository.UnitOfWork
+	Config     *config.Config
+}
+
+func NewAPIKeyController(repos *repository.Repositories, cfg *config.Config) *APIKeyController {
+	return &APIKeyController{
+		APIKeys:    repos.APIKeys,
+		AuditLog:   repos.AuditLog,
+		UnitOfWork: repos.UnitOfWork,
+		Config:     cfg,
+	}
+}
+
+type apiKeyRequest struct {
+	Name      string        `json:"name" binding:"required,max=100"`
+	Roles     []models.Role `json:"roles" binding:"required,min=1,dive,oneof=admin accountant cashier viewer"`
+	ExpiresAt *time.Time    `json:"expires_at"`
+}
+
+// issuedAPIKey is the response to issuing a key, the only one that
+// includes the key itself.
+type issuedAPIKey struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKey issues a new API key.
+func (kc *APIKeyController) CreateAPIKey(c *gin.Context) {
+	ctx, cancel := requestContext(c, kc.Config)
+	defer cancel()
+
+	var request apiKeyRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		c.Error(apperror.Validation("expires_at must be in the future").WithField("expires_at", "must be in the future"))
+		return
+	}
+
+	var apiKey *models.APIKey
+	var key string
+	err := kc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		apiKey, key, err = auth.IssueAPIKey(txCtx, kc.APIKeys, request.Name, request.Roles, requestUser(c), request.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		// The key's hash is never rendered, so it is not recorded either
+		return recordAudit(txCtx, c, kc.AuditLog, models.AuditCreate, models.EntityAPIKey, apiKey.ID, nil, apiKey)
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, issuedAPIKey{APIKey: *apiKey, Key: key})
+}
+
+var apiKeyListSpec = listSpec[models.APIKey]{
+	Fields: repository.APIKeyFields,
+	Filters: []filterParam{
+		{Param: "name", Field: "name", Op: repository.OpContains},
+	},
+	DefaultSort: repository.Sort{Field: "created_at", Descending: true},
+}
+
+// GetAPIKeys lists issued keys, revoked ones included. Keys themselves
+// are never listed, only their prefixes.
+func (kc *APIKeyController) GetAPIKeys(c *gin.Context) {
+	ctx, cancel := requestContext(c, kc.Config)
+	defer cancel()
+
+	query, ok := bindListQuery(c, apiKeyListSpec)
+	if !ok {
+		return
+	}
+
+	page, err := kc.APIKeys.List(ctx, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newListResponse(query, page.Total, page.Next, page.Items))
+}
+
+// RevokeAPIKey stops a key from being accepted. The key stays listed.
+func (kc *APIKeyController) RevokeAPIKey(c *gin.Context) {
+	ctx, cancel := requestContext(c, kc.Config)
+	defer cancel()
+
+	keyID, ok := parseID(c, "id", "API key")
+	if !ok {
+		return
+	}
+
+	var apiKey *models.APIKey
+	err := kc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		apiKey, err = auditedChange(txCtx, c, kc.AuditLog, models.AuditDelete, models.EntityAPIKey, keyID, kc.APIKeys.FindByID,
+			func(ctx context.Context, id primitive.ObjectID) error { return kc.APIKeys.Revoke(ctx, id, time.Now()) })
+		return err
+	})
+	if err != nil {
+		c.Error(notFound(err, "API key not found or already revoked"))
+		return
+	}
+
+	c.JSON(http.StatusOK, apiKey)
+}
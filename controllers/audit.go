@@ -0,0 +1,164 @@
+// controllers/audit.go
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/middleware"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type AuditController struct {
+	AuditLog repository.AuditRepository
+	Config   *config.Config
+}
+
+func NewAuditController(repos *repository.Repositories, cfg *config.Config) *AuditController {
+	return &AuditController{
+		AuditLog: repos.AuditLog,
+		Config:   cfg,
+	}
+}
+
+var auditListSpec = listSpec[models.AuditEntry]{
+	Fields: repository.AuditFields,
+	Filters: []filterParam{
+		{Param: "actor", Field: "actor", Op: repository.OpEq},
+		{Param: "action", Field: "action", Op: repository.OpEq},
+		{Param: "entity_type", Field: "entity_type", Op: repository.OpEq},
+		{Param: "entity_id", Field: "entity_id", Op: repository.OpEq},
+		{Param: "date_from", Field: "created_at", Op: repository.OpGte},
+		{Param: "date_to", Field: "created_at", Op: repository.OpLte},
+	},
+	DefaultSort: repository.Sort{Field: "created_at", Descending: true},
+}
+
+// GetAuditLog lists audit entries, newest first.
+func (ac *AuditController) GetAuditLog(c *gin.Context) {
+	ctx, cancel := requestContext(c, ac.Config)
+	defer cancel()
+
+	query, ok := bindListQuery(c, auditListSpec)
+	if !ok {
+		return
+	}
+
+	page, err := ac.AuditLog.List(ctx, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newListResponse(query, page.Total, page.Next, page.Items))
+}
+
+// recordAudit appends an entry for a change to an entity, with the fields
+// that differ between before and after as they are rendered to clients.
+// Pass nil for before on a create and for after on a hard delete. Run it
+// inside the unit of work that makes the change, so a change that rolls
+// back leaves no entry and an entry that cannot be written rolls the
+// change back.
+func recordAudit(ctx context.Context, c *gin.Context, log repository.AuditRepository, action models.AuditAction, entityType string, entityID primitive.ObjectID, before, after interface{}) error {
+	changes, err := diffFields(before, after)
+	if err != nil {
+		return err
+	}
+	return log.Create(ctx, &models.AuditEntry{
+		ID:         primitive.NewObjectID(),
+		Actor:      requestUser(c),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+		RequestID:  middleware.GetRequestID(c),
+		CreatedAt:  time.Now(),
+	})
+}
+
+// diffFields compares the top-level JSON fields of two values. A nested
+// value that changed is recorded whole.
+func diffFields(before, after interface{}) ([]models.AuditChange, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(beforeFields)+len(afterFields))
+	for name := range beforeFields {
+		names = append(names, name)
+	}
+	for name := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := []models.AuditChange{}
+	for _, name := range names {
+		oldValue, newValue := auditValue(beforeFields[name]), auditValue(afterFields[name])
+		if oldValue == newValue {
+			continue
+		}
+		changes = append(changes, models.AuditChange{Field: name, Before: oldValue, After: newValue})
+	}
+	return changes, nil
+}
+
+func jsonFields(value interface{}) (map[string]json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// auditValue keeps a JSON null the same as an absent field.
+func auditValue(raw json.RawMessage) models.AuditValue {
+	if raw == nil || string(raw) == "null" {
+		return ""
+	}
+	return models.AuditValue(raw)
+}
+
+// auditedChange applies change to the entity with the given ID and records
+// it, loading the entity with find before and after. It returns the entity
+// as stored afterwards. Only use it for changes that keep the entity
+// findable, such as an update or a soft delete.
+func auditedChange[T any](ctx context.Context, c *gin.Context, log repository.AuditRepository, action models.AuditAction, entityType string, id primitive.ObjectID, find func(context.Context, primitive.ObjectID) (*T, error), change func(context.Context, primitive.ObjectID) error) (*T, error) {
+	before, err := find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := change(ctx, id); err != nil {
+		return nil, err
+	}
+	after, err := find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, c, log, action, entityType, id, before, after); err != nil {
+		return nil, err
+	}
+	return after, nil
+}
@@ -0,0 +1,28 @@
+// controllers/context.go
+package controllers
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/middleware"
+)
+
+// requestContext derives the context used for a handler's database calls.
+// It is cancelled when the client goes away or the configured operation
+// timeout elapses, whichever comes first.
+func requestContext(c *gin.Context, cfg *config.Config) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.Request.Context(), cfg.Storage.OperationTimeout.Duration)
+}
+
+// requestUser names who made the request, for the records that keep
+// track of it: the authenticated principal, or the X-User header as-is
+// when authentication is disabled.
+func requestUser(c *gin.Context) string {
+	if principal := middleware.GetPrincipal(c); principal != nil {
+		return principal.Subject
+	}
+	return c.GetHeader("X-User")
+}
@@ -5,63 +5,98 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mifaabiyyu/go-test.git/config"
 	"github.com/mifaabiyyu/go-test.git/models"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/mifaabiyyu/go-test.git/repository"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CustomerAddressController struct {
-	Collection *mongo.Collection
+	Addresses  repository.CustomerAddressRepository
+	References *ReferenceValidator
+	AuditLog   repository.AuditRepository
+	UnitOfWork repository.UnitOfWork
+	Config     *config.Config
 }
 
-func NewCustomerAddressController(db *mongo.Database) *CustomerAddressController {
+func NewCustomerAddressController(repos *repository.Repositories, cfg *config.Config) *CustomerAddressController {
 	return &CustomerAddressController{
-		Collection: db.Collection("customer_address"),
+		Addresses:  repos.CustomerAddresses,
+		References: NewReferenceValidator(repos),
+		AuditLog:   repos.AuditLog,
+		UnitOfWork: repos.UnitOfWork,
+		Config:     cfg,
 	}
 }
 
 func (cac *CustomerAddressController) CreateCustomerAddress(c *gin.Context) {
+	ctx, cancel := requestContext(c, cac.Config)
+	defer cancel()
+
 	var customerAddress models.CustomerAddress
-	if err := c.ShouldBindJSON(&customerAddress); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &customerAddress) {
+		return
+	}
+
+	if err := cac.References.Check(ctx).Customer("customer_id", customerAddress.CustomerID).Err(); err != nil {
+		c.Error(err)
 		return
 	}
+
 	customerAddress.ID = primitive.NewObjectID()
-	_, err := cac.Collection.InsertOne(context.Background(), customerAddress)
+	err := cac.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		if err := cac.Addresses.Create(txCtx, &customerAddress); err != nil {
+			return err
+		}
+		return recordAudit(txCtx, c, cac.AuditLog, models.AuditCreate, models.EntityCustomerAddress, customerAddress.ID, nil, customerAddress)
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, customerAddress)
 }
 
+var customerAddressListSpec = listSpec[models.CustomerAddress]{
+	Fields: repository.CustomerAddressFields,
+	Filters: []filterParam{
+		{Param: "customer_id", Field: "customer_id", Op: repository.OpEq},
+		{Param: "city", Field: "city", Op: repository.OpContains},
+	},
+	DefaultSort: repository.Sort{Field: "id"},
+}
+
 func (cac *CustomerAddressController) GetCustomerAddresses(c *gin.Context) {
-	var customerAddresses []models.CustomerAddress
-	cursor, err := cac.Collection.Find(context.Background(), bson.M{})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	ctx, cancel := requestContext(c, cac.Config)
+	defer cancel()
+
+	query, ok := bindListQuery(c, customerAddressListSpec)
+	if !ok {
 		return
 	}
-	defer cursor.Close(context.Background())
 
-	for cursor.Next(context.Background()) {
-		var customerAddress models.CustomerAddress
-		cursor.Decode(&customerAddress)
-		customerAddresses = append(customerAddresses, customerAddress)
+	page, err := cac.Addresses.List(ctx, query)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	c.JSON(http.StatusOK, customerAddresses)
+	c.JSON(http.StatusOK, newListResponse(query, page.Total, page.Next, page.Items))
 }
 
 func (cac *CustomerAddressController) GetCustomerAddress(c *gin.Context) {
-	id := c.Param("id")
-	idAddress, err := primitive.ObjectIDFromHex(id)
-	var customerAddress models.CustomerAddress
-	err = cac.Collection.FindOne(context.Background(), bson.M{"_id": idAddress}).Decode(&customerAddress)
+	ctx, cancel := requestContext(c, cac.Config)
+	defer cancel()
+
+	idAddress, ok := parseID(c, "id", "customer address")
+	if !ok {
+		return
+	}
+
+	customerAddress, err := cac.Addresses.FindByID(ctx, idAddress)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Customer address not found"})
+		c.Error(notFound(err, "Customer address not found"))
 		return
 	}
 
@@ -69,64 +104,99 @@ func (cac *CustomerAddressController) GetCustomerAddress(c *gin.Context) {
 }
 
 func (cac *CustomerAddressController) UpdateCustomerAddress(c *gin.Context) {
-	id := c.Param("id")
-	customerAddressID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer address ID format"})
+	ctx, cancel := requestContext(c, cac.Config)
+	defer cancel()
+
+	customerAddressID, ok := parseID(c, "id", "customer address")
+	if !ok {
 		return
 	}
 
 	var customerAddress models.CustomerAddress
-	if err := c.ShouldBindJSON(&customerAddress); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &customerAddress) {
 		return
 	}
 
 	// Check if the customer address exists
-	existingCustomerAddress := models.CustomerAddress{}
-	err = cac.Collection.FindOne(context.Background(), bson.M{"_id": customerAddressID}).Decode(&existingCustomerAddress)
+	_, err := cac.Addresses.FindByID(ctx, customerAddressID)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Customer address not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(notFound(err, "Customer address not found"))
+		return
+	}
+
+	cac.saveCustomerAddress(ctx, c, customerAddressID, &customerAddress)
+}
+
+// PatchCustomerAddress changes only the fields the patch names.
+func (cac *CustomerAddressController) PatchCustomerAddress(c *gin.Context) {
+	ctx, cancel := requestContext(c, cac.Config)
+	defer cancel()
+
+	customerAddressID, ok := parseID(c, "id", "customer address")
+	if !ok {
 		return
 	}
 
-	_, err = cac.Collection.ReplaceOne(
-		context.Background(),
-		bson.M{"_id": customerAddressID},
-		customerAddress,
-	)
+	existing, err := cac.Addresses.FindByID(ctx, customerAddressID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(notFound(err, "Customer address not found"))
 		return
 	}
 
-	customerAddress.ID = primitive.NewObjectID()
+	var customerAddress models.CustomerAddress
+	if !bindPatch(c, existing, &customerAddress) {
+		return
+	}
 
-	c.JSON(http.StatusOK, customerAddress)
+	cac.saveCustomerAddress(ctx, c, customerAddressID, &customerAddress)
 }
 
-func (cac *CustomerAddressController) DeleteCustomerAddress(c *gin.Context) {
-	id := c.Param("id")
-	customerAddressID, err := primitive.ObjectIDFromHex(id)
+// saveCustomerAddress overwrites the address with customerAddress and
+// responds with what was stored.
+func (cac *CustomerAddressController) saveCustomerAddress(ctx context.Context, c *gin.Context, customerAddressID primitive.ObjectID, customerAddress *models.CustomerAddress) {
+	if err := cac.References.Check(ctx).Customer("customer_id", customerAddress.CustomerID).Err(); err != nil {
+		c.Error(err)
+		return
+	}
 
-	var existingAddress models.CustomerAddress
-	err = cac.Collection.FindOne(context.Background(), bson.M{"_id": customerAddressID}).Decode(&existingAddress)
+	customerAddress.ID = customerAddressID
+	var stored *models.CustomerAddress
+	err := cac.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		stored, err = auditedChange(txCtx, c, cac.AuditLog, models.AuditUpdate, models.EntityCustomerAddress, customerAddressID, cac.Addresses.FindByID,
+			func(ctx context.Context, _ primitive.ObjectID) error { return cac.Addresses.Update(ctx, customerAddress) })
+		return err
+	})
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Address not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(notFound(err, "Customer address not found"))
 		return
 	}
 
-	_, err = cac.Collection.DeleteOne(context.Background(), bson.M{"_id": customerAddressID})
+	c.JSON(http.StatusOK, stored)
+}
+
+func (cac *CustomerAddressController) DeleteCustomerAddress(c *gin.Context) {
+	ctx, cancel := requestContext(c, cac.Config)
+	defer cancel()
+
+	customerAddressID, ok := parseID(c, "id", "customer address")
+	if !ok {
+		return
+	}
+
+	// Addresses are deleted for good, so the entry keeps what was removed
+	err := cac.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		address, err := cac.Addresses.FindByID(txCtx, customerAddressID)
+		if err != nil {
+			return err
+		}
+		if err := cac.Addresses.Delete(txCtx, customerAddressID); err != nil {
+			return err
+		}
+		return recordAudit(txCtx, c, cac.AuditLog, models.AuditDelete, models.EntityCustomerAddress, customerAddressID, address, nil)
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(notFound(err, "Address not found"))
 		return
 	}
 
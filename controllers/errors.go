@@ -0,0 +1,119 @@
+// controllers/errors.go
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/repository"
+	"github.com/mifaabiyyu/go-test.git/validation"
+)
+
+// Handlers report failures with c.Error and return; the errors middleware
+// writes the response. Anything that is not an *apperror.Error is
+// translated there, so repository errors can be passed on as they are.
+
+func init() {
+	// Name fields in binding errors by their JSON name, and add the
+	// custom rules models use
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonFieldName)
+		if err := validation.Register(v); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// bindJSON decodes the request body into obj, reporting a validation error
+// and returning false when it is malformed or breaks a binding rule.
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		c.Error(bindingError(c, obj, err))
+		return false
+	}
+	return true
+}
+
+// bindingError reports every invalid field of obj at once, with messages
+// in the language the client asked for. A value of the wrong type stops
+// decoding from reporting the others, so the rest of obj, which is
+// still decoded, is validated as well.
+func bindingError(c *gin.Context, obj interface{}, err error) *apperror.Error {
+	appErr := apperror.Validation("request body is invalid")
+	language := validation.FromAcceptLanguage(c.GetHeader("Accept-Language"))
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		appErr.WithField(typeErr.Field, validation.TypeMessage(typeErr.Type, language))
+		err = binding.Validator.ValidateStruct(obj)
+	}
+
+	var validationErrs validator.ValidationErrors
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, fieldErr := range validationErrs {
+			field := fieldPath(obj, fieldErr.Namespace())
+			if typeErr != nil && field == typeErr.Field {
+				continue
+			}
+			appErr.WithField(field, validation.Message(fieldErr, language))
+		}
+	case err != nil && typeErr == nil:
+		appErr.Message = "request body is not valid JSON"
+	}
+	return appErr
+}
+
+// fieldPath drops the name of obj's type a validator namespace starts
+// with. Anonymous request structs have none, so theirs start with the
+// field.
+func fieldPath(obj interface{}, namespace string) string {
+	t := reflect.TypeOf(obj)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Name() == "" {
+		return namespace
+	}
+	return strings.TrimPrefix(namespace, t.Name()+".")
+}
+
+// parseID reads the ObjectID path parameter param, reporting a validation
+// error naming resource when it is malformed.
+func parseID(c *gin.Context, param, resource string) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param(param))
+	if err != nil {
+		message := "Invalid " + resource + " ID format"
+		c.Error(apperror.Validation(message).WithField(param, message))
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+// notFound reports repository.ErrNotFound as a 404 with message and
+// passes any other error on unchanged.
+func notFound(err error, message string) error {
+	if err == repository.ErrNotFound {
+		return apperror.NotFound(message)
+	}
+	return err
+}
@@ -0,0 +1,274 @@
+// controllers/exchange_rate_controller.go
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/money"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// ExchangeRateController maintains the exchange-rate table that prices,
+// payments and reports are converted with.
+type ExchangeRateController struct {
+	ExchangeRates repository.ExchangeRateRepository
+	AuditLog      repository.AuditRepository
+	UnitOfWork    repository.UnitOfWork
+	Config        *config.Config
+}
+
+func NewExchangeRateController(repos *repository.Repositories, cfg *config.Config) *ExchangeRateController {
+	return &ExchangeRateController{
+		ExchangeRates: repos.ExchangeRates,
+		AuditLog:      repos.AuditLog,
+		UnitOfWork:    repos.UnitOfWork,
+		Config:        cfg,
+	}
+}
+
+// CreateExchangeRate records a rate. It supersedes the pair's earlier
+// rates from its effective time on.
+func (ec *ExchangeRateController) CreateExchangeRate(c *gin.Context) {
+	ctx, cancel := requestContext(c, ec.Config)
+	defer cancel()
+
+	var rate models.ExchangeRate
+	if !bindJSON(c, &rate) {
+		return
+	}
+	if rate.FromCurrency == rate.ToCurrency {
+		c.Error(apperror.Validation("to_currency must differ from from_currency").
+			WithField("to_currency", "must differ from from_currency"))
+		return
+	}
+
+	rate.ID = primitive.NewObjectID()
+	rate.EffectiveFrom = rate.EffectiveFrom.UTC()
+	rate.CreatedBy = requestUser(c)
+	rate.CreatedAt = time.Now()
+
+	err := ec.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		if err := ec.ExchangeRates.Create(txCtx, &rate); err != nil {
+			return err
+		}
+		return recordAudit(txCtx, c, ec.AuditLog, models.AuditCreate, models.EntityExchangeRate, rate.ID, nil, rate)
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rate)
+}
+
+var exchangeRateListSpec = listSpec[models.ExchangeRate]{
+	Fields: repository.ExchangeRateFields,
+	Filters: []filterParam{
+		{Param: "from_currency", Field: "from_currency", Op: repository.OpEq},
+		{Param: "to_currency", Field: "to_currency", Op: repository.OpEq},
+		{Param: "date_from", Field: "effective_from", Op: repository.OpGte},
+		{Param: "date_to", Field: "effective_from", Op: repository.OpLte},
+	},
+	DefaultSort: repository.Sort{Field: "effective_from", Descending: true},
+}
+
+// GetExchangeRates lists rates, the latest to take effect first.
+func (ec *ExchangeRateController) GetExchangeRates(c *gin.Context) {
+	ctx, cancel := requestContext(c, ec.Config)
+	defer cancel()
+
+	query, ok := bindListQuery(c, exchangeRateListSpec)
+	if !ok {
+		return
+	}
+
+	page, err := ec.ExchangeRates.List(ctx, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newListResponse(query, page.Total, page.Next, page.Items))
+}
+
+func (ec *ExchangeRateController) GetExchangeRate(c *gin.Context) {
+	ctx, cancel := requestContext(c, ec.Config)
+	defer cancel()
+
+	rateID, ok := parseID(c, "id", "exchange rate")
+	if !ok {
+		return
+	}
+
+	rate, err := ec.ExchangeRates.FindByID(ctx, rateID)
+	if err != nil {
+		c.Error(notFound(err, "Exchange rate not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, rate)
+}
+
+// DeleteExchangeRate removes a wrong rate. Amounts already converted at it
+// keep the rate they were converted at.
+func (ec *ExchangeRateController) DeleteExchangeRate(c *gin.Context) {
+	ctx, cancel := requestContext(c, ec.Config)
+	defer cancel()
+
+	rateID, ok := parseID(c, "id", "exchange rate")
+	if !ok {
+		return
+	}
+
+	err := ec.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		before, err := ec.ExchangeRates.FindByID(txCtx, rateID)
+		if err != nil {
+			return err
+		}
+		if err := ec.ExchangeRates.Delete(txCtx, rateID); err != nil {
+			return err
+		}
+		return recordAudit(txCtx, c, ec.AuditLog, models.AuditDelete, models.EntityExchangeRate, rateID, before, nil)
+	})
+	if err != nil {
+		c.Error(notFound(err, "Exchange rate not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Exchange rate deleted"})
+}
+
+// effectiveRate returns the rate between two currencies in effect at the
+// given time, quoted either way round; the later one when both are.
+func effectiveRate(ctx context.Context, rates repository.ExchangeRateRepository, from, to string, at time.Time) (*models.ExchangeRate, error) {
+	direct, err := rates.Effective(ctx, from, to, at)
+	if err != nil && err != repository.ErrNotFound {
+		return nil, err
+	}
+	inverse, err := rates.Effective(ctx, to, from, at)
+	if err != nil && err != repository.ErrNotFound {
+		return nil, err
+	}
+
+	switch {
+	case direct == nil && inverse == nil:
+		return nil, repository.ErrNotFound
+	case direct == nil:
+		return inverse, nil
+	case inverse != nil && inverse.EffectiveFrom.After(direct.EffectiveFrom):
+		return inverse, nil
+	}
+	return direct, nil
+}
+
+// convertAmount converts amount into currency at the rate in effect at the
+// given time, and returns the rate it applied: the units of currency that
+// one unit of amount's bought. An amount already in currency is returned
+// as it is, at a rate of 1. A missing rate is reported against field.
+func convertAmount(ctx context.Context, rates repository.ExchangeRateRepository, field string, amount money.Money, currency string, at time.Time) (money.Money, float64, error) {
+	from := amount.Currency()
+	if from == currency {
+		return amount, 1, nil
+	}
+
+	rate, err := effectiveRate(ctx, rates, from, currency, at)
+	if err == repository.ErrNotFound {
+		return money.Money{}, 0, missingRate(field, from, currency, at)
+	}
+	if err != nil {
+		return money.Money{}, 0, err
+	}
+	converted, err := rate.Convert(amount)
+	if err != nil {
+		return money.Money{}, 0, err
+	}
+	return converted, rate.RateTo(currency), nil
+}
+
+// rateBook converts amounts like convertAmount, from the rates of each
+// currency pair read once and kept for the rest of a request. It serves
+// requests converting many amounts, such as reports.
+type rateBook struct {
+	rates repository.ExchangeRateRepository
+	// until bounds the rates read: none taking effect after it is needed.
+	until time.Time
+	pairs map[[2]string][]models.ExchangeRate
+}
+
+func newRateBook(rates repository.ExchangeRateRepository, until time.Time) *rateBook {
+	return &rateBook{rates: rates, until: until, pairs: make(map[[2]string][]models.ExchangeRate)}
+}
+
+// convert converts amount into currency at the rate in effect at the given
+// time, chosen the way effectiveRate chooses it. A missing rate is
+// reported against field.
+func (b *rateBook) convert(ctx context.Context, field string, amount money.Money, currency string, at time.Time) (money.Money, error) {
+	from := amount.Currency()
+	if from == currency {
+		return amount, nil
+	}
+
+	direct, err := b.effective(ctx, from, currency, at)
+	if err != nil {
+		return money.Money{}, err
+	}
+	inverse, err := b.effective(ctx, currency, from, at)
+	if err != nil {
+		return money.Money{}, err
+	}
+	rate := direct
+	if rate == nil || (inverse != nil && inverse.EffectiveFrom.After(direct.EffectiveFrom)) {
+		rate = inverse
+	}
+	if rate == nil {
+		return money.Money{}, missingRate(field, from, currency, at)
+	}
+	return rate.Convert(amount)
+}
+
+// effective returns the rate from one currency to another in effect at the
+// given time, or nil when there is none.
+func (b *rateBook) effective(ctx context.Context, from, to string, at time.Time) (*models.ExchangeRate, error) {
+	pair := [2]string{from, to}
+	rates, ok := b.pairs[pair]
+	if !ok {
+		page, err := b.rates.List(ctx, repository.ListQuery{
+			Filters: []repository.Filter{
+				{Field: "from_currency", Op: repository.OpEq, Value: from},
+				{Field: "to_currency", Op: repository.OpEq, Value: to},
+				{Field: "effective_from", Op: repository.OpLte, Value: b.until},
+			},
+			Sort: repository.Sort{Field: "effective_from"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		rates = page.Items
+		b.pairs[pair] = rates
+	}
+
+	// The first rate taking effect after at, and the one before it
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].EffectiveFrom.After(at) })
+	if i == 0 {
+		return nil, nil
+	}
+	return &rates[i-1], nil
+}
+
+func missingRate(field, from, to string, at time.Time) *apperror.Error {
+	message := "has no exchange rate from " + from + " to " + to + " on " + at.UTC().Format("2006-01-02")
+	return apperror.Unprocessable("exchange_rate_missing", field+" "+message).
+		WithField(field, message).
+		WithDetail("from_currency", from).
+		WithDetail("to_currency", to).
+		WithDetail("date", at.UTC())
+}
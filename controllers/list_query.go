@@ -0,0 +1,236 @@
+// controllers/list_query.go
+package controllers
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/money"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// filterParam maps a query string parameter onto a filter of a list.
+type filterParam struct {
+	Param string
+	Field string
+	Op    repository.Operator
+}
+
+// listSpec declares the filters and default order a list endpoint accepts.
+// Sortable fields are the ones whitelisted in Fields.
+type listSpec[T any] struct {
+	Fields      repository.Fields[T]
+	Filters     []filterParam
+	DefaultSort repository.Sort
+}
+
+// ListResponse is the envelope every list endpoint returns. Page is only
+// set for page-based requests; NextCursor is set whenever more items follow
+// and can be passed back as ?cursor= to fetch them.
+type ListResponse[T any] struct {
+	Data       []T    `json:"data"`
+	Total      int64  `json:"total"`
+	Page       int64  `json:"page,omitempty"`
+	PageSize   int64  `json:"page_size"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// bindListQuery reads pagination, sorting and filter parameters:
+//
+//	page, page_size          page-based pagination (page starts at 1)
+//	cursor                   continue after a previous page's next_cursor
+//	order_by, order_direction  a whitelisted field and asc or desc
+//	include_deleted          also list soft-deleted items
+//
+// plus the filters in spec. It reports a validation error naming the
+// parameter and returns false when a parameter is invalid.
+func bindListQuery[T any](c *gin.Context, spec listSpec[T]) (repository.ListQuery, bool) {
+	query := repository.ListQuery{Sort: spec.DefaultSort, Limit: DefaultPageSize}
+
+	fail := func(param, message string) (repository.ListQuery, bool) {
+		c.Error(invalidParam(param, message))
+		return repository.ListQuery{}, false
+	}
+
+	if raw, ok := c.GetQuery("page_size"); ok {
+		size, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || size < 1 || size > MaxPageSize {
+			return fail("page_size", "page_size must be between 1 and "+strconv.Itoa(MaxPageSize))
+		}
+		query.Limit = size
+	}
+
+	if raw, ok := c.GetQuery("cursor"); ok {
+		if _, ok := c.GetQuery("page"); ok {
+			return fail("cursor", "cursor and page cannot be combined")
+		}
+		cursor, err := repository.DecodeCursor(raw, spec.Fields)
+		if err != nil {
+			return fail("cursor", "cursor is invalid")
+		}
+		query.After = cursor
+		query.Sort = cursor.Sort
+	} else if raw, ok := c.GetQuery("page"); ok {
+		page, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || page < 1 {
+			return fail("page", "page must be a positive integer")
+		}
+		// Past this page the offset no longer fits in an int64
+		if page-1 > math.MaxInt64/query.Limit {
+			return fail("page", "page must be at most "+strconv.FormatInt(math.MaxInt64/query.Limit+1, 10))
+		}
+		query.Offset = (page - 1) * query.Limit
+	}
+
+	if field, ok := c.GetQuery("order_by"); ok {
+		if f, ok := spec.Fields[field]; !ok || f.Kind == repository.DeletedField {
+			return fail("order_by", "cannot sort by "+strconv.Quote(field))
+		}
+		query.Sort.Field = field
+	}
+	if direction, ok := c.GetQuery("order_direction"); ok {
+		if direction != "asc" && direction != "desc" {
+			return fail("order_direction", "order_direction must be asc or desc")
+		}
+		query.Sort.Descending = direction == "desc"
+	}
+	if query.After != nil && query.After.Sort != query.Sort {
+		return fail("cursor", "cursor was issued for a different order")
+	}
+
+	includeDeleted, ok := bindIncludeDeleted(c)
+	if !ok {
+		return repository.ListQuery{}, false
+	}
+	query.IncludeDeleted = includeDeleted
+
+	filters, ok := bindFilters(c, spec.Fields, spec.Filters)
+	if !ok {
+		return repository.ListQuery{}, false
+	}
+	query.Filters = filters
+
+	return query, true
+}
+
+// bindFilters reads the filter parameters in params. Amounts are in the
+// currency the currency parameter names, DefaultCurrency by default, and
+// only match amounts in that currency; a currency filter matches that
+// currency in any case. It reports a validation error naming the parameter
+// and returns false when one is invalid.
+func bindFilters[T any](c *gin.Context, fields repository.Fields[T], params []filterParam) ([]repository.Filter, bool) {
+	currency := strings.ToUpper(c.DefaultQuery("currency", money.DefaultCurrency))
+
+	var filters []repository.Filter
+	for _, param := range params {
+		raw, ok := c.GetQuery(param.Param)
+		if !ok {
+			continue
+		}
+		kind := fields[param.Field].Kind
+		if param.Param == "currency" {
+			raw = currency
+		}
+		if _, known := money.Exponent(currency); (kind == repository.MoneyField || param.Param == "currency") && !known {
+			c.Error(invalidParam("currency", "currency must be a supported currency code"))
+			return nil, false
+		}
+		filter, err := parseFilter(param, kind, raw, currency)
+		if err != nil {
+			c.Error(invalidParam(param.Param, err.Error()))
+			return nil, false
+		}
+		filters = append(filters, filter)
+	}
+	return filters, true
+}
+
+// bindIncludeDeleted reads the include_deleted parameter, which makes
+// soft-deleted items visible. It reports a validation error and returns
+// false when the value is not a boolean.
+func bindIncludeDeleted(c *gin.Context) (include bool, ok bool) {
+	raw, present := c.GetQuery("include_deleted")
+	if !present {
+		return false, true
+	}
+	include, err := strconv.ParseBool(raw)
+	if err != nil {
+		c.Error(invalidParam("include_deleted", "include_deleted must be true or false"))
+		return false, false
+	}
+	return include, true
+}
+
+func invalidParam(param, message string) *apperror.Error {
+	return apperror.Validation(message).WithField(param, message)
+}
+
+// parseFilter converts a raw parameter into a typed filter, reading
+// amounts in currency. A date without a time used as an upper bound covers
+// that whole day.
+func parseFilter(param filterParam, kind repository.FieldKind, raw, currency string) (repository.Filter, error) {
+	filter := repository.Filter{Field: param.Field, Op: param.Op}
+
+	var err error
+	switch kind {
+	case repository.StringField:
+		filter.Value = raw
+	case repository.NumberField:
+		filter.Value, err = strconv.ParseFloat(raw, 64)
+	case repository.BoolField:
+		filter.Value, err = strconv.ParseBool(raw)
+	case repository.IDField:
+		filter.Value, err = primitive.ObjectIDFromHex(raw)
+	case repository.MoneyField:
+		filter.Value, err = money.Parse(raw, currency)
+	case repository.TimeField:
+		var t time.Time
+		if t, err = time.Parse("2006-01-02", raw); err == nil {
+			if param.Op == repository.OpLte {
+				t, filter.Op = t.AddDate(0, 0, 1), repository.OpLt
+			}
+		} else {
+			t, err = time.Parse(time.RFC3339, raw)
+		}
+		filter.Value = t
+	}
+	if err != nil {
+		return repository.Filter{}, &invalidParamError{Param: param.Param}
+	}
+	return filter, nil
+}
+
+type invalidParamError struct {
+	Param string
+}
+
+func (e *invalidParamError) Error() string {
+	return e.Param + " has an invalid value"
+}
+
+// newListResponse wraps one page of data, which may be the page's items
+// or a view built from them.
+func newListResponse[T any](query repository.ListQuery, total int64, next *repository.Cursor, data []T) ListResponse[T] {
+	if data == nil {
+		data = []T{}
+	}
+	response := ListResponse[T]{Data: data, Total: total, PageSize: query.Limit}
+	if query.After == nil {
+		response.Page = query.Offset/query.Limit + 1
+	}
+	if next != nil {
+		response.NextCursor = next.Encode()
+	}
+	return response
+}
@@ -0,0 +1,71 @@
+// controllers/patch.go
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/patch"
+)
+
+// bindPatch applies the request body to current, as it is rendered in
+// responses, and decodes the result into target, checking target's
+// binding rules like bindJSON does. The body is a JSON Merge Patch, or a
+// JSON Patch when sent as application/json-patch+json. It reports the
+// error itself and returns false when the patch is rejected or the
+// patched resource is invalid.
+func bindPatch(c *gin.Context, current, target interface{}) bool {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.Error(err)
+		return false
+	}
+	document, err := json.Marshal(current)
+	if err != nil {
+		c.Error(err)
+		return false
+	}
+
+	patched, err := patch.Apply(c.GetHeader("Content-Type"), document, body)
+	if err != nil {
+		c.Error(patchError(err))
+		return false
+	}
+	err = json.Unmarshal(patched, target)
+	if err == nil {
+		err = binding.Validator.ValidateStruct(target)
+	}
+	if err != nil {
+		c.Error(bindingError(c, target, err))
+		return false
+	}
+	return true
+}
+
+func patchError(err error) error {
+	if err == patch.ErrUnsupportedType {
+		return apperror.UnsupportedMediaType("PATCH takes " + patch.MergePatchType + " or " + patch.JSONPatchType)
+	}
+	var patchErr *patch.Error
+	if !errors.As(err, &patchErr) {
+		return err
+	}
+
+	var appErr *apperror.Error
+	switch patchErr.Reason {
+	case patch.TestFailed:
+		appErr = apperror.Conflict("patch_test_failed", patchErr.Message)
+	case patch.Unapplicable:
+		appErr = apperror.Unprocessable("patch_unapplicable", patchErr.Message)
+	default:
+		appErr = apperror.Validation("patch is invalid: " + patchErr.Message)
+	}
+	if patchErr.Operation >= 0 {
+		appErr.WithDetail("operation", patchErr.Operation).WithDetail("path", patchErr.Path)
+	}
+	return appErr
+}
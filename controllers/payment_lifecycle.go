@@ -0,0 +1,117 @@
+// controllers/payment_lifecycle.go
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// AuthorizePayment handles POST /transaction/:id/payments/:pid/authorize.
+func (tc *TransactionController) AuthorizePayment(c *gin.Context) {
+	tc.transitionPayment(c, models.PaymentAuthorized)
+}
+
+// CapturePayment handles POST /transaction/:id/payments/:pid/capture.
+func (tc *TransactionController) CapturePayment(c *gin.Context) {
+	tc.transitionPayment(c, models.PaymentPaid)
+}
+
+// FailPayment handles POST /transaction/:id/payments/:pid/fail.
+func (tc *TransactionController) FailPayment(c *gin.Context) {
+	tc.transitionPayment(c, models.PaymentFailed)
+}
+
+// RefundPayment handles POST /transaction/:id/payments/:pid/refund.
+func (tc *TransactionController) RefundPayment(c *gin.Context) {
+	tc.transitionPayment(c, models.PaymentRefunded)
+}
+
+// CancelPayment handles POST /transaction/:id/payments/:pid/cancel.
+func (tc *TransactionController) CancelPayment(c *gin.Context) {
+	tc.transitionPayment(c, models.PaymentCancelled)
+}
+
+// VoidPayment handles DELETE /transaction/:id/payments/:pid. Payments are
+// never removed; voiding cancels one that has not been settled, so the
+// history of the transaction stays intact.
+func (tc *TransactionController) VoidPayment(c *gin.Context) {
+	tc.transitionPayment(c, models.PaymentCancelled)
+}
+
+// transitionPayment moves one payment of a transaction to status, rejecting
+// moves the payment lifecycle does not allow with 409.
+func (tc *TransactionController) transitionPayment(c *gin.Context, status models.PaymentStatus) {
+	ctx, cancel := requestContext(c, tc.Config)
+	defer cancel()
+
+	transactionID, ok := parseID(c, "id", "transaction")
+	if !ok {
+		return
+	}
+	paymentID, ok := parseID(c, "pid", "payment")
+	if !ok {
+		return
+	}
+
+	var payment *models.TransactionPayment
+	err := tc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		payment, err = tc.Payments.FindByID(txCtx, paymentID)
+		if err != nil {
+			return err
+		}
+		if payment.TransactionID != transactionID {
+			return repository.ErrNotFound
+		}
+
+		before := *payment
+		payment.Status, err = payment.Status.Transition(status)
+		if err != nil {
+			return err
+		}
+		if status == models.PaymentPaid {
+			payment.PaymentDate = time.Now()
+		}
+		if err := tc.UpdateTransactionPayment(txCtx, *payment); err != nil {
+			return err
+		}
+		return recordAudit(txCtx, c, tc.AuditLog, models.AuditUpdate, models.EntityTransactionPayment, paymentID, before, payment)
+	})
+	if err != nil {
+		if transitionErr, ok := err.(*models.TransitionError); ok {
+			err = apperror.Conflict("invalid_transition", transitionErr.Error()).
+				WithDetail("from", transitionErr.From).
+				WithDetail("to", transitionErr.To)
+		}
+		c.Error(notFound(err, "Payment not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+const errPaymentNotInitial = "payments must start as pending, authorized or paid"
+
+// checkPaymentStatuses rejects new payments recorded directly in a state
+// that can only be reached through a transition, such as refunded.
+// Payments that already have an ID were stored earlier and are skipped.
+func checkPaymentStatuses(payments []models.TransactionPayment) error {
+	for i, payment := range payments {
+		if payment.ID.IsZero() && !payment.Status.IsInitial() {
+			return errNotInitial("payments[" + strconv.Itoa(i) + "].status")
+		}
+	}
+	return nil
+}
+
+func errNotInitial(field string) *apperror.Error {
+	return apperror.Unprocessable("invalid_payment_status", errPaymentNotInitial).WithField(field, errPaymentNotInitial)
+}
@@ -6,84 +6,111 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/config"
 	"github.com/mifaabiyyu/go-test.git/models"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/mifaabiyyu/go-test.git/repository"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type PaymentMethodController struct {
-	Collection *mongo.Collection
+	PaymentMethods repository.PaymentMethodRepository
+	AuditLog       repository.AuditRepository
+	UnitOfWork     repository.UnitOfWork
+	Config         *config.Config
 }
 
-func NewPaymentMethodController(db *mongo.Database) *PaymentMethodController {
+func NewPaymentMethodController(repos *repository.Repositories, cfg *config.Config) *PaymentMethodController {
 	return &PaymentMethodController{
-		Collection: db.Collection("payment_methods"),
+		PaymentMethods: repos.PaymentMethods,
+		AuditLog:       repos.AuditLog,
+		UnitOfWork:     repos.UnitOfWork,
+		Config:         cfg,
 	}
 }
 
 func (pmc *PaymentMethodController) CreatePaymentMethod(c *gin.Context) {
+	ctx, cancel := requestContext(c, pmc.Config)
+	defer cancel()
+
 	var paymentMethod models.PaymentMethod
-	if err := c.ShouldBindJSON(&paymentMethod); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &paymentMethod) {
 		return
 	}
 
-	existingPaymentMethod := models.PaymentMethod{}
-	err := pmc.Collection.FindOne(context.Background(), bson.M{"name": paymentMethod.Name}).Decode(&existingPaymentMethod)
+	_, err := pmc.PaymentMethods.FindByName(ctx, paymentMethod.Name)
 	if err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Name already exists"})
+		c.Error(apperror.Conflict("duplicate", "Name already exists").WithField("name", "Name already exists"))
 		return
-	} else if err != mongo.ErrNoDocuments {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	} else if err != repository.ErrNotFound {
+		c.Error(err)
 		return
 	}
-	// Clear the ID field to let MongoDB generate it
+	// Assign the ID here so it can be returned to the client
 	paymentMethod.ID = primitive.NewObjectID()
+	paymentMethod.DeletedAt = nil
 
-	_, err = pmc.Collection.InsertOne(context.Background(), paymentMethod)
+	err = pmc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		if err := pmc.PaymentMethods.Create(txCtx, &paymentMethod); err != nil {
+			return err
+		}
+		return recordAudit(txCtx, c, pmc.AuditLog, models.AuditCreate, models.EntityPaymentMethod, paymentMethod.ID, nil, paymentMethod)
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, paymentMethod)
 }
 
+var paymentMethodListSpec = listSpec[models.PaymentMethod]{
+	Fields: repository.PaymentMethodFields,
+	Filters: []filterParam{
+		{Param: "name", Field: "name", Op: repository.OpContains},
+		{Param: "is_active", Field: "is_active", Op: repository.OpEq},
+	},
+	DefaultSort: repository.Sort{Field: "id"},
+}
+
 func (pmc *PaymentMethodController) GetPaymentMethods(c *gin.Context) {
-	var paymentMethods []models.PaymentMethod
-	cursor, err := pmc.Collection.Find(context.Background(), bson.M{})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	ctx, cancel := requestContext(c, pmc.Config)
+	defer cancel()
+
+	query, ok := bindListQuery(c, paymentMethodListSpec)
+	if !ok {
 		return
 	}
-	defer cursor.Close(context.Background())
 
-	for cursor.Next(context.Background()) {
-		var paymentMethod models.PaymentMethod
-		cursor.Decode(&paymentMethod)
-		paymentMethods = append(paymentMethods, paymentMethod)
+	page, err := pmc.PaymentMethods.List(ctx, query)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	c.JSON(http.StatusOK, paymentMethods)
+	c.JSON(http.StatusOK, newListResponse(query, page.Total, page.Next, page.Items))
 }
 
 func (pmc *PaymentMethodController) GetPaymentMethod(c *gin.Context) {
-	id := c.Param("id")
-	paymentMethodID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment method ID format"})
+	ctx, cancel := requestContext(c, pmc.Config)
+	defer cancel()
+
+	paymentMethodID, ok := parseID(c, "id", "payment method")
+	if !ok {
 		return
 	}
 
-	var paymentMethod models.PaymentMethod
-	err = pmc.Collection.FindOne(context.Background(), bson.M{"_id": paymentMethodID}).Decode(&paymentMethod)
+	includeDeleted, ok := bindIncludeDeleted(c)
+	if !ok {
+		return
+	}
+
+	paymentMethod, err := pmc.PaymentMethods.FindByID(ctx, paymentMethodID)
+	if err == nil && paymentMethod.DeletedAt != nil && !includeDeleted {
+		err = repository.ErrNotFound
+	}
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Payment Method not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(notFound(err, "Payment method not found"))
 		return
 	}
 
@@ -91,60 +118,111 @@ func (pmc *PaymentMethodController) GetPaymentMethod(c *gin.Context) {
 }
 
 func (pmc *PaymentMethodController) UpdatePaymentMethod(c *gin.Context) {
-	id := c.Param("id")
-	paymentMethodID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment method ID format"})
+	ctx, cancel := requestContext(c, pmc.Config)
+	defer cancel()
+
+	paymentMethodID, ok := parseID(c, "id", "payment method")
+	if !ok {
 		return
 	}
 
 	var updatedPaymentMethod models.PaymentMethod
-	if err := c.ShouldBindJSON(&updatedPaymentMethod); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &updatedPaymentMethod) {
 		return
 	}
 
-	_, err = pmc.Collection.UpdateOne(
-		context.Background(),
-		bson.M{"_id": paymentMethodID},
-		bson.M{"$set": updatedPaymentMethod},
-	)
+	pmc.savePaymentMethod(ctx, c, paymentMethodID, &updatedPaymentMethod)
+}
+
+// PatchPaymentMethod changes only the fields the patch names.
+func (pmc *PaymentMethodController) PatchPaymentMethod(c *gin.Context) {
+	ctx, cancel := requestContext(c, pmc.Config)
+	defer cancel()
+
+	paymentMethodID, ok := parseID(c, "id", "payment method")
+	if !ok {
+		return
+	}
+
+	existing, err := pmc.PaymentMethods.FindByID(ctx, paymentMethodID)
+	if err == nil && existing.DeletedAt != nil {
+		err = repository.ErrNotFound
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(notFound(err, "Payment method not found"))
 		return
 	}
 
-	updatedPaymentMethod.ID = primitive.NewObjectID()
+	var patchedPaymentMethod models.PaymentMethod
+	if !bindPatch(c, existing, &patchedPaymentMethod) {
+		return
+	}
 
-	c.JSON(http.StatusOK, updatedPaymentMethod)
+	pmc.savePaymentMethod(ctx, c, paymentMethodID, &patchedPaymentMethod)
 }
 
-func (pmc *PaymentMethodController) DeletePaymentMethod(c *gin.Context) {
-	id := c.Param("id")
-	paymentMethodID, err := primitive.ObjectIDFromHex(id)
+// savePaymentMethod overwrites the payment method with paymentMethod and
+// responds with what was stored.
+func (pmc *PaymentMethodController) savePaymentMethod(ctx context.Context, c *gin.Context, paymentMethodID primitive.ObjectID, paymentMethod *models.PaymentMethod) {
+	paymentMethod.ID = paymentMethodID
+	paymentMethod.DeletedAt = nil
+
+	var stored *models.PaymentMethod
+	err := pmc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		stored, err = auditedChange(txCtx, c, pmc.AuditLog, models.AuditUpdate, models.EntityPaymentMethod, paymentMethodID, pmc.PaymentMethods.FindByID,
+			func(ctx context.Context, _ primitive.ObjectID) error { return pmc.PaymentMethods.Update(ctx, paymentMethod) })
+		return err
+	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment method ID format"})
+		c.Error(notFound(err, "Payment method not found"))
 		return
 	}
 
-	// Check if the payment method exists
-	var existingPaymentMethod models.PaymentMethod
-	err = pmc.Collection.FindOne(context.Background(), bson.M{"_id": paymentMethodID}).Decode(&existingPaymentMethod)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Payment method not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	c.JSON(http.StatusOK, stored)
+}
+
+func (pmc *PaymentMethodController) DeletePaymentMethod(c *gin.Context) {
+	ctx, cancel := requestContext(c, pmc.Config)
+	defer cancel()
+
+	paymentMethodID, ok := parseID(c, "id", "payment method")
+	if !ok {
 		return
 	}
 
-	// Proceed with payment method deletion
-	_, err = pmc.Collection.DeleteOne(context.Background(), bson.M{"_id": paymentMethodID})
+	// Payments keep referring to a deleted payment method
+	err := pmc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		_, err := auditedChange(txCtx, c, pmc.AuditLog, models.AuditDelete, models.EntityPaymentMethod, paymentMethodID, pmc.PaymentMethods.FindByID, pmc.PaymentMethods.Delete)
+		return err
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(notFound(err, "Payment method not found"))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Payment method deleted"})
 }
+
+func (pmc *PaymentMethodController) RestorePaymentMethod(c *gin.Context) {
+	ctx, cancel := requestContext(c, pmc.Config)
+	defer cancel()
+
+	paymentMethodID, ok := parseID(c, "id", "payment method")
+	if !ok {
+		return
+	}
+
+	var paymentMethod *models.PaymentMethod
+	err := pmc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		paymentMethod, err = auditedChange(txCtx, c, pmc.AuditLog, models.AuditRestore, models.EntityPaymentMethod, paymentMethodID, pmc.PaymentMethods.FindByID, pmc.PaymentMethods.Restore)
+		return err
+	})
+	if err != nil {
+		c.Error(notFound(err, "Deleted payment method not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, paymentMethod)
+}
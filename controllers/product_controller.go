@@ -3,85 +3,149 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/config"
 	"github.com/mifaabiyyu/go-test.git/models"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/mifaabiyyu/go-test.git/repository"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type ProductController struct {
-	Collection *mongo.Collection
+	Products        repository.ProductRepository
+	ProductVersions repository.ProductVersionRepository
+	StockMovements  repository.StockMovementRepository
+	AuditLog        repository.AuditRepository
+	UnitOfWork      repository.UnitOfWork
+	Config          *config.Config
 }
 
-func NewProductController(db *mongo.Database) *ProductController {
+func NewProductController(repos *repository.Repositories, cfg *config.Config) *ProductController {
 	return &ProductController{
-		Collection: db.Collection("products"),
+		Products:        repos.Products,
+		ProductVersions: repos.ProductVersions,
+		StockMovements:  repos.StockMovements,
+		AuditLog:        repos.AuditLog,
+		UnitOfWork:      repos.UnitOfWork,
+		Config:          cfg,
 	}
 }
 
 func (pc *ProductController) CreateProduct(c *gin.Context) {
+	ctx, cancel := requestContext(c, pc.Config)
+	defer cancel()
+
 	var product models.Product
-	if err := c.ShouldBindJSON(&product); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &product) {
+		return
+	}
+	if err := checkPrices(&product); err != nil {
+		c.Error(err)
 		return
 	}
 
-	existingProduct := models.Product{}
-	err := pc.Collection.FindOne(context.Background(), bson.M{"code": product.Code}).Decode(&existingProduct)
+	_, err := pc.Products.FindByCode(ctx, product.Code)
 	if err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Code already exists"})
+		c.Error(apperror.Conflict("duplicate", "Code already exists").WithField("code", "Code already exists"))
 		return
-	} else if err != mongo.ErrNoDocuments {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	} else if err != repository.ErrNotFound {
+		c.Error(err)
 		return
 	}
+
+	// The product starts out empty and its opening stock is received
+	// through the ledger, so the two never disagree.
+	opening := product.StockQuantity
 	product.ID = primitive.NewObjectID()
-	_, err = pc.Collection.InsertOne(context.Background(), product)
+	product.StockQuantity = 0
+	product.DeletedAt = nil
+	err = pc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		if err := pc.Products.Create(txCtx, &product); err != nil {
+			return err
+		}
+		if err := recordProductVersion(txCtx, pc.ProductVersions, &product, requestUser(c)); err != nil {
+			return err
+		}
+		if opening > 0 {
+			product.StockQuantity = opening
+			err := recordMovement(txCtx, pc.Products, pc.StockMovements, &models.StockMovement{
+				ID:        primitive.NewObjectID(),
+				ProductID: product.ID,
+				Type:      models.MovementReceipt,
+				Quantity:  opening,
+				Reason:    "opening stock",
+				User:      requestUser(c),
+				CreatedAt: time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return recordAudit(txCtx, c, pc.AuditLog, models.AuditCreate, models.EntityProduct, product.ID, nil, product)
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, product)
 }
 
+var productListSpec = listSpec[models.Product]{
+	Fields: repository.ProductFields,
+	Filters: []filterParam{
+		{Param: "code", Field: "code", Op: repository.OpEq},
+		{Param: "name", Field: "name", Op: repository.OpContains},
+		{Param: "category", Field: "category", Op: repository.OpEq},
+		{Param: "min_price", Field: "price", Op: repository.OpGte},
+		{Param: "max_price", Field: "price", Op: repository.OpLte},
+		{Param: "max_stock", Field: "stock_quantity", Op: repository.OpLte},
+	},
+	DefaultSort: repository.Sort{Field: "id"},
+}
+
 func (pc *ProductController) GetProducts(c *gin.Context) {
-	var products []models.Product
-	cursor, err := pc.Collection.Find(context.Background(), bson.M{})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	ctx, cancel := requestContext(c, pc.Config)
+	defer cancel()
+
+	query, ok := bindListQuery(c, productListSpec)
+	if !ok {
 		return
 	}
-	defer cursor.Close(context.Background())
 
-	for cursor.Next(context.Background()) {
-		var product models.Product
-		cursor.Decode(&product)
-		products = append(products, product)
+	page, err := pc.Products.List(ctx, query)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	c.JSON(http.StatusOK, products)
+	c.JSON(http.StatusOK, newListResponse(query, page.Total, page.Next, page.Items))
 }
 
 func (pc *ProductController) GetProduct(c *gin.Context) {
-	id := c.Param("id")
-	productID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID format"})
+	ctx, cancel := requestContext(c, pc.Config)
+	defer cancel()
+
+	productID, ok := parseID(c, "id", "product")
+	if !ok {
 		return
 	}
 
-	var product models.Product
-	err = pc.Collection.FindOne(context.Background(), bson.M{"_id": productID}).Decode(&product)
+	includeDeleted, ok := bindIncludeDeleted(c)
+	if !ok {
+		return
+	}
+
+	product, err := pc.Products.FindByID(ctx, productID)
+	if err == nil && product.DeletedAt != nil && !includeDeleted {
+		err = repository.ErrNotFound
+	}
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(notFound(err, "Product not found"))
 		return
 	}
 
@@ -89,70 +153,133 @@ func (pc *ProductController) GetProduct(c *gin.Context) {
 }
 
 func (pc *ProductController) UpdateProduct(c *gin.Context) {
-	id := c.Param("id")
-	productID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID format"})
+	ctx, cancel := requestContext(c, pc.Config)
+	defer cancel()
+
+	productID, ok := parseID(c, "id", "product")
+	if !ok {
 		return
 	}
 
 	var updatedProduct models.Product
-	if err := c.ShouldBindJSON(&updatedProduct); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &updatedProduct) {
+		return
+	}
+
+	pc.saveProduct(ctx, c, productID, &updatedProduct)
+}
+
+// PatchProduct changes only the fields the patch names.
+func (pc *ProductController) PatchProduct(c *gin.Context) {
+	ctx, cancel := requestContext(c, pc.Config)
+	defer cancel()
+
+	productID, ok := parseID(c, "id", "product")
+	if !ok {
 		return
 	}
 
-	_, err = pc.Collection.UpdateOne(
-		context.Background(),
-		bson.M{"_id": productID},
-		bson.M{"$set": updatedProduct},
-	)
+	existing, err := pc.Products.FindByID(ctx, productID)
+	if err == nil && existing.DeletedAt != nil {
+		err = repository.ErrNotFound
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(notFound(err, "Product not found"))
+		return
+	}
+
+	var patchedProduct models.Product
+	if !bindPatch(c, existing, &patchedProduct) {
 		return
 	}
 
-	updatedProduct.ID = primitive.NewObjectID()
-	c.JSON(http.StatusOK, updatedProduct)
+	pc.saveProduct(ctx, c, productID, &patchedProduct)
 }
 
-func (pc *ProductController) DeleteProduct(c *gin.Context) {
-	id := c.Param("id")
-	productID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID format"})
+// saveProduct overwrites the product with product, except for its stock,
+// records the new version and responds with what was stored.
+func (pc *ProductController) saveProduct(ctx context.Context, c *gin.Context, productID primitive.ObjectID, product *models.Product) {
+	if err := checkPrices(product); err != nil {
+		c.Error(err)
 		return
 	}
 
-	var existingProduct models.Product
-	err = pc.Collection.FindOne(context.Background(), bson.M{"_id": productID}).Decode(&existingProduct)
+	product.ID = productID
+	product.DeletedAt = nil
+
+	var stored *models.Product
+	err := pc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		stored, err = auditedChange(txCtx, c, pc.AuditLog, models.AuditUpdate, models.EntityProduct, productID, pc.Products.FindByID,
+			func(ctx context.Context, _ primitive.ObjectID) error { return pc.Products.Update(ctx, product) })
+		if err != nil {
+			return err
+		}
+		return recordProductVersion(txCtx, pc.ProductVersions, stored, requestUser(c))
+	})
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
-			return
+		if err == repository.ErrDuplicate {
+			err = apperror.Conflict("concurrent_update", "Product was changed concurrently, please retry")
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(notFound(err, "Product not found"))
 		return
 	}
 
-	td := NewTransactionDetailController(pc.Collection.Database())
+	c.JSON(http.StatusOK, stored)
+}
+
+// checkPrices rejects a product with two prices in the same currency.
+func checkPrices(product *models.Product) error {
+	i := product.DuplicatePrice()
+	if i < 0 {
+		return nil
+	}
+	field := fmt.Sprintf("prices[%d]", i)
+	message := "repeats the currency " + product.Prices[i].Currency()
+	return apperror.Validation(field+" "+message).WithField(field, message)
+}
+
+func (pc *ProductController) DeleteProduct(c *gin.Context) {
+	ctx, cancel := requestContext(c, pc.Config)
+	defer cancel()
+
+	productID, ok := parseID(c, "id", "product")
+	if !ok {
+		return
+	}
 
-	transactionCount, err := td.Collection.CountDocuments(context.Background(), bson.M{"product_id": productID})
+	// Transaction details keep referring to a deleted product
+	err := pc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		_, err := auditedChange(txCtx, c, pc.AuditLog, models.AuditDelete, models.EntityProduct, productID, pc.Products.FindByID, pc.Products.Delete)
+		return err
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(notFound(err, "Product not found"))
 		return
 	}
 
-	if transactionCount > 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot delete product with associated transaction"})
+	c.JSON(http.StatusOK, gin.H{"message": "Product deleted"})
+}
+
+func (pc *ProductController) RestoreProduct(c *gin.Context) {
+	ctx, cancel := requestContext(c, pc.Config)
+	defer cancel()
+
+	productID, ok := parseID(c, "id", "product")
+	if !ok {
 		return
 	}
 
-	_, err = pc.Collection.DeleteOne(context.Background(), bson.M{"_id": productID})
+	var product *models.Product
+	err := pc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		product, err = auditedChange(txCtx, c, pc.AuditLog, models.AuditRestore, models.EntityProduct, productID, pc.Products.FindByID, pc.Products.Restore)
+		return err
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(notFound(err, "Deleted product not found"))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Product deleted"})
+	c.JSON(http.StatusOK, product)
 }
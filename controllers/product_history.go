@@ -0,0 +1,82 @@
+// controllers/product_history.go
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+var productVersionListSpec = listSpec[models.ProductVersion]{
+	Fields: repository.ProductVersionFields,
+	Filters: []filterParam{
+		{Param: "date_from", Field: "created_at", Op: repository.OpGte},
+		{Param: "date_to", Field: "created_at", Op: repository.OpLte},
+	},
+	DefaultSort: repository.Sort{Field: "version", Descending: true},
+}
+
+// GetProductHistory lists the versions of a product, newest first. The
+// history of a deleted product stays readable.
+func (pc *ProductController) GetProductHistory(c *gin.Context) {
+	ctx, cancel := requestContext(c, pc.Config)
+	defer cancel()
+
+	productID, ok := parseID(c, "id", "product")
+	if !ok {
+		return
+	}
+	query, ok := bindListQuery(c, productVersionListSpec)
+	if !ok {
+		return
+	}
+
+	if _, err := pc.Products.FindByID(ctx, productID); err != nil {
+		c.Error(notFound(err, "Product not found"))
+		return
+	}
+
+	query.Filters = append(query.Filters, repository.Filter{Field: "product_id", Op: repository.OpEq, Value: productID})
+	page, err := pc.ProductVersions.List(ctx, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newListResponse(query, page.Total, page.Next, page.Items))
+}
+
+// recordProductVersion appends the current state of product to its
+// history, numbered after the latest version. Nothing is recorded when
+// the product is unchanged since that version. Run it inside the unit of
+// work that writes the product; a concurrent change taking the same number
+// fails it with repository.ErrDuplicate.
+func recordProductVersion(ctx context.Context, versions repository.ProductVersionRepository, product *models.Product, user string) error {
+	latest, err := versions.List(ctx, repository.ListQuery{
+		Filters: []repository.Filter{{Field: "product_id", Op: repository.OpEq, Value: product.ID}},
+		Sort:    repository.Sort{Field: "version", Descending: true},
+		Limit:   1,
+	})
+	if err != nil {
+		return err
+	}
+
+	version := models.NewProductVersion(product)
+	version.Version = 1
+	if len(latest.Items) > 0 {
+		if latest.Items[0].SameAs(version) {
+			return nil
+		}
+		version.Version = latest.Items[0].Version + 1
+	}
+	version.ID = primitive.NewObjectID()
+	version.User = user
+	version.CreatedAt = time.Now()
+	return versions.Create(ctx, &version)
+}
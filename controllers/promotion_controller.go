@@ -0,0 +1,344 @@
+// controllers/promotion_controller.go
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// PromotionController maintains the promotions and coupon codes that
+// transactions are discounted with.
+type PromotionController struct {
+	Promotions repository.PromotionRepository
+	References *ReferenceValidator
+	AuditLog   repository.AuditRepository
+	UnitOfWork repository.UnitOfWork
+	Config     *config.Config
+}
+
+func NewPromotionController(repos *repository.Repositories, cfg *config.Config) *PromotionController {
+	return &PromotionController{
+		Promotions: repos.Promotions,
+		References: NewReferenceValidator(repos),
+		AuditLog:   repos.AuditLog,
+		UnitOfWork: repos.UnitOfWork,
+		Config:     cfg,
+	}
+}
+
+func (pc *PromotionController) CreatePromotion(c *gin.Context) {
+	ctx, cancel := requestContext(c, pc.Config)
+	defer cancel()
+
+	var promotion models.Promotion
+	if !bindJSON(c, &promotion) {
+		return
+	}
+	if err := pc.checkPromotion(ctx, primitive.NilObjectID, &promotion); err != nil {
+		c.Error(err)
+		return
+	}
+
+	promotion.ID = primitive.NewObjectID()
+	promotion.UsageCount = 0
+	promotion.DeletedAt = nil
+
+	err := pc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		if err := pc.Promotions.Create(txCtx, &promotion); err != nil {
+			return err
+		}
+		return recordAudit(txCtx, c, pc.AuditLog, models.AuditCreate, models.EntityPromotion, promotion.ID, nil, promotion)
+	})
+	if err != nil {
+		// Another request may have taken the code since it was checked
+		if err == repository.ErrDuplicate {
+			err = errCodeTaken()
+		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, promotion)
+}
+
+var promotionListSpec = listSpec[models.Promotion]{
+	Fields: repository.PromotionFields,
+	Filters: []filterParam{
+		{Param: "name", Field: "name", Op: repository.OpContains},
+		{Param: "code", Field: "code", Op: repository.OpEq},
+		{Param: "type", Field: "type", Op: repository.OpEq},
+		{Param: "scope", Field: "scope", Op: repository.OpEq},
+		{Param: "is_active", Field: "is_active", Op: repository.OpEq},
+	},
+	DefaultSort: repository.Sort{Field: "id"},
+}
+
+func (pc *PromotionController) GetPromotions(c *gin.Context) {
+	ctx, cancel := requestContext(c, pc.Config)
+	defer cancel()
+
+	query, ok := bindListQuery(c, promotionListSpec)
+	if !ok {
+		return
+	}
+
+	page, err := pc.Promotions.List(ctx, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newListResponse(query, page.Total, page.Next, page.Items))
+}
+
+func (pc *PromotionController) GetPromotion(c *gin.Context) {
+	ctx, cancel := requestContext(c, pc.Config)
+	defer cancel()
+
+	promotionID, ok := parseID(c, "id", "promotion")
+	if !ok {
+		return
+	}
+
+	includeDeleted, ok := bindIncludeDeleted(c)
+	if !ok {
+		return
+	}
+
+	promotion, err := pc.Promotions.FindByID(ctx, promotionID)
+	if err == nil && promotion.DeletedAt != nil && !includeDeleted {
+		err = repository.ErrNotFound
+	}
+	if err != nil {
+		c.Error(notFound(err, "Promotion not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+func (pc *PromotionController) UpdatePromotion(c *gin.Context) {
+	ctx, cancel := requestContext(c, pc.Config)
+	defer cancel()
+
+	promotionID, ok := parseID(c, "id", "promotion")
+	if !ok {
+		return
+	}
+
+	var updatedPromotion models.Promotion
+	if !bindJSON(c, &updatedPromotion) {
+		return
+	}
+
+	pc.savePromotion(ctx, c, promotionID, &updatedPromotion)
+}
+
+// PatchPromotion changes only the fields the patch names.
+func (pc *PromotionController) PatchPromotion(c *gin.Context) {
+	ctx, cancel := requestContext(c, pc.Config)
+	defer cancel()
+
+	promotionID, ok := parseID(c, "id", "promotion")
+	if !ok {
+		return
+	}
+
+	existing, err := pc.Promotions.FindByID(ctx, promotionID)
+	if err == nil && existing.DeletedAt != nil {
+		err = repository.ErrNotFound
+	}
+	if err != nil {
+		c.Error(notFound(err, "Promotion not found"))
+		return
+	}
+
+	var patchedPromotion models.Promotion
+	if !bindPatch(c, existing, &patchedPromotion) {
+		return
+	}
+
+	pc.savePromotion(ctx, c, promotionID, &patchedPromotion)
+}
+
+// savePromotion overwrites the promotion with promotion and responds with
+// what was stored. The usage count is left as it is.
+func (pc *PromotionController) savePromotion(ctx context.Context, c *gin.Context, promotionID primitive.ObjectID, promotion *models.Promotion) {
+	if err := pc.checkPromotion(ctx, promotionID, promotion); err != nil {
+		c.Error(err)
+		return
+	}
+	promotion.ID = promotionID
+	promotion.DeletedAt = nil
+
+	var stored *models.Promotion
+	err := pc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		stored, err = auditedChange(txCtx, c, pc.AuditLog, models.AuditUpdate, models.EntityPromotion, promotionID, pc.Promotions.FindByID,
+			func(ctx context.Context, _ primitive.ObjectID) error { return pc.Promotions.Update(ctx, promotion) })
+		return err
+	})
+	if err == repository.ErrDuplicate {
+		err = errCodeTaken()
+	}
+	if err != nil {
+		c.Error(notFound(err, "Promotion not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, stored)
+}
+
+// DeletePromotion stops a promotion from applying. Transactions it was
+// applied to keep their discounts.
+func (pc *PromotionController) DeletePromotion(c *gin.Context) {
+	ctx, cancel := requestContext(c, pc.Config)
+	defer cancel()
+
+	promotionID, ok := parseID(c, "id", "promotion")
+	if !ok {
+		return
+	}
+
+	err := pc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		_, err := auditedChange(txCtx, c, pc.AuditLog, models.AuditDelete, models.EntityPromotion, promotionID, pc.Promotions.FindByID, pc.Promotions.Delete)
+		return err
+	})
+	if err != nil {
+		c.Error(notFound(err, "Promotion not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promotion deleted"})
+}
+
+func (pc *PromotionController) RestorePromotion(c *gin.Context) {
+	ctx, cancel := requestContext(c, pc.Config)
+	defer cancel()
+
+	promotionID, ok := parseID(c, "id", "promotion")
+	if !ok {
+		return
+	}
+
+	var promotion *models.Promotion
+	err := pc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		promotion, err = auditedChange(txCtx, c, pc.AuditLog, models.AuditRestore, models.EntityPromotion, promotionID, pc.Promotions.FindByID,
+			func(ctx context.Context, id primitive.ObjectID) error {
+				if err := pc.checkCodeFree(ctx, id); err != nil {
+					return err
+				}
+				return pc.Promotions.Restore(ctx, id)
+			})
+		return err
+	})
+	if err == repository.ErrDuplicate {
+		err = errCodeTaken()
+	}
+	if err != nil {
+		c.Error(notFound(err, "Deleted promotion not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+// checkPromotion normalises the coupon code of the promotion with the
+// given ID, the nil ID for a new one, and rejects rules that the binding
+// tags cannot express: the settings each type needs, a window that ends
+// before it starts, a code another promotion has, and missing products.
+func (pc *PromotionController) checkPromotion(ctx context.Context, promotionID primitive.ObjectID, promotion *models.Promotion) error {
+	promotion.Code = normalizeCoupon(promotion.Code)
+
+	appErr := apperror.Validation("request body is invalid")
+	switch promotion.Type {
+	case models.PromotionPercentage:
+		if promotion.Percent <= 0 {
+			appErr.WithField("percent", "must be greater than 0")
+		}
+	case models.PromotionFixed:
+		if promotion.Amount.Sign() <= 0 {
+			appErr.WithField("amount", "must be greater than 0")
+		}
+	case models.PromotionBuyXGetY:
+		if promotion.Scope != models.ScopeProduct {
+			appErr.WithField("scope", "must be product for buy_x_get_y promotions")
+		}
+		if promotion.BuyQuantity <= 0 {
+			appErr.WithField("buy_quantity", "must be greater than 0")
+		}
+		if promotion.GetQuantity <= 0 {
+			appErr.WithField("get_quantity", "must be greater than 0")
+		}
+	}
+	if promotion.Scope == models.ScopeProduct && len(promotion.ProductIDs) == 0 {
+		appErr.WithField("product_ids", "is required for product promotions")
+	}
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		appErr.WithField("ends_at", "must be after starts_at")
+	}
+	switch len(appErr.Fields) {
+	case 0:
+	case 1:
+		appErr.Message = appErr.Fields[0].Field + " " + appErr.Fields[0].Message
+		return appErr
+	default:
+		return appErr
+	}
+
+	if promotion.Code != "" {
+		other, err := pc.Promotions.FindByCode(ctx, promotion.Code)
+		if err == nil && other.ID != promotionID {
+			return errCodeTaken()
+		} else if err != nil && err != repository.ErrNotFound {
+			return err
+		}
+	}
+
+	check := pc.References.Check(ctx)
+	for i, id := range promotion.ProductIDs {
+		check.Product(fmt.Sprintf("product_ids[%d]", i), id)
+	}
+	return check.Err()
+}
+
+// checkCodeFree fails with repository.ErrDuplicate when another promotion
+// has the coupon code of the promotion with the given ID, so that a
+// restored promotion does not bring back a code that is in use.
+func (pc *PromotionController) checkCodeFree(ctx context.Context, promotionID primitive.ObjectID) error {
+	promotion, err := pc.Promotions.FindByID(ctx, promotionID)
+	if err != nil || promotion.Code == "" {
+		return err
+	}
+	other, err := pc.Promotions.FindByCode(ctx, promotion.Code)
+	if err == repository.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if other.ID != promotionID {
+		return repository.ErrDuplicate
+	}
+	return nil
+}
+
+func errCodeTaken() *apperror.Error {
+	return apperror.Conflict("duplicate", "Code already exists").WithField("code", "Code already exists")
+}
+
+// normalizeCoupon returns a coupon code as it is stored and looked up:
+// trimmed and in capitals, so codes match regardless of case.
+func normalizeCoupon(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,157 @@
+// controllers/reference_validator.go
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// Reasons a reference can be rejected.
+const (
+	ReferenceNotFound = "not_found"
+	ReferenceInactive = "inactive"
+	ReferenceDeleted  = "deleted"
+)
+
+// BrokenReference names a request field whose ID does not point at a usable document.
+type BrokenReference struct {
+	Field  string             `json:"field"`
+	ID     primitive.ObjectID `json:"id"`
+	Reason string             `json:"reason"`
+}
+
+// ReferenceError lists every broken reference found in a request.
+type ReferenceError struct {
+	References []BrokenReference `json:"references"`
+}
+
+func (e *ReferenceError) Error() string {
+	return fmt.Sprintf("request references %d missing or unusable document(s)", len(e.References))
+}
+
+func (e *ReferenceError) AppError() *apperror.Error {
+	return apperror.Unprocessable("broken_references", e.Error()).WithDetail("references", e.References)
+}
+
+// ReferenceValidator verifies that the foreign keys carried by a request
+// point at existing documents before anything is written.
+type ReferenceValidator struct {
+	Customers      repository.CustomerRepository
+	Products       repository.ProductRepository
+	PaymentMethods repository.PaymentMethodRepository
+}
+
+func NewReferenceValidator(repos *repository.Repositories) *ReferenceValidator {
+	return &ReferenceValidator{
+		Customers:      repos.Customers,
+		Products:       repos.Products,
+		PaymentMethods: repos.PaymentMethods,
+	}
+}
+
+// Check starts collecting reference checks for a single request.
+func (v *ReferenceValidator) Check(ctx context.Context) *ReferenceCheck {
+	return &ReferenceCheck{validator: v, ctx: ctx, seen: make(map[primitive.ObjectID]string)}
+}
+
+// ReferenceCheck accumulates broken references so that they can all be
+// reported at once. Lookups are cached per ID for the lifetime of the check.
+type ReferenceCheck struct {
+	validator *ReferenceValidator
+	ctx       context.Context
+	seen      map[primitive.ObjectID]string
+	broken    []BrokenReference
+	err       error
+}
+
+func (rc *ReferenceCheck) Customer(field string, id primitive.ObjectID) *ReferenceCheck {
+	return rc.check(field, id, func() (string, error) {
+		customer, err := rc.validator.Customers.FindByID(rc.ctx, id)
+		if err != nil {
+			return reasonFor(err)
+		}
+		if customer.DeletedAt != nil {
+			return ReferenceDeleted, nil
+		}
+		return "", nil
+	})
+}
+
+func (rc *ReferenceCheck) Product(field string, id primitive.ObjectID) *ReferenceCheck {
+	return rc.check(field, id, func() (string, error) {
+		product, err := rc.validator.Products.FindByID(rc.ctx, id)
+		if err != nil {
+			return reasonFor(err)
+		}
+		if product.DeletedAt != nil {
+			return ReferenceDeleted, nil
+		}
+		return "", nil
+	})
+}
+
+// ActivePaymentMethod also rejects payment methods that are switched off.
+func (rc *ReferenceCheck) ActivePaymentMethod(field string, id primitive.ObjectID) *ReferenceCheck {
+	return rc.check(field, id, func() (string, error) {
+		paymentMethod, err := rc.validator.PaymentMethods.FindByID(rc.ctx, id)
+		if err != nil {
+			return reasonFor(err)
+		}
+		if paymentMethod.DeletedAt != nil {
+			return ReferenceDeleted, nil
+		}
+		if !paymentMethod.IsActive {
+			return ReferenceInactive, nil
+		}
+		return "", nil
+	})
+}
+
+// Err returns a lookup failure, a *ReferenceError or nil.
+func (rc *ReferenceCheck) Err() error {
+	if rc.err != nil {
+		return rc.err
+	}
+	if len(rc.broken) > 0 {
+		return &ReferenceError{References: rc.broken}
+	}
+	return nil
+}
+
+func (rc *ReferenceCheck) check(field string, id primitive.ObjectID, lookup func() (string, error)) *ReferenceCheck {
+	if rc.err != nil {
+		return rc
+	}
+
+	reason, seen := rc.seen[id]
+	if !seen {
+		var err error
+		reason, err = lookup()
+		if err != nil {
+			rc.err = err
+			return rc
+		}
+		rc.seen[id] = reason
+	}
+
+	if reason != "" {
+		rc.broken = append(rc.broken, BrokenReference{Field: field, ID: id, Reason: reason})
+	}
+	return rc
+}
+
+func reasonFor(err error) (string, error) {
+	switch err {
+	case nil:
+		return "", nil
+	case repository.ErrNotFound:
+		return ReferenceNotFound, nil
+	default:
+		return "", err
+	}
+}
@@ -0,0 +1,140 @@
+// controllers/report_controller.go
+package controllers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/money"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type ReportController struct {
+	Transactions  repository.TransactionRepository
+	ExchangeRates repository.ExchangeRateRepository
+	Config        *config.Config
+}
+
+func NewReportController(repos *repository.Repositories, cfg *config.Config) *ReportController {
+	return &ReportController{
+		Transactions:  repos.Transactions,
+		ExchangeRates: repos.ExchangeRates,
+		Config:        cfg,
+	}
+}
+
+// SalesReport sums the transactions of a period in one currency.
+type SalesReport struct {
+	Currency     string          `json:"currency"`
+	Transactions int             `json:"transactions"`
+	TotalQty     float64         `json:"total_qty"`
+	TotalAmount  money.Money     `json:"total_amount"`
+	ByCurrency   []CurrencySales `json:"by_currency"`
+}
+
+// CurrencySales sums the transactions made in one currency, as made and
+// converted into the currency of the report.
+type CurrencySales struct {
+	Currency     string      `json:"currency"`
+	Transactions int         `json:"transactions"`
+	TotalAmount  money.Money `json:"total_amount"`
+	Converted    money.Money `json:"converted"`
+}
+
+// MaxReportDays bounds the period of a sales report.
+const MaxReportDays = 366
+
+var salesReportFilters = []filterParam{
+	{Param: "date_from", Field: "transaction_date", Op: repository.OpGte},
+	{Param: "date_to", Field: "transaction_date", Op: repository.OpLte},
+}
+
+// GetSalesReport sums the transactions between date_from and date_to, both
+// required and at most MaxReportDays apart, in the currency the currency
+// parameter names, DefaultCurrency by default. Each transaction is
+// converted at the exchange rate in effect on its transaction date; the
+// rates of each currency are read once for the whole report.
+func (rc *ReportController) GetSalesReport(c *gin.Context) {
+	ctx, cancel := requestContext(c, rc.Config)
+	defer cancel()
+
+	currency := strings.ToUpper(c.DefaultQuery("currency", money.DefaultCurrency))
+	if _, ok := money.Exponent(currency); !ok {
+		c.Error(invalidParam("currency", "currency must be a supported currency code"))
+		return
+	}
+	for _, param := range salesReportFilters {
+		if _, ok := c.GetQuery(param.Param); !ok {
+			c.Error(invalidParam(param.Param, param.Param+" is required"))
+			return
+		}
+	}
+	filters, ok := bindFilters(c, repository.TransactionFields, salesReportFilters)
+	if !ok {
+		return
+	}
+	// date_to is the end of the period: the start of the day after it, for
+	// a plain date
+	from, to := filters[0].Value.(time.Time), filters[1].Value.(time.Time)
+	if !to.After(from) || to.Sub(from) > MaxReportDays*24*time.Hour {
+		c.Error(invalidParam("date_to", "date_to must be after date_from and at most "+strconv.Itoa(MaxReportDays)+" days later"))
+		return
+	}
+
+	page, err := rc.Transactions.List(ctx, repository.ListQuery{
+		Filters: filters,
+		Sort:    repository.Sort{Field: "transaction_date"},
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	report := SalesReport{Currency: currency, TotalAmount: money.Zero(currency), ByCurrency: []CurrencySales{}}
+	byCurrency := make(map[string]*CurrencySales)
+	rates := newRateBook(rc.ExchangeRates, to)
+	for _, transaction := range page.Items {
+		converted, err := rates.convert(ctx, "currency", transaction.TotalAmount, currency, transaction.TransactionDate)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		made := transaction.TotalAmount.Currency()
+		sales, ok := byCurrency[made]
+		if !ok {
+			sales = &CurrencySales{Currency: made, TotalAmount: money.Zero(made), Converted: money.Zero(currency)}
+			byCurrency[made] = sales
+		}
+		if sales.TotalAmount, err = sales.TotalAmount.Add(transaction.TotalAmount); err != nil {
+			c.Error(err)
+			return
+		}
+		if sales.Converted, err = sales.Converted.Add(converted); err != nil {
+			c.Error(err)
+			return
+		}
+		if report.TotalAmount, err = report.TotalAmount.Add(converted); err != nil {
+			c.Error(err)
+			return
+		}
+		sales.Transactions++
+		report.Transactions++
+		report.TotalQty += transaction.TotalQty
+	}
+
+	for _, sales := range byCurrency {
+		report.ByCurrency = append(report.ByCurrency, *sales)
+	}
+	sort.Slice(report.ByCurrency, func(i, j int) bool {
+		return report.ByCurrency[i].Currency < report.ByCurrency[j].Currency
+	})
+
+	c.JSON(http.StatusOK, report)
+}
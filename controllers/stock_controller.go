@@ -0,0 +1,157 @@
+// controllers/stock_controller.go
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// StockController records stock receipts and adjustments and serves the
+// stock ledger of a product. Sales and returns are recorded by the
+// transaction endpoints.
+type StockController struct {
+	Products       repository.ProductRepository
+	StockMovements repository.StockMovementRepository
+	UnitOfWork     repository.UnitOfWork
+	Config         *config.Config
+}
+
+func NewStockController(repos *repository.Repositories, cfg *config.Config) *StockController {
+	return &StockController{
+		Products:       repos.Products,
+		StockMovements: repos.StockMovements,
+		UnitOfWork:     repos.UnitOfWork,
+		Config:         cfg,
+	}
+}
+
+// StockReceiptRequest is the body of POST /product/:id/receipts.
+type StockReceiptRequest struct {
+	Quantity float64 `json:"quantity" binding:"required,gt=0"`
+	Reason   string  `json:"reason"`
+}
+
+// StockAdjustmentRequest is the body of POST /product/:id/adjustments. A
+// negative quantity takes stock out.
+type StockAdjustmentRequest struct {
+	Quantity float64 `json:"quantity" binding:"required"`
+	Reason   string  `json:"reason" binding:"required,max=200"`
+}
+
+// StockMovementResponse is a recorded movement with the product's stock
+// after it.
+type StockMovementResponse struct {
+	Movement models.StockMovement `json:"movement"`
+	Product  models.Product       `json:"product"`
+}
+
+func (sc *StockController) PostReceipt(c *gin.Context) {
+	var request StockReceiptRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	sc.postMovement(c, models.MovementReceipt, request.Quantity, request.Reason)
+}
+
+func (sc *StockController) PostAdjustment(c *gin.Context) {
+	var request StockAdjustmentRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+	sc.postMovement(c, models.MovementAdjustment, request.Quantity, request.Reason)
+}
+
+func (sc *StockController) postMovement(c *gin.Context, movementType models.StockMovementType, quantity float64, reason string) {
+	ctx, cancel := requestContext(c, sc.Config)
+	defer cancel()
+
+	productID, ok := parseID(c, "id", "product")
+	if !ok {
+		return
+	}
+
+	response := StockMovementResponse{Movement: models.StockMovement{
+		ID:        primitive.NewObjectID(),
+		ProductID: productID,
+		Type:      movementType,
+		Quantity:  quantity,
+		Reason:    reason,
+		User:      requestUser(c),
+		CreatedAt: time.Now(),
+	}}
+	err := sc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		if err := recordMovement(txCtx, sc.Products, sc.StockMovements, &response.Movement); err != nil {
+			return err
+		}
+		product, err := sc.Products.FindByID(txCtx, productID)
+		if err != nil {
+			return err
+		}
+		response.Product = *product
+		return nil
+	})
+	if err != nil {
+		c.Error(notFound(err, "Product not found"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+var stockMovementListSpec = listSpec[models.StockMovement]{
+	Fields: repository.StockMovementFields,
+	Filters: []filterParam{
+		{Param: "type", Field: "type", Op: repository.OpEq},
+		{Param: "date_from", Field: "created_at", Op: repository.OpGte},
+		{Param: "date_to", Field: "created_at", Op: repository.OpLte},
+	},
+	DefaultSort: repository.Sort{Field: "created_at", Descending: true},
+}
+
+// GetMovements lists the stock ledger of a product, newest first.
+func (sc *StockController) GetMovements(c *gin.Context) {
+	ctx, cancel := requestContext(c, sc.Config)
+	defer cancel()
+
+	productID, ok := parseID(c, "id", "product")
+	if !ok {
+		return
+	}
+	query, ok := bindListQuery(c, stockMovementListSpec)
+	if !ok {
+		return
+	}
+
+	if _, err := sc.Products.FindByID(ctx, productID); err != nil {
+		c.Error(notFound(err, "Product not found"))
+		return
+	}
+
+	query.Filters = append(query.Filters, repository.Filter{Field: "product_id", Op: repository.OpEq, Value: productID})
+	page, err := sc.StockMovements.List(ctx, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newListResponse(query, page.Total, page.Next, page.Items))
+}
+
+// recordMovement applies one movement to its product's stock and appends
+// it to the ledger. Run it inside a unit of work so that both happen or
+// neither does.
+func recordMovement(ctx context.Context, products repository.ProductRepository, movements repository.StockMovementRepository, movement *models.StockMovement) error {
+	err := applyStock(ctx, products, []stockChange{{ProductID: movement.ProductID, Delta: movement.Quantity}})
+	if err != nil {
+		return err
+	}
+	return movements.Create(ctx, movement)
+}
@@ -3,116 +3,168 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/config"
 	"github.com/mifaabiyyu/go-test.git/models"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/mifaabiyyu/go-test.git/money"
+	"github.com/mifaabiyyu/go-test.git/repository"
+	"github.com/mifaabiyyu/go-test.git/tax"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type TransactionController struct {
-	Collection             *mongo.Collection
+	Transactions           repository.TransactionRepository
+	Products               repository.ProductRepository
+	PaymentMethods         repository.PaymentMethodRepository
+	Details                repository.TransactionDetailRepository
+	Payments               repository.TransactionPaymentRepository
+	StockMovements         repository.StockMovementRepository
+	ExchangeRates          repository.ExchangeRateRepository
+	Promotions             repository.PromotionRepository
+	Taxes                  *tax.Table
 	TransactionPaymentCtrl *TransactionPaymentController
+	References             *ReferenceValidator
+	AuditLog               repository.AuditRepository
+	UnitOfWork             repository.UnitOfWork
+	Config                 *config.Config
 }
 
 type CreateTransactionResponse struct {
 	Transaction models.Transaction `json:"transaction"`
 }
 
-func NewTransactionController(db *mongo.Database, tpc *TransactionPaymentController) *TransactionController {
-	return &TransactionController{
-		Collection:             db.Collection("transactions"),
-		TransactionPaymentCtrl: tpc,
-	}
+// TransactionUpdate is the body accepted when replacing a transaction.
+type TransactionUpdate struct {
+	Transaction models.Transaction          `json:"transaction"`
+	Details     []models.TransactionDetail  `json:"details" binding:"dive"`
+	Payments    []models.TransactionPayment `json:"payments" binding:"dive"`
 }
 
-func NewTransactionDetailController(db *mongo.Database) *TransactionController {
+func NewTransactionController(repos *repository.Repositories, tpc *TransactionPaymentController, cfg *config.Config) *TransactionController {
 	return &TransactionController{
-		Collection: db.Collection("transaction_details"),
+		Transactions:           repos.Transactions,
+		Products:               repos.Products,
+		PaymentMethods:         repos.PaymentMethods,
+		Details:                repos.TransactionDetails,
+		Payments:               repos.TransactionPayments,
+		StockMovements:         repos.StockMovements,
+		ExchangeRates:          repos.ExchangeRates,
+		Promotions:             repos.Promotions,
+		Taxes:                  tax.New(cfg.Tax),
+		TransactionPaymentCtrl: tpc,
+		References:             NewReferenceValidator(repos),
+		AuditLog:               repos.AuditLog,
+		UnitOfWork:             repos.UnitOfWork,
+		Config:                 cfg,
 	}
 }
 
 func (tc *TransactionController) CreateTransaction(c *gin.Context) {
+	ctx, cancel := requestContext(c, tc.Config)
+	defer cancel()
+
 	var transactionData struct {
 		Transaction models.Transaction          `json:"transaction" `
-		Details     []models.TransactionDetail  `json:"details" binding:"required"`
-		Payments    []models.TransactionPayment `json:"payments"`
+		Details     []models.TransactionDetail  `json:"details" binding:"required,min=1,dive"`
+		Payments    []models.TransactionPayment `json:"payments" binding:"dive"`
 	}
 
-	if err := c.ShouldBindJSON(&transactionData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &transactionData) {
 		return
 	}
 
-	var totalAmount float64
-	var totalQty float64
-	for _, detail := range transactionData.Details {
-		totalAmount += detail.Subtotal
-		totalQty += detail.Quantity
+	// Every payment sent with a new transaction is new
+	for i := range transactionData.Payments {
+		transactionData.Payments[i].ID = primitive.NilObjectID
 	}
 
-	transactionData.Transaction.TransactionDate = time.Now()
-	transactionData.Transaction.TotalAmount = totalAmount
-	transactionData.Transaction.TotalQty = totalQty
+	if err := checkPaymentStatuses(transactionData.Payments); err != nil {
+		c.Error(err)
+		return
+	}
 
-	ctx := context.TODO() // Create a context
+	if err := tc.checkReferences(ctx, &transactionData.Transaction, transactionData.Details, transactionData.Payments); err != nil {
+		c.Error(err)
+		return
+	}
 
-	session, err := tc.Collection.Database().Client().StartSession()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if transactionData.Transaction.Currency == "" {
+		transactionData.Transaction.Currency = money.DefaultCurrency
+	}
+	currency := transactionData.Transaction.Currency
+	if err := priceDetails(ctx, tc.Products, tc.ExchangeRates, tc.Taxes, transactionData.Details, currency, time.Now()); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := applyPromotions(ctx, tc.Promotions, &transactionData.Transaction, transactionData.Details, nil, time.Now()); err != nil {
+		c.Error(err)
 		return
 	}
-	defer session.EndSession(ctx)
 
-	// Start a transaction
-	err = session.StartTransaction()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := transactionTotals(tc.Taxes, &transactionData.Transaction, transactionData.Details); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := settlePayments(ctx, tc.ExchangeRates, transactionData.Payments, currency); err != nil {
+		c.Error(err)
 		return
 	}
 
-	// Insert transaction
-	transactionData.Transaction.ID = primitive.NewObjectID()
-	_, err = tc.Collection.InsertOne(ctx, transactionData.Transaction)
-	if err != nil {
-		session.AbortTransaction(ctx)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := checkStock(ctx, tc.Products, stockChanges(nil, transactionData.Details)); err != nil {
+		c.Error(err)
 		return
 	}
 
-	// Insert transaction details
-	for i, detail := range transactionData.Details {
-		detail.ID = primitive.NewObjectID()
-		detail.TransactionID = transactionData.Transaction.ID
-		transactionData.Details[i] = detail
-		_, err = tc.Collection.Database().Collection("transaction_details").InsertOne(ctx, detail)
-		if err != nil {
-			session.AbortTransaction(ctx)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+	transactionData.Transaction.TransactionDate = time.Now()
+
+	err := tc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		// Insert transaction
+		transactionData.Transaction.ID = primitive.NewObjectID()
+		if err := tc.Transactions.Create(txCtx, &transactionData.Transaction); err != nil {
+			return err
 		}
-	}
 
-	// Assign the transaction ID to the payment
-	for i, payment := range transactionData.Payments {
-		payment.ID = primitive.NewObjectID()
-		payment.TransactionID = transactionData.Transaction.ID
-		transactionData.Payments[i] = payment
-		if err := tc.TransactionPaymentCtrl.CreateTransactionPayment(&payment); err != nil {
-			session.AbortTransaction(ctx)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+		// Insert transaction details
+		for i, detail := range transactionData.Details {
+			detail.ID = primitive.NewObjectID()
+			detail.TransactionID = transactionData.Transaction.ID
+			transactionData.Details[i] = detail
+			if err := tc.Details.Create(txCtx, &detail); err != nil {
+				return err
+			}
 		}
-	}
-	// Commit the transaction
-	err = session.CommitTransaction(ctx)
+
+		// Take the sold quantities out of stock
+		if err := moveStock(txCtx, tc.Products, tc.StockMovements, nil, transactionData.Details, "transaction created", requestUser(c)); err != nil {
+			return err
+		}
+		if err := applyUsage(txCtx, tc.Promotions, usageChanges(nil, transactionData.Transaction.Discounts)); err != nil {
+			return err
+		}
+
+		// Assign the transaction ID to the payment
+		for i, payment := range transactionData.Payments {
+			payment.ID = primitive.NewObjectID()
+			payment.TransactionID = transactionData.Transaction.ID
+			transactionData.Payments[i] = payment
+			if err := tc.TransactionPaymentCtrl.CreateTransactionPayment(txCtx, &payment); err != nil {
+				return err
+			}
+		}
+
+		created, err := tc.findWithRelations(txCtx, transactionData.Transaction.ID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(txCtx, c, tc.AuditLog, models.AuditCreate, models.EntityTransaction, created.ID, nil, created)
+	})
 	if err != nil {
-		session.AbortTransaction(ctx)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -122,300 +174,341 @@ func (tc *TransactionController) CreateTransaction(c *gin.Context) {
 
 	response.Transaction.Details = transactionData.Details
 	response.Transaction.Payments = transactionData.Payments
+	response.Transaction.SummarizePayments()
 
 	c.JSON(http.StatusCreated, response)
 }
 
+var transactionListSpec = listSpec[models.Transaction]{
+	Fields: repository.TransactionFields,
+	Filters: []filterParam{
+		{Param: "customer_id", Field: "customer_id", Op: repository.OpEq},
+		{Param: "currency", Field: "currency", Op: repository.OpEq},
+		{Param: "date_from", Field: "transaction_date", Op: repository.OpGte},
+		{Param: "date_to", Field: "transaction_date", Op: repository.OpLte},
+		{Param: "min_total", Field: "total_amount", Op: repository.OpGte},
+		{Param: "max_total", Field: "total_amount", Op: repository.OpLte},
+	},
+	DefaultSort: repository.Sort{Field: "transaction_date"},
+}
+
 func (tc *TransactionController) GetTransactions(c *gin.Context) {
-	var transactions []models.Transaction
+	ctx, cancel := requestContext(c, tc.Config)
+	defer cancel()
 
-	orderParam := c.DefaultQuery("order_by", "transaction_date") // Default order by transaction_date
-	orderDirection := 1
-	if c.DefaultQuery("order_direction", "asc") == "desc" {
-		orderDirection = -1
+	query, ok := bindListQuery(c, transactionListSpec)
+	if !ok {
+		return
+	}
+	embed, ok := bindEmbed(c)
+	if !ok {
+		return
 	}
 
-	sortBy := bson.D{{Key: orderParam, Value: orderDirection}}
-
-	cursor, err := tc.Collection.Find(context.Background(), bson.M{}, options.Find().SetSort(sortBy))
+	page, err := tc.Transactions.List(ctx, query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	defer cursor.Close(context.Background())
-
-	for cursor.Next(context.Background()) {
-		var transaction models.Transaction
-		cursor.Decode(&transaction)
-
-		// Fetch details associated with the transaction
-		detailsCursor, err := tc.Collection.Database().Collection("transaction_details").Find(context.Background(), bson.M{"transaction_id": transaction.ID})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		defer detailsCursor.Close(context.Background())
-
-		var details []models.TransactionDetail
-		for detailsCursor.Next(context.Background()) {
-			var detail models.TransactionDetail
-			detailsCursor.Decode(&detail)
-			details = append(details, detail)
-		}
-		transaction.Details = details
+	transactions := page.Items
 
-		// Fetch payments associated with the transaction
-		paymentsCursor, err := tc.Collection.Database().Collection("transaction_payments").Find(context.Background(), bson.M{"transaction_id": transaction.ID})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		defer paymentsCursor.Close(context.Background())
-
-		var payments []models.TransactionPayment
-		for paymentsCursor.Next(context.Background()) {
-			var payment models.TransactionPayment
-			paymentsCursor.Decode(&payment)
-			payments = append(payments, payment)
-		}
-		transaction.Payments = payments
-
-		transactions = append(transactions, transaction)
+	// Fetch details and payments of the whole page at once
+	if err := tc.loadRelations(ctx, transactions, embed); err != nil {
+		c.Error(err)
+		return
 	}
 
-	c.JSON(http.StatusOK, transactions)
+	c.JSON(http.StatusOK, newListResponse(query, page.Total, page.Next, transactions))
 }
 
 func (tc *TransactionController) GetTransaction(c *gin.Context) {
-	id := c.Param("id")
-	transactionID, err := primitive.ObjectIDFromHex(id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
-		return
-	}
-
-	var transaction models.Transaction
-	err = tc.Collection.FindOne(context.Background(), bson.M{"_id": transactionID}).Decode(&transaction)
+	ctx, cancel := requestContext(c, tc.Config)
+	defer cancel()
 
-	detailsCursor, err := tc.Collection.Database().Collection("transaction_details").Find(context.Background(), bson.M{"transaction_id": transaction.ID})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	transactionID, ok := parseID(c, "id", "transaction")
+	if !ok {
 		return
 	}
-	defer detailsCursor.Close(context.Background())
-
-	var details []models.TransactionDetail
-	for detailsCursor.Next(context.Background()) {
-		var detail models.TransactionDetail
-		detailsCursor.Decode(&detail)
-		details = append(details, detail)
+	embed, ok := bindEmbed(c)
+	if !ok {
+		return
 	}
-	transaction.Details = details
 
-	// Fetch payments associated with the transaction
-	paymentsCursor, err := tc.Collection.Database().Collection("transaction_payments").Find(context.Background(), bson.M{"transaction_id": transaction.ID})
+	transaction, err := tc.Transactions.FindByID(ctx, transactionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(notFound(err, "Transaction not found"))
 		return
 	}
-	defer paymentsCursor.Close(context.Background())
-
-	var payments []models.TransactionPayment
-	for paymentsCursor.Next(context.Background()) {
-		var payment models.TransactionPayment
-		paymentsCursor.Decode(&payment)
-		payments = append(payments, payment)
-	}
-	transaction.Payments = payments
 
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+	transactions := []models.Transaction{*transaction}
+	if err := tc.loadRelations(ctx, transactions, embed); err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, transaction)
+	c.JSON(http.StatusOK, transactions[0])
 }
 
 func (tc *TransactionController) UpdateTransaction(c *gin.Context) {
-	transactionID := c.Param("id") // Get the transaction ID from the URL parameter
-
-	var updatedData struct {
-		Transaction models.Transaction          `json:"transaction"`
-		Details     []models.TransactionDetail  `json:"details"`
-		Payments    []models.TransactionPayment `json:"payments"`
-	}
+	ctx, cancel := requestContext(c, tc.Config)
+	defer cancel()
 
-	if err := c.ShouldBindJSON(&updatedData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	transactionID, ok := parseID(c, "id", "transaction")
+	if !ok {
 		return
 	}
 
-	var totalAmount float64
-	var totalQty float64
-	for _, detail := range updatedData.Details {
-		totalAmount += detail.Subtotal
-		totalQty += detail.Quantity
+	var updatedData TransactionUpdate
+	if !bindJSON(c, &updatedData) {
+		return
 	}
 
-	updatedData.Transaction.TransactionDate = time.Now()
-	updatedData.Transaction.TotalAmount = totalAmount
-	updatedData.Transaction.TotalQty = totalQty
+	tc.saveTransaction(ctx, c, transactionID, &updatedData)
+}
 
-	ctx := context.TODO() // Create a context
+// PatchTransaction patches a transaction as GET renders it, details and
+// payments included, and saves the result the way UpdateTransaction does.
+func (tc *TransactionController) PatchTransaction(c *gin.Context) {
+	ctx, cancel := requestContext(c, tc.Config)
+	defer cancel()
 
-	session, err := tc.Collection.Database().Client().StartSession()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	transactionID, ok := parseID(c, "id", "transaction")
+	if !ok {
 		return
 	}
-	defer session.EndSession(ctx)
 
-	// Start a transaction
-	err = session.StartTransaction()
+	existing, err := tc.findWithRelations(ctx, transactionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(notFound(err, "Transaction not found"))
 		return
 	}
 
-	// Update transaction
-	updatedData.Transaction.ID, err = primitive.ObjectIDFromHex(transactionID)
-	_, err = tc.Collection.ReplaceOne(ctx, bson.M{"_id": updatedData.Transaction.ID}, updatedData.Transaction)
-	if err != nil {
-		session.AbortTransaction(ctx)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var patched models.Transaction
+	if !bindPatch(c, existing, &patched) {
 		return
 	}
 
-	// Delete existing transaction details
-	_, err = tc.Collection.Database().Collection("transaction_details").DeleteMany(ctx, bson.M{"transaction_id": updatedData.Transaction.ID})
-	if err != nil {
-		session.AbortTransaction(ctx)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	// Details are priced again from the catalogue, as they are when PUT
+	// sends them without amounts
+	for i := range patched.Details {
+		patched.Details[i].Price = money.Money{}
+		patched.Details[i].Subtotal = money.Money{}
 	}
-
-	// Insert new transaction details
-	for i, detail := range updatedData.Details {
-		detail.ID = primitive.NewObjectID()
-		detail.TransactionID = updatedData.Transaction.ID
-		updatedData.Details[i] = detail
-		_, err = tc.Collection.Database().Collection("transaction_details").InsertOne(ctx, detail)
-		if err != nil {
-			session.AbortTransaction(ctx)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+	// Payments already stored are managed through the payment endpoints
+	var payments []models.TransactionPayment
+	for _, payment := range patched.Payments {
+		if payment.ID.IsZero() {
+			payments = append(payments, payment)
 		}
 	}
 
-	// Commit the transaction
-	err = session.CommitTransaction(ctx)
-	if err != nil {
-		session.AbortTransaction(ctx)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Transaction updated successfully"})
+	tc.saveTransaction(ctx, c, transactionID, &TransactionUpdate{
+		Transaction: patched,
+		Details:     patched.Details,
+		Payments:    payments,
+	})
 }
 
-func (tc *TransactionController) DeleteTransaction(c *gin.Context) {
-	transactionID := c.Param("id") // Get the transaction ID from the URL parameter
+// saveTransaction replaces the transaction and its details with updatedData,
+// adds its new payments and responds with what was stored.
+func (tc *TransactionController) saveTransaction(ctx context.Context, c *gin.Context, transactionID primitive.ObjectID, updatedData *TransactionUpdate) {
+	// Details and payments are stored on their own
+	updatedData.Transaction.Details = nil
+	updatedData.Transaction.Payments = nil
 
-	ctx := context.TODO() // Create a context
+	if err := checkPaymentStatuses(updatedData.Payments); err != nil {
+		c.Error(err)
+		return
+	}
 
-	session, err := tc.Collection.Database().Client().StartSession()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := tc.checkReferences(ctx, &updatedData.Transaction, updatedData.Details, updatedData.Payments); err != nil {
+		c.Error(err)
 		return
 	}
-	defer session.EndSession(ctx)
 
-	// Start a transaction
-	err = session.StartTransaction()
+	// The currency is fixed once the transaction is created
+	existing, err := tc.Transactions.FindByID(ctx, transactionID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(notFound(err, "Transaction not found"))
+		return
+	}
+	currency := existing.Currency
+	switch updatedData.Transaction.Currency {
+	case "", currency:
+		updatedData.Transaction.Currency = currency
+	default:
+		c.Error(apperror.Validation("transaction.currency cannot change once the transaction is created").
+			WithField("transaction.currency", "cannot change once the transaction is created"))
 		return
 	}
 
-	// Delete the transaction
-	_, err = tc.Collection.DeleteOne(ctx, bson.M{"_id": transactionID})
-	if err != nil {
-		session.AbortTransaction(ctx)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := priceDetails(ctx, tc.Products, tc.ExchangeRates, tc.Taxes, updatedData.Details, currency, time.Now()); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := applyPromotions(ctx, tc.Promotions, &updatedData.Transaction, updatedData.Details, existing.Discounts, time.Now()); err != nil {
+		c.Error(err)
 		return
 	}
 
-	// Delete associated transaction details
-	_, err = tc.Collection.Database().Collection("transaction_details").DeleteMany(ctx, bson.M{"transaction_id": transactionID})
-	if err != nil {
-		session.AbortTransaction(ctx)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := transactionTotals(tc.Taxes, &updatedData.Transaction, updatedData.Details); err != nil {
+		c.Error(err)
+		return
+	}
+	if err := settlePayments(ctx, tc.ExchangeRates, updatedData.Payments, currency); err != nil {
+		c.Error(err)
 		return
 	}
 
-	// Delete associated transaction payments
-	_, err = tc.Collection.Database().Collection("transaction_payments").DeleteMany(ctx, bson.M{"transaction_id": transactionID})
+	previous, err := tc.Details.ListByTransaction(ctx, transactionID)
 	if err != nil {
-		session.AbortTransaction(ctx)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
+	if err := checkStock(ctx, tc.Products, stockChanges(previous, updatedData.Details)); err != nil {
+		c.Error(err)
+		return
+	}
+
+	updatedData.Transaction.TransactionDate = time.Now()
 
-	// Commit the transaction
-	err = session.CommitTransaction(ctx)
+	var stored *models.Transaction
+	err = tc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		before, err := tc.findWithRelations(txCtx, transactionID)
+		if err != nil {
+			return err
+		}
+
+		// Update transaction
+		updatedData.Transaction.ID = transactionID
+		if err := tc.Transactions.Update(txCtx, &updatedData.Transaction); err != nil {
+			return err
+		}
+
+		// Delete existing transaction details
+		previous, err := tc.Details.ListByTransaction(txCtx, transactionID)
+		if err != nil {
+			return err
+		}
+		if err := tc.Details.DeleteByTransaction(txCtx, transactionID); err != nil {
+			return err
+		}
+
+		// Insert new transaction details
+		for i, detail := range updatedData.Details {
+			detail.ID = primitive.NewObjectID()
+			detail.TransactionID = transactionID
+			updatedData.Details[i] = detail
+			if err := tc.Details.Create(txCtx, &detail); err != nil {
+				return err
+			}
+		}
+
+		// Return the old quantities to stock and take out the new ones
+		if err := moveStock(txCtx, tc.Products, tc.StockMovements, previous, updatedData.Details, "transaction updated", requestUser(c)); err != nil {
+			return err
+		}
+		if err := applyUsage(txCtx, tc.Promotions, usageChanges(before.Discounts, updatedData.Transaction.Discounts)); err != nil {
+			return err
+		}
+
+		// Record new payments; payments that already have an ID are
+		// managed through the payment endpoints.
+		for _, payment := range updatedData.Payments {
+			if !payment.ID.IsZero() {
+				continue
+			}
+			payment.ID = primitive.NewObjectID()
+			payment.TransactionID = transactionID
+			if err := tc.TransactionPaymentCtrl.CreateTransactionPayment(txCtx, &payment); err != nil {
+				return err
+			}
+		}
+
+		stored, err = tc.findWithRelations(txCtx, transactionID)
+		if err != nil {
+			return err
+		}
+		return recordAudit(txCtx, c, tc.AuditLog, models.AuditUpdate, models.EntityTransaction, transactionID, before, stored)
+	})
 	if err != nil {
-		session.AbortTransaction(ctx)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(notFound(err, "Transaction not found"))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Transaction and associated details/payments deleted"})
+	c.JSON(http.StatusOK, stored)
 }
 
-func (tc *TransactionController) UpdateTransactionDetail(detail models.TransactionDetail) error {
-	ctx := context.TODO()
-
-	// Define the filter to identify the specific detail you want to update
-	filter := bson.M{"_id": detail.ID}
+func (tc *TransactionController) DeleteTransaction(c *gin.Context) {
+	ctx, cancel := requestContext(c, tc.Config)
+	defer cancel()
 
-	// Define the update data
-	update := bson.M{
-		"$set": bson.M{
-			"quantity": detail.Quantity,
-			"subtotal": detail.Subtotal,
-			"price":    detail.Price,
-		},
+	transactionID, ok := parseID(c, "id", "transaction")
+	if !ok {
+		return
 	}
 
-	// Perform the update using the updateOne method
-	_, err := tc.Collection.Database().Collection("transaction_details").UpdateOne(ctx, filter, update)
+	err := tc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		before, err := tc.findWithRelations(txCtx, transactionID)
+		if err != nil {
+			return err
+		}
+
+		// Delete associated transaction details and payments first so
+		// backends with foreign keys never see orphaned rows, and return
+		// the sold quantities to stock.
+		details, err := tc.Details.ListByTransaction(txCtx, transactionID)
+		if err != nil {
+			return err
+		}
+		if err := tc.Details.DeleteByTransaction(txCtx, transactionID); err != nil {
+			return err
+		}
+		if err := moveStock(txCtx, tc.Products, tc.StockMovements, details, nil, "transaction deleted", requestUser(c)); err != nil {
+			return err
+		}
+		// Give back the uses of the promotions it was discounted with
+		if err := applyUsage(txCtx, tc.Promotions, usageChanges(before.Discounts, nil)); err != nil {
+			return err
+		}
+		if err := tc.Payments.DeleteByTransaction(txCtx, transactionID); err != nil {
+			return err
+		}
+
+		// Delete the transaction
+		if err := tc.Transactions.Delete(txCtx, transactionID); err != nil {
+			return err
+		}
+		return recordAudit(txCtx, c, tc.AuditLog, models.AuditDelete, models.EntityTransaction, transactionID, before, nil)
+	})
 	if err != nil {
-		return err
+		c.Error(notFound(err, "Transaction not found"))
+		return
 	}
 
-	return nil
+	c.JSON(http.StatusOK, gin.H{"message": "Transaction and associated details/payments deleted"})
 }
 
-func (tc *TransactionController) UpdateTransactionPayment(payment models.TransactionPayment) error {
-	ctx := context.TODO()
+func (tc *TransactionController) UpdateTransactionDetail(ctx context.Context, detail models.TransactionDetail) error {
+	return tc.Details.Update(ctx, &detail)
+}
 
-	// Define the filter to identify the specific payment you want to update
-	filter := bson.M{"_id": payment.ID}
+func (tc *TransactionController) UpdateTransactionPayment(ctx context.Context, payment models.TransactionPayment) error {
+	return tc.Payments.Update(ctx, &payment)
+}
 
-	// Define the update data
-	update := bson.M{
-		"$set": bson.M{
-			"status":       payment.Status,
-			"paid_amount":  payment.PaidAmount,
-			"payment_date": payment.PaymentDate,
-		},
+// checkReferences verifies the customer, products and payment methods a
+// transaction points at.
+func (tc *TransactionController) checkReferences(ctx context.Context, transaction *models.Transaction, details []models.TransactionDetail, payments []models.TransactionPayment) error {
+	check := tc.References.Check(ctx).Customer("transaction.customer_id", transaction.CustomerID)
+	for i, detail := range details {
+		check.Product(fmt.Sprintf("details[%d].product_id", i), detail.ProductID)
 	}
-
-	// Perform the update using the updateOne method
-	_, err := tc.Collection.Database().Collection("transaction_payments").UpdateOne(ctx, filter, update)
-	if err != nil {
-		return err
+	for i, payment := range payments {
+		if !payment.ID.IsZero() {
+			continue // stored earlier
+		}
+		check.ActivePaymentMethod(fmt.Sprintf("payments[%d].payment_method_id", i), payment.PaymentMethodID)
 	}
-
-	return nil
+	return check.Err()
 }
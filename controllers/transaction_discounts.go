@@ -0,0 +1,182 @@
+// controllers/transaction_discounts.go
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/promotion"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// applyPromotions discounts the priced details of transaction with the
+// automatic promotions in effect at the given time and the promotion its
+// coupon code names, and records on both what each promotion took off.
+// Promotions the transaction already used, listed in previous, do not
+// count against their usage limit again. Automatic fixed-amount promotions
+// only apply to transactions in their currency; a coupon for one in
+// another currency is rejected.
+func applyPromotions(ctx context.Context, promotions repository.PromotionRepository, transaction *models.Transaction, details []models.TransactionDetail, previous []models.AppliedDiscount, at time.Time) error {
+	used := make(map[primitive.ObjectID]bool, len(previous))
+	for _, discount := range previous {
+		used[discount.PromotionID] = true
+	}
+
+	automatic, err := promotions.Automatic(ctx, at)
+	if err != nil {
+		return err
+	}
+	currency := transaction.Currency
+	var applicable []models.Promotion
+	for _, candidate := range automatic {
+		if candidate.Type == models.PromotionFixed && candidate.Amount.Currency() != currency {
+			continue
+		}
+		if !candidate.Exhausted() || used[candidate.ID] {
+			applicable = append(applicable, candidate)
+		}
+	}
+
+	transaction.CouponCode = normalizeCoupon(transaction.CouponCode)
+	var coupon *models.Promotion
+	if transaction.CouponCode != "" {
+		if coupon, err = findCoupon(ctx, promotions, transaction.CouponCode, used, at); err != nil {
+			return err
+		}
+		applicable = append(applicable, *coupon)
+	}
+
+	lines := make([]promotion.Line, len(details))
+	for i, detail := range details {
+		lines[i] = promotion.Line{ProductID: detail.ProductID, Quantity: detail.Quantity, Price: detail.Price, Amount: detail.Subtotal}
+	}
+	result, err := promotion.Apply(currency, lines, applicable)
+	var currencyErr *promotion.CurrencyError
+	if errors.As(err, &currencyErr) && coupon != nil && currencyErr.Promotion.ID == coupon.ID {
+		return invalidCoupon(transaction.CouponCode, "currency_mismatch", "is in "+coupon.Amount.Currency()+", not "+currency)
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := range details {
+		details[i].DiscountAmount = result.Lines[i]
+		details[i].Discounts = []models.AppliedDiscount{}
+	}
+	transaction.DiscountAmount = result.Total
+	transaction.Discounts = []models.AppliedDiscount{}
+	couponApplied := false
+	for _, discount := range result.Discounts {
+		applied := models.AppliedDiscount{
+			PromotionID: discount.Promotion.ID,
+			Name:        discount.Promotion.Name,
+			Code:        discount.Promotion.Code,
+			Amount:      discount.Amount,
+		}
+		transaction.Discounts = append(transaction.Discounts, applied)
+		for i, share := range discount.Lines {
+			if !share.IsZero() {
+				applied.Amount = share
+				details[i].Discounts = append(details[i].Discounts, applied)
+			}
+		}
+		if coupon != nil && discount.Promotion.ID == coupon.ID {
+			couponApplied = true
+		}
+	}
+	if coupon != nil && !couponApplied {
+		return invalidCoupon(transaction.CouponCode, "not_applicable", "does not apply to this transaction")
+	}
+	return nil
+}
+
+// findCoupon looks up the promotion of a coupon code and checks that it
+// can be used at the given time. A promotion in used is already counted
+// against its usage limit.
+func findCoupon(ctx context.Context, promotions repository.PromotionRepository, code string, used map[primitive.ObjectID]bool, at time.Time) (*models.Promotion, error) {
+	coupon, err := promotions.FindByCode(ctx, code)
+	if err == repository.ErrNotFound || (err == nil && coupon.DeletedAt != nil) {
+		return nil, invalidCoupon(code, "not_found", "does not exist")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !coupon.IsActive:
+		return nil, invalidCoupon(code, "inactive", "is not active")
+	case coupon.StartsAt != nil && at.Before(*coupon.StartsAt):
+		return nil, invalidCoupon(code, "not_started", "is not valid yet")
+	case coupon.EndsAt != nil && !at.Before(*coupon.EndsAt):
+		return nil, invalidCoupon(code, "expired", "has expired")
+	case coupon.Exhausted() && !used[coupon.ID]:
+		return nil, invalidCoupon(code, "usage_limit_reached", "has reached its usage limit")
+	}
+	return coupon, nil
+}
+
+func invalidCoupon(code, reason, message string) *apperror.Error {
+	return apperror.Unprocessable("coupon_invalid", "transaction.coupon_code "+message).
+		WithField("transaction.coupon_code", message).
+		WithDetail("coupon_code", code).
+		WithDetail("reason", reason)
+}
+
+// usageChange is what a write adds to (positive) or takes from (negative)
+// one promotion's usage count.
+type usageChange struct {
+	PromotionID primitive.ObjectID
+	Delta       int
+}
+
+// usageChanges nets the promotions a transaction used before a write
+// against the ones it uses after: a transaction counts once towards every
+// promotion it uses. Promotions used both before and after are left out;
+// the rest are ordered by ID so concurrent writes lock rows in the same
+// order.
+func usageChanges(before, after []models.AppliedDiscount) []usageChange {
+	deltas := make(map[primitive.ObjectID]int)
+	for _, discount := range before {
+		deltas[discount.PromotionID]--
+	}
+	for _, discount := range after {
+		deltas[discount.PromotionID]++
+	}
+
+	var changes []usageChange
+	for id, delta := range deltas {
+		if delta != 0 {
+			changes = append(changes, usageChange{PromotionID: id, Delta: delta})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i].PromotionID, changes[j].PromotionID
+		return bytes.Compare(a[:], b[:]) < 0
+	})
+	return changes
+}
+
+// applyUsage adjusts usage counts by changes. Run it inside the unit of
+// work that writes the transaction, so a failed write gives the uses back.
+func applyUsage(ctx context.Context, promotions repository.PromotionRepository, changes []usageChange) error {
+	for _, change := range changes {
+		err := promotions.AdjustUsage(ctx, change.PromotionID, change.Delta)
+		if err == repository.ErrUsageLimit {
+			// Used up elsewhere since applyPromotions ran
+			return apperror.Unprocessable("usage_limit_reached", fmt.Sprintf("promotion %s has reached its usage limit", change.PromotionID.Hex())).
+				WithDetail("promotion_id", change.PromotionID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
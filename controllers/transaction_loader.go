@@ -0,0 +1,155 @@
+// controllers/transaction_loader.go
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+// embedOptions selects the names loadRelations copies onto details and
+// payments, requested with ?embed=product,payment_method.
+type embedOptions struct {
+	ProductNames       bool
+	PaymentMethodNames bool
+}
+
+// bindEmbed reads the embed parameter, reporting a validation error and
+// returning false when it names something that cannot be embedded.
+func bindEmbed(c *gin.Context) (embedOptions, bool) {
+	var embed embedOptions
+	raw := c.Query("embed")
+	if raw == "" {
+		return embed, true
+	}
+
+	for _, name := range strings.Split(raw, ",") {
+		switch strings.TrimSpace(name) {
+		case "product":
+			embed.ProductNames = true
+		case "payment_method":
+			embed.PaymentMethodNames = true
+		default:
+			c.Error(invalidParam("embed", "embed accepts product and payment_method"))
+			return embed, false
+		}
+	}
+	return embed, true
+}
+
+// loadRelations fills in the details and payments of every transaction
+// with one query per related store, however many transactions there are,
+// and one more per embedded name.
+func (tc *TransactionController) loadRelations(ctx context.Context, transactions []models.Transaction, embed embedOptions) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, len(transactions))
+	for i, transaction := range transactions {
+		ids[i] = transaction.ID
+	}
+
+	details, err := tc.Details.ListByTransactions(ctx, ids...)
+	if err != nil {
+		return err
+	}
+	payments, err := tc.Payments.ListByTransactions(ctx, ids...)
+	if err != nil {
+		return err
+	}
+
+	if embed.ProductNames {
+		if err := tc.embedProductNames(ctx, details); err != nil {
+			return err
+		}
+	}
+	if embed.PaymentMethodNames {
+		if err := tc.embedPaymentMethodNames(ctx, payments); err != nil {
+			return err
+		}
+	}
+
+	detailsByTransaction := make(map[primitive.ObjectID][]models.TransactionDetail, len(transactions))
+	for _, detail := range details {
+		detailsByTransaction[detail.TransactionID] = append(detailsByTransaction[detail.TransactionID], detail)
+	}
+	paymentsByTransaction := make(map[primitive.ObjectID][]models.TransactionPayment, len(transactions))
+	for _, payment := range payments {
+		paymentsByTransaction[payment.TransactionID] = append(paymentsByTransaction[payment.TransactionID], payment)
+	}
+
+	for i := range transactions {
+		transactions[i].Details = detailsByTransaction[transactions[i].ID]
+		transactions[i].Payments = paymentsByTransaction[transactions[i].ID]
+		transactions[i].SummarizePayments()
+	}
+	return nil
+}
+
+func (tc *TransactionController) embedProductNames(ctx context.Context, details []models.TransactionDetail) error {
+	// Only details stored before names were snapshotted lack one
+	ids := make([]primitive.ObjectID, 0, len(details))
+	for _, detail := range details {
+		if detail.ProductName == "" {
+			ids = append(ids, detail.ProductID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	products, err := tc.Products.ListByIDs(ctx, ids...)
+	if err != nil {
+		return err
+	}
+	names := make(map[primitive.ObjectID]string, len(products))
+	for _, product := range products {
+		names[product.ID] = product.Name
+	}
+
+	for i := range details {
+		if details[i].ProductName == "" {
+			details[i].ProductName = names[details[i].ProductID]
+		}
+	}
+	return nil
+}
+
+func (tc *TransactionController) embedPaymentMethodNames(ctx context.Context, payments []models.TransactionPayment) error {
+	ids := make([]primitive.ObjectID, 0, len(payments))
+	for _, payment := range payments {
+		ids = append(ids, payment.PaymentMethodID)
+	}
+
+	paymentMethods, err := tc.PaymentMethods.ListByIDs(ctx, ids...)
+	if err != nil {
+		return err
+	}
+	names := make(map[primitive.ObjectID]string, len(paymentMethods))
+	for _, paymentMethod := range paymentMethods {
+		names[paymentMethod.ID] = paymentMethod.Name
+	}
+
+	for i := range payments {
+		payments[i].PaymentMethodName = names[payments[i].PaymentMethodID]
+	}
+	return nil
+}
+
+// findWithRelations loads one transaction with its details and payments.
+func (tc *TransactionController) findWithRelations(ctx context.Context, id primitive.ObjectID) (*models.Transaction, error) {
+	transaction, err := tc.Transactions.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	transactions := []models.Transaction{*transaction}
+	if err := tc.loadRelations(ctx, transactions, embedOptions{}); err != nil {
+		return nil, err
+	}
+	return &transactions[0], nil
+}
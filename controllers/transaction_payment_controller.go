@@ -3,27 +3,213 @@ package controllers
 
 import (
 	"context"
-	// "net/http"
+	"net/http"
+	"time"
 
-	// "github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/config"
 	"github.com/mifaabiyyu/go-test.git/models"
-	"go.mongodb.org/mongo-driver/mongo"
+	"github.com/mifaabiyyu/go-test.git/money"
+	"github.com/mifaabiyyu/go-test.git/repository"
 )
 
 type TransactionPaymentController struct {
-	Collection *mongo.Collection
+	Transactions  repository.TransactionRepository
+	Payments      repository.TransactionPaymentRepository
+	ExchangeRates repository.ExchangeRateRepository
+	References    *ReferenceValidator
+	AuditLog      repository.AuditRepository
+	UnitOfWork    repository.UnitOfWork
+	Config        *config.Config
+}
+
+// PaymentRequest is the body accepted when adding a payment to an
+// existing transaction.
+type PaymentRequest struct {
+	PaymentMethodID primitive.ObjectID   `json:"payment_method_id" binding:"objectid"`
+	Status          models.PaymentStatus `json:"status"`
+	PaidAmount      money.Money          `json:"paid_amount" binding:"positive_money"`
+	PaymentDate     time.Time            `json:"payment_date"`
 }
 
-func NewTransactionPaymentController(db *mongo.Database) *TransactionPaymentController {
+// PaymentUpdateRequest is the body accepted when correcting a payment.
+// Status changes go through the lifecycle endpoints instead.
+type PaymentUpdateRequest struct {
+	PaidAmount  money.Money `json:"paid_amount" binding:"positive_money"`
+	PaymentDate time.Time   `json:"payment_date"`
+}
+
+func NewTransactionPaymentController(repos *repository.Repositories, cfg *config.Config) *TransactionPaymentController {
 	return &TransactionPaymentController{
-		Collection: db.Collection("transaction_payments"),
+		Transactions:  repos.Transactions,
+		Payments:      repos.TransactionPayments,
+		ExchangeRates: repos.ExchangeRates,
+		References:    NewReferenceValidator(repos),
+		AuditLog:      repos.AuditLog,
+		UnitOfWork:    repos.UnitOfWork,
+		Config:        cfg,
 	}
 }
 
-func (tpc *TransactionPaymentController) CreateTransactionPayment(payment *models.TransactionPayment) error {
-	_, err := tpc.Collection.InsertOne(context.Background(), payment)
+// CreateTransactionPayment stores a payment. Pass the transaction context
+// when the payment is part of a larger unit of work.
+func (tpc *TransactionPaymentController) CreateTransactionPayment(ctx context.Context, payment *models.TransactionPayment) error {
+	err := tpc.Payments.Create(ctx, payment)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+func (tpc *TransactionPaymentController) GetTransactionPayments(c *gin.Context) {
+	ctx, cancel := requestContext(c, tpc.Config)
+	defer cancel()
+
+	transaction, ok := tpc.findTransaction(ctx, c)
+	if !ok {
+		return
+	}
+
+	payments, err := tpc.Payments.ListByTransaction(ctx, transaction.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if payments == nil {
+		payments = []models.TransactionPayment{}
+	}
+
+	c.JSON(http.StatusOK, payments)
+}
+
+func (tpc *TransactionPaymentController) AddTransactionPayment(c *gin.Context) {
+	ctx, cancel := requestContext(c, tpc.Config)
+	defer cancel()
+
+	var request PaymentRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	transaction, ok := tpc.findTransaction(ctx, c)
+	if !ok {
+		return
+	}
+	payment := models.TransactionPayment{
+		ID:              primitive.NewObjectID(),
+		TransactionID:   transaction.ID,
+		PaymentMethodID: request.PaymentMethodID,
+		Status:          request.Status,
+		PaidAmount:      request.PaidAmount,
+		PaymentDate:     request.PaymentDate,
+	}
+	if !payment.Status.IsInitial() {
+		c.Error(errNotInitial("status"))
+		return
+	}
+	if err := tpc.References.Check(ctx).ActivePaymentMethod("payment_method_id", payment.PaymentMethodID).Err(); err != nil {
+		c.Error(err)
+		return
+	}
+	if payment.Status == models.PaymentPaid && payment.PaymentDate.IsZero() {
+		payment.PaymentDate = time.Now()
+	}
+	if err := settlePayment(ctx, tpc.ExchangeRates, "paid_amount", &payment, transaction.Currency); err != nil {
+		c.Error(err)
+		return
+	}
+
+	err := tpc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		if err := tpc.CreateTransactionPayment(txCtx, &payment); err != nil {
+			return err
+		}
+		return recordAudit(txCtx, c, tpc.AuditLog, models.AuditCreate, models.EntityTransactionPayment, payment.ID, nil, payment)
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
+
+// UpdateTransactionPayment corrects the amount or date of a payment that
+// has not been settled yet.
+func (tpc *TransactionPaymentController) UpdateTransactionPayment(c *gin.Context) {
+	ctx, cancel := requestContext(c, tpc.Config)
+	defer cancel()
+
+	var request PaymentUpdateRequest
+	if !bindJSON(c, &request) {
+		return
+	}
+
+	transactionID, ok := parseID(c, "id", "transaction")
+	if !ok {
+		return
+	}
+	paymentID, ok := parseID(c, "pid", "payment")
+	if !ok {
+		return
+	}
+
+	// Read, check and write the payment in one unit of work, so a capture
+	// or refund that lands in between is not overwritten.
+	var payment *models.TransactionPayment
+	err := tpc.UnitOfWork.WithTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		payment, err = tpc.Payments.FindByID(txCtx, paymentID)
+		if err != nil {
+			return err
+		}
+		if payment.TransactionID != transactionID {
+			return repository.ErrNotFound
+		}
+
+		if payment.Status != models.PaymentPending && payment.Status != models.PaymentAuthorized {
+			return apperror.Conflict("payment_settled", "only pending or authorized payments can be changed").
+				WithDetail("status", payment.Status)
+		}
+
+		transaction, err := tpc.Transactions.FindByID(txCtx, transactionID)
+		if err != nil {
+			return err
+		}
+
+		before := *payment
+		payment.PaidAmount = request.PaidAmount
+		payment.PaymentDate = request.PaymentDate
+		if err := settlePayment(txCtx, tpc.ExchangeRates, "paid_amount", payment, transaction.Currency); err != nil {
+			return err
+		}
+		if err := tpc.Payments.Update(txCtx, payment); err != nil {
+			return err
+		}
+		return recordAudit(txCtx, c, tpc.AuditLog, models.AuditUpdate, models.EntityTransactionPayment, paymentID, before, payment)
+	})
+	if err != nil {
+		c.Error(notFound(err, "Payment not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// findTransaction parses the :id parameter and loads the transaction,
+// reporting the error itself when it does not exist.
+func (tpc *TransactionPaymentController) findTransaction(ctx context.Context, c *gin.Context) (*models.Transaction, bool) {
+	transactionID, ok := parseID(c, "id", "transaction")
+	if !ok {
+		return nil, false
+	}
+
+	transaction, err := tpc.Transactions.FindByID(ctx, transactionID)
+	if err != nil {
+		c.Error(notFound(err, "Transaction not found"))
+		return nil, false
+	}
+	return transaction, true
+}
@@ -0,0 +1,226 @@
+// controllers/transaction_pricing.go
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/money"
+	"github.com/mifaabiyyu/go-test.git/repository"
+	"github.com/mifaabiyyu/go-test.git/tax"
+)
+
+// PriceMismatch reports a detail whose client-sent amount disagrees with
+// the amount computed from the product catalogue.
+type PriceMismatch struct {
+	Index     int                `json:"index"`
+	ProductID primitive.ObjectID `json:"product_id"`
+	Field     string             `json:"field"`
+	Sent      money.Money        `json:"sent"`
+	Expected  money.Money        `json:"expected"`
+}
+
+// PricingError is returned when transaction details cannot be priced from
+// the catalogue, either because products are unknown or have no price in
+// the transaction's currency, or because the client sent prices that do
+// not match.
+type PricingError struct {
+	UnknownProducts  []primitive.ObjectID `json:"unknown_products,omitempty"`
+	UnpricedProducts []primitive.ObjectID `json:"unpriced_products,omitempty"`
+	Mismatches       []PriceMismatch      `json:"mismatches,omitempty"`
+}
+
+func (e *PricingError) Error() string {
+	var parts []string
+	if len(e.UnknownProducts) > 0 {
+		parts = append(parts, fmt.Sprintf("%d unknown product(s)", len(e.UnknownProducts)))
+	}
+	if len(e.UnpricedProducts) > 0 {
+		parts = append(parts, fmt.Sprintf("%d product(s) without a price in the currency", len(e.UnpricedProducts)))
+	}
+	if len(e.Mismatches) > 0 {
+		parts = append(parts, fmt.Sprintf("%d price mismatch(es)", len(e.Mismatches)))
+	}
+	return "transaction details do not match the product catalogue: " + strings.Join(parts, ", ")
+}
+
+func (e *PricingError) AppError() *apperror.Error {
+	appErr := apperror.Unprocessable("pricing_failed", e.Error())
+	if len(e.UnknownProducts) > 0 {
+		appErr.WithDetail("unknown_products", e.UnknownProducts)
+	}
+	if len(e.UnpricedProducts) > 0 {
+		appErr.WithDetail("unpriced_products", e.UnpricedProducts)
+	}
+	if len(e.Mismatches) > 0 {
+		appErr.WithDetail("mismatches", e.Mismatches)
+	}
+	return appErr
+}
+
+// priceDetails sets Price, Subtotal, TaxRate and the product snapshot on
+// every detail from the product catalogue, in currency. A product without a
+// price in currency has its price converted at the exchange rate in effect
+// at the given time. Zero amounts sent by the client are treated as "not sent";
+// any other value must match the catalogue.
+func priceDetails(ctx context.Context, products repository.ProductRepository, rates repository.ExchangeRateRepository, taxes *tax.Table, details []models.TransactionDetail, currency string, at time.Time) error {
+	pricingErr := &PricingError{}
+	catalogue := make(map[primitive.ObjectID]*models.Product)
+	prices := make(map[primitive.ObjectID]*money.Money)
+
+	for i := range details {
+		detail := &details[i]
+
+		product, seen := catalogue[detail.ProductID]
+		if !seen {
+			found, err := products.FindByID(ctx, detail.ProductID)
+			if err != nil && err != repository.ErrNotFound {
+				return err
+			}
+			product = found
+			catalogue[detail.ProductID] = product
+			if product == nil {
+				pricingErr.UnknownProducts = append(pricingErr.UnknownProducts, detail.ProductID)
+			}
+		}
+		if product == nil {
+			continue
+		}
+
+		found, priced := prices[product.ID]
+		if !priced {
+			var err error
+			found, err = productPrice(ctx, rates, product, currency, at)
+			if err != nil {
+				return err
+			}
+			prices[product.ID] = found
+			if found == nil {
+				pricingErr.UnpricedProducts = append(pricingErr.UnpricedProducts, product.ID)
+			}
+		}
+		if found == nil {
+			continue
+		}
+		price := *found
+		subtotal, err := price.Mul(detail.Quantity)
+		if err != nil {
+			return err
+		}
+
+		if !detail.Price.IsZero() && !detail.Price.Equal(price) {
+			pricingErr.Mismatches = append(pricingErr.Mismatches, PriceMismatch{
+				Index: i, ProductID: detail.ProductID, Field: "price", Sent: detail.Price, Expected: price,
+			})
+		}
+		if !detail.Subtotal.IsZero() && !detail.Subtotal.Equal(subtotal) {
+			pricingErr.Mismatches = append(pricingErr.Mismatches, PriceMismatch{
+				Index: i, ProductID: detail.ProductID, Field: "subtotal", Sent: detail.Subtotal, Expected: subtotal,
+			})
+		}
+
+		detail.Price = price
+		detail.Subtotal = subtotal
+		detail.TaxRate = taxes.Rate(product.Category)
+		detail.ProductCode = product.Code
+		detail.ProductName = product.Name
+	}
+
+	if len(pricingErr.UnknownProducts) > 0 || len(pricingErr.UnpricedProducts) > 0 || len(pricingErr.Mismatches) > 0 {
+		return pricingErr
+	}
+	return nil
+}
+
+// productPrice returns the product's price in currency, converting its
+// main price when it has none in currency. It returns nil when there is
+// no exchange rate to convert at either.
+func productPrice(ctx context.Context, rates repository.ExchangeRateRepository, product *models.Product, currency string, at time.Time) (*money.Money, error) {
+	if price, ok := product.PriceIn(currency); ok {
+		return &price, nil
+	}
+	rate, err := effectiveRate(ctx, rates, product.Price.Currency(), currency, at)
+	if err == repository.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	price, err := rate.Convert(product.Price)
+	if err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+// transactionTotals taxes the priced and discounted details of transaction
+// and sets its subtotal, tax, total and quantity from them.
+func transactionTotals(taxes *tax.Table, transaction *models.Transaction, details []models.TransactionDetail) error {
+	lines := make([]tax.Line, len(details))
+	var totalQty float64
+	for i, detail := range details {
+		amount, err := detail.Subtotal.Sub(detail.DiscountAmount)
+		if err != nil {
+			return err
+		}
+		lines[i] = tax.Line{Amount: amount, Rate: detail.TaxRate}
+		totalQty += detail.Quantity
+	}
+	summary, err := taxes.Compute(transaction.Currency, lines)
+	if err != nil {
+		return err
+	}
+
+	for i, line := range summary.Lines {
+		details[i].TaxableAmount = line.Taxable
+		details[i].TaxAmount = line.Tax
+		details[i].Total = line.Total
+	}
+	transaction.Subtotal = summary.Taxable
+	transaction.TaxAmount = summary.Tax
+	transaction.TotalAmount = summary.Total
+	transaction.TaxInclusive = taxes.Inclusive()
+	transaction.Taxes = make([]models.TaxTotal, len(summary.ByRate))
+	for i, rate := range summary.ByRate {
+		transaction.Taxes[i] = models.TaxTotal{Rate: rate.Rate, TaxableAmount: rate.Taxable, TaxAmount: rate.Tax}
+	}
+	transaction.TotalQty = totalQty
+	return nil
+}
+
+// settlePayments sets the settled amount and exchange rate of every new
+// payment; payments with an ID are stored already.
+func settlePayments(ctx context.Context, rates repository.ExchangeRateRepository, payments []models.TransactionPayment, currency string) error {
+	for i := range payments {
+		if !payments[i].ID.IsZero() {
+			continue
+		}
+		if err := settlePayment(ctx, rates, fmt.Sprintf("payments[%d].paid_amount", i), &payments[i], currency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settlePayment converts the paid amount of payment into currency, the
+// currency of its transaction, at the exchange rate in effect on its
+// payment date, or now when it has none yet.
+func settlePayment(ctx context.Context, rates repository.ExchangeRateRepository, field string, payment *models.TransactionPayment, currency string) error {
+	at := payment.PaymentDate
+	if at.IsZero() {
+		at = time.Now()
+	}
+	settled, rate, err := convertAmount(ctx, rates, field, payment.PaidAmount, currency, at)
+	if err != nil {
+		return err
+	}
+	payment.SettledAmount = settled
+	payment.ExchangeRate = rate
+	return nil
+}
@@ -0,0 +1,163 @@
+// controllers/transaction_stock.go
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// StockShortage reports a product that cannot cover the quantity a
+// transaction asks for.
+type StockShortage struct {
+	ProductID primitive.ObjectID `json:"product_id"`
+	Requested float64            `json:"requested"`
+	Available float64            `json:"available"`
+}
+
+// StockError is returned when a transaction would sell more than is in
+// stock. It lists every product that falls short.
+type StockError struct {
+	Shortages []StockShortage `json:"shortages"`
+}
+
+func (e *StockError) Error() string {
+	return fmt.Sprintf("insufficient stock for %d product(s)", len(e.Shortages))
+}
+
+func (e *StockError) AppError() *apperror.Error {
+	return apperror.Unprocessable("insufficient_stock", e.Error()).WithDetail("shortages", e.Shortages)
+}
+
+// stockChange is the net quantity a write adds to (positive) or takes from
+// (negative) one product's stock.
+type stockChange struct {
+	ProductID primitive.ObjectID
+	Delta     float64
+}
+
+// stockChanges nets the quantities of the details a transaction had before
+// a write against the ones it has after, per product. Products whose
+// quantity is unchanged are left out; the rest are ordered by ID so
+// concurrent writes lock rows in the same order.
+func stockChanges(before, after []models.TransactionDetail) []stockChange {
+	deltas := make(map[primitive.ObjectID]float64)
+	for _, detail := range before {
+		deltas[detail.ProductID] += detail.Quantity
+	}
+	for _, detail := range after {
+		deltas[detail.ProductID] -= detail.Quantity
+	}
+
+	var changes []stockChange
+	for id, delta := range deltas {
+		if delta != 0 {
+			changes = append(changes, stockChange{ProductID: id, Delta: delta})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i].ProductID, changes[j].ProductID
+		return bytes.Compare(a[:], b[:]) < 0
+	})
+	return changes
+}
+
+// checkStock verifies up front that every product can cover the quantity
+// changes take from it, so all shortages are reported at once. It is only
+// a preview: applyStock is what guards against concurrent sales.
+func checkStock(ctx context.Context, products repository.ProductRepository, changes []stockChange) error {
+	var ids []primitive.ObjectID
+	for _, change := range changes {
+		if change.Delta < 0 {
+			ids = append(ids, change.ProductID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	found, err := products.ListByIDs(ctx, ids...)
+	if err != nil {
+		return err
+	}
+	available := make(map[primitive.ObjectID]float64, len(found))
+	for i := range found {
+		available[found[i].ID] = found[i].AvailableQuantity()
+	}
+
+	stockErr := &StockError{}
+	for _, change := range changes {
+		if change.Delta < 0 && available[change.ProductID] < -change.Delta {
+			stockErr.Shortages = append(stockErr.Shortages, StockShortage{
+				ProductID: change.ProductID,
+				Requested: -change.Delta,
+				Available: available[change.ProductID],
+			})
+		}
+	}
+	if len(stockErr.Shortages) > 0 {
+		return stockErr
+	}
+	return nil
+}
+
+// applyStock adjusts stock by changes. Run it inside the unit of work that
+// writes the details, so a failed write puts the stock back.
+func applyStock(ctx context.Context, products repository.ProductRepository, changes []stockChange) error {
+	for _, change := range changes {
+		err := products.AdjustStock(ctx, change.ProductID, change.Delta)
+		if err == repository.ErrInsufficientStock {
+			// Sold elsewhere since checkStock ran
+			shortage := StockShortage{ProductID: change.ProductID, Requested: -change.Delta}
+			if product, err := products.FindByID(ctx, change.ProductID); err == nil {
+				shortage.Available = product.AvailableQuantity()
+			}
+			return &StockError{Shortages: []StockShortage{shortage}}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveStock applies the stock changes of a transaction write and appends
+// them to the stock ledger: a return for every detail the transaction had
+// before and a sale for every detail it has after. Details must already
+// have their IDs.
+func moveStock(ctx context.Context, products repository.ProductRepository, movements repository.StockMovementRepository, before, after []models.TransactionDetail, reason, user string) error {
+	if err := applyStock(ctx, products, stockChanges(before, after)); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	entry := func(movementType models.StockMovementType, detail models.TransactionDetail, quantity float64) *models.StockMovement {
+		return &models.StockMovement{
+			ID:                  primitive.NewObjectID(),
+			ProductID:           detail.ProductID,
+			Type:                movementType,
+			Quantity:            quantity,
+			TransactionID:       detail.TransactionID,
+			TransactionDetailID: detail.ID,
+			Reason:              reason,
+			User:                user,
+			CreatedAt:           now,
+		}
+	}
+	var entries []*models.StockMovement
+	for _, detail := range before {
+		entries = append(entries, entry(models.MovementReturn, detail, detail.Quantity))
+	}
+	for _, detail := range after {
+		entries = append(entries, entry(models.MovementSale, detail, -detail.Quantity))
+	}
+	return movements.Create(ctx, entries...)
+}
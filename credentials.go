@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mifaabiyyu/go-test.git/auth"
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// createAPIKey issues an API key and writes it to w. This is the only time
+// the key is shown. args are the key name followed by its roles, admin when
+// none are given.
+func createAPIKey(ctx context.Context, w io.Writer, repos *repository.Repositories, args []string) error {
+	if len(args) == 0 || args[0] == "" {
+		return errors.New("create-api-key: a key name is required")
+	}
+	name := args[0]
+	roles, err := parseRoles(args[1:])
+	if err != nil {
+		return fmt.Errorf("create-api-key: %w", err)
+	}
+	if len(roles) == 0 {
+		roles = []models.Role{models.RoleAdmin}
+	}
+	apiKey, key, err := auth.IssueAPIKey(ctx, repos.APIKeys, name, roles, "create-api-key", nil)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "Created API key %s (%s) with roles %v:\n%s\n", apiKey.Name, apiKey.ID.Hex(), apiKey.Roles, key)
+	return err
+}
+
+// issueToken signs a JWT with the configured key and writes it to w. args
+// are the subject followed by at least one role.
+func issueToken(w io.Writer, cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] == "" {
+		return errors.New("issue-token: a subject is required")
+	}
+	subject := args[0]
+	roles, err := parseRoles(args[1:])
+	if err != nil {
+		return fmt.Errorf("issue-token: %w", err)
+	}
+	if len(roles) == 0 {
+		return errors.New("issue-token: at least one role is required")
+	}
+	jwt, err := auth.NewJWT(cfg.Auth.JWT)
+	if err != nil {
+		return err
+	}
+	if jwt == nil {
+		return errors.New("issue-token: auth.jwt.algorithm is not configured")
+	}
+	token, err := jwt.Issue(subject, roles, time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, token)
+	return err
+}
+
+func parseRoles(names []string) ([]models.Role, error) {
+	roles := make([]models.Role, 0, len(names))
+	for _, name := range names {
+		role := models.Role(name)
+		if !role.IsValid() {
+			return nil, fmt.Errorf("unknown role %q (use admin, accountant, cashier or viewer)", name)
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/controllers"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/money"
+	"github.com/mifaabiyyu/go-test.git/patch"
+	"github.com/mifaabiyyu/go-test.git/repository"
+	"github.com/mifaabiyyu/go-test.git/repository/memory"
+)
+
+// postRate records an exchange rate through the API.
+func postRate(t *testing.T, r http.Handler, from, to string, rate float64, effectiveFrom time.Time) models.ExchangeRate {
+	t.Helper()
+	w := performRequest(t, r, "POST", "/exchange-rates", models.ExchangeRate{
+		FromCurrency: from, ToCurrency: to, Rate: rate, EffectiveFrom: effectiveFrom,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /exchange-rates: %d %s", w.Code, w.Body.String())
+	}
+	var created models.ExchangeRate
+	decodeBody(t, w, &created)
+	return created
+}
+
+func TestExchangeRateConvert(t *testing.T) {
+	rate := models.ExchangeRate{FromCurrency: "USD", ToCurrency: "IDR", Rate: 15500.5}
+
+	converted, err := rate.Convert(money.MustParse("10", "USD"))
+	assert.NoError(t, err)
+	assert.Equal(t, money.MustParse("155005", "IDR"), converted)
+
+	// Either way round, rounding to the nearest minor unit
+	converted, err = rate.Convert(idr(10000))
+	assert.NoError(t, err)
+	assert.Equal(t, money.MustParse("0.65", "USD"), converted)
+	assert.Equal(t, 15500.5, rate.RateTo("IDR"))
+	assert.InDelta(t, 1/15500.5, rate.RateTo("USD"), 1e-12)
+
+	// Currencies without decimals
+	converted, err = models.ExchangeRate{FromCurrency: "IDR", ToCurrency: "JPY", Rate: 0.0095}.Convert(idr(12345))
+	assert.NoError(t, err)
+	assert.Equal(t, money.New(117, "JPY"), converted)
+
+	_, err = rate.Convert(money.New(100, "EUR"))
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+	_, err = money.New(100, "USD").Convert("IDR", 0)
+	assert.ErrorIs(t, err, money.ErrInvalidRate)
+}
+
+func TestExchangeRateEndpoints(t *testing.T) {
+	r, _ := newTestRouter()
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	rate := postRate(t, r, "USD", "IDR", 15500, day)
+	postRate(t, r, "USD", "IDR", 15600, day.AddDate(0, 0, 1))
+	postRate(t, r, "SGD", "IDR", 11600, day)
+
+	// One rate per pair and effective time
+	w := performRequest(t, r, "POST", "/exchange-rates", models.ExchangeRate{FromCurrency: "USD", ToCurrency: "IDR", Rate: 15700, EffectiveFrom: day})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = performRequest(t, r, "POST", "/exchange-rates", models.ExchangeRate{FromCurrency: "usd", ToCurrency: "IDR", Rate: 0, EffectiveFrom: day})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, map[string]string{
+		"from_currency": "must be a supported currency code, such as IDR",
+		"rate":          "must be greater than 0",
+	}, fieldErrors(decodeError(t, w)))
+
+	w = performRequest(t, r, "POST", "/exchange-rates", models.ExchangeRate{FromCurrency: "IDR", ToCurrency: "IDR", Rate: 1, EffectiveFrom: day})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "to_currency", decodeError(t, w).Fields[0].Field)
+
+	var list controllers.ListResponse[models.ExchangeRate]
+	decodeBody(t, performRequest(t, r, "GET", "/exchange-rates?from_currency=USD", nil), &list)
+	if assert.Len(t, list.Data, 2) {
+		assert.Equal(t, 15600.0, list.Data[0].Rate)
+	}
+
+	w = performRequest(t, r, "GET", "/exchange-rates/"+rate.ID.Hex(), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = performRequest(t, r, "DELETE", "/exchange-rates/"+rate.ID.Hex(), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = performRequest(t, r, "GET", "/exchange-rates/"+rate.ID.Hex(), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestTransactionInForeignCurrency(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+	now := time.Now()
+
+	// The latest rate already in effect applies, quoted either way round
+	postRate(t, r, "USD", "IDR", 15000, now.AddDate(0, 0, -10))
+	postRate(t, r, "IDR", "USD", 1.0/16000, now.AddDate(0, 0, -1))
+	postRate(t, r, "USD", "IDR", 20000, now.AddDate(0, 0, 1))
+
+	// Teh has its own price in dollars; Kopi is converted
+	w := performRequestWithHeader(t, r, "PATCH", "/product/"+cat.Products[1].ID.Hex(), []jsonPatch{
+		{"op": "add", "path": "/prices", "value": []money.Money{money.MustParse("0.90", "USD")}},
+	}, "Content-Type", patch.JSONPatchType)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	request := newTransactionRequest(cat)
+	request.Transaction.Currency = "USD"
+	for i := range request.Details {
+		request.Details[i].Price = money.Money{}
+		request.Details[i].Subtotal = money.Money{}
+	}
+	request.Payments = nil
+	w = performRequest(t, r, "POST", "/transaction", request)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response controllers.CreateTransactionResponse
+	decodeBody(t, w, &response)
+	transaction := response.Transaction
+	assert.Equal(t, "USD", transaction.Currency)
+	assert.Equal(t, money.MustParse("0.75", "USD"), transaction.Details[0].Price)
+	assert.Equal(t, money.MustParse("0.90", "USD"), transaction.Details[1].Price)
+	assert.Equal(t, money.MustParse("3.30", "USD"), transaction.TotalAmount)
+
+	var list controllers.ListResponse[models.Transaction]
+	decodeBody(t, performRequest(t, r, "GET", "/transactions?currency=USD", nil), &list)
+	assert.Len(t, list.Data, 1)
+	// Codes match in any case, for the currency filter and the amounts alike
+	list = controllers.ListResponse[models.Transaction]{}
+	decodeBody(t, performRequest(t, r, "GET", "/transactions?currency=usd", nil), &list)
+	assert.Len(t, list.Data, 1)
+	list = controllers.ListResponse[models.Transaction]{}
+	decodeBody(t, performRequest(t, r, "GET", "/transactions?currency=usd&min_total=3", nil), &list)
+	assert.Len(t, list.Data, 1)
+	list = controllers.ListResponse[models.Transaction]{}
+	decodeBody(t, performRequest(t, r, "GET", "/transactions?currency=usd&min_total=4", nil), &list)
+	assert.Empty(t, list.Data)
+	w = performRequest(t, r, "GET", "/transactions?currency=xyz", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, map[string]string{"currency": "currency must be a supported currency code"}, fieldErrors(decodeError(t, w)))
+
+	// The currency is fixed once the transaction is created
+	request.Transaction.Currency = "IDR"
+	w = performRequest(t, r, "PUT", "/transaction/"+transaction.ID.Hex(), request)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "transaction.currency", decodeError(t, w).Fields[0].Field)
+	request.Transaction.Currency = ""
+	w = performRequest(t, r, "PUT", "/transaction/"+transaction.ID.Hex(), request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	decodeBody(t, w, &transaction)
+	assert.Equal(t, "USD", transaction.Currency)
+
+	// Products in duplicate currencies are refused
+	w = performRequestWithHeader(t, r, "PATCH", "/product/"+cat.Products[1].ID.Hex(), []jsonPatch{
+		{"op": "add", "path": "/prices/-", "value": money.MustParse("1", "USD")},
+	}, "Content-Type", patch.JSONPatchType)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, map[string]string{"prices[1]": "repeats the currency USD"}, fieldErrors(decodeError(t, w)))
+}
+
+func TestForeignCurrencyPayment(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			r, repos := backend.router(t, testConfig())
+			cat := seedCatalogue(t, repos)
+			day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+			postRate(t, r, "USD", "IDR", 15000, day)
+			postRate(t, r, "USD", "IDR", 16000, day.AddDate(0, 0, 7))
+
+			created := performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat))
+			var response controllers.CreateTransactionResponse
+			decodeBody(t, created, &response)
+			transactionPath := "/transaction/" + response.Transaction.ID.Hex()
+
+			// Paid in dollars at the rate of the payment date
+			w := performRequest(t, r, "POST", transactionPath+"/payments", controllers.PaymentRequest{
+				PaymentMethodID: cat.PaymentMethod.ID, Status: models.PaymentPaid,
+				PaidAmount: money.MustParse("2", "USD"), PaymentDate: day.AddDate(0, 0, 3),
+			})
+			assert.Equal(t, http.StatusCreated, w.Code)
+			var payment models.TransactionPayment
+			decodeBody(t, w, &payment)
+			assert.Equal(t, money.MustParse("2", "USD"), payment.PaidAmount)
+			assert.Equal(t, idr(30000), payment.SettledAmount)
+			assert.Equal(t, 15000.0, payment.ExchangeRate)
+
+			var transaction models.Transaction
+			decodeBody(t, performRequest(t, r, "GET", transactionPath, nil), &transaction)
+			assert.Equal(t, idr(30000), transaction.PaidAmount)
+			assert.Equal(t, idr(24000), transaction.BalanceDue)
+			assert.Equal(t, models.BalancePartial, transaction.PaymentStatus)
+
+			// Correcting a pending payment settles it again
+			pending := response.Transaction.Payments[0]
+			w = performRequest(t, r, "PUT", transactionPath+"/payments/"+pending.ID.Hex(), controllers.PaymentUpdateRequest{
+				PaidAmount: money.MustParse("1.50", "USD"), PaymentDate: day.AddDate(0, 0, 8),
+			})
+			assert.Equal(t, http.StatusOK, w.Code)
+			decodeBody(t, w, &payment)
+			assert.Equal(t, idr(24000), payment.SettledAmount)
+
+			// No rate before the first one takes effect
+			w = performRequest(t, r, "POST", transactionPath+"/payments", controllers.PaymentRequest{
+				PaymentMethodID: cat.PaymentMethod.ID, Status: models.PaymentPaid,
+				PaidAmount: money.MustParse("2", "USD"), PaymentDate: day.AddDate(0, 0, -1),
+			})
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+			body := decodeError(t, w)
+			assert.Equal(t, "exchange_rate_missing", body.Code)
+			assert.Equal(t, map[string]string{"paid_amount": "has no exchange rate from USD to IDR on 2024-02-29"}, fieldErrors(body))
+		})
+	}
+}
+
+func TestSalesReportConvertsCurrencies(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+	postRate(t, r, "USD", "IDR", 15000, time.Now().AddDate(0, 0, -1))
+
+	performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat))
+	usd := models.Product{ID: primitive.NewObjectID(), Code: "P003", Name: "Kopi Luwak", Price: money.MustParse("25", "USD"), StockQuantity: 10}
+	if err := repos.Products.Create(context.Background(), &usd); err != nil {
+		t.Fatal(err)
+	}
+	w := performRequest(t, r, "POST", "/transaction", transactionRequest{
+		Transaction: models.Transaction{CustomerID: cat.Customer.ID, Currency: "USD"},
+		Details:     []models.TransactionDetail{{ProductID: usd.ID, Quantity: 2}},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	today := time.Now().Format("2006-01-02")
+	period := "date_from=" + today + "&date_to=" + today
+	var report controllers.SalesReport
+	w = performRequest(t, r, "GET", "/reports/sales?"+period, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	decodeBody(t, w, &report)
+	assert.Equal(t, "IDR", report.Currency)
+	assert.Equal(t, 2, report.Transactions)
+	assert.Equal(t, 6.0, report.TotalQty)
+	assert.Equal(t, idr(804000), report.TotalAmount)
+	assert.Equal(t, []controllers.CurrencySales{
+		{Currency: "IDR", Transactions: 1, TotalAmount: idr(54000), Converted: idr(54000)},
+		{Currency: "USD", Transactions: 1, TotalAmount: money.MustParse("50", "USD"), Converted: idr(750000)},
+	}, report.ByCurrency)
+
+	decodeBody(t, performRequest(t, r, "GET", "/reports/sales?currency=usd&"+period, nil), &report)
+	assert.Equal(t, money.MustParse("53.60", "USD"), report.TotalAmount)
+
+	w = performRequest(t, r, "GET", "/reports/sales?currency=EUR&"+period, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "exchange_rate_missing", decodeError(t, w).Code)
+
+	decodeBody(t, performRequest(t, r, "GET", "/reports/sales?date_from=2000-01-01&date_to=2000-01-31", nil), &report)
+	assert.Equal(t, 0, report.Transactions)
+	assert.Equal(t, idr(0), report.TotalAmount)
+
+	// The period is required and bounded
+	w = performRequest(t, r, "GET", "/reports/sales?date_to="+today, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, map[string]string{"date_from": "date_from is required"}, fieldErrors(decodeError(t, w)))
+	w = performRequest(t, r, "GET", "/reports/sales?date_from=2000-01-01&date_to="+today, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, fieldErrors(decodeError(t, w)), "date_to")
+}
+
+// countingRates counts the reads of an exchange-rate table.
+type countingRates struct {
+	repository.ExchangeRateRepository
+	reads atomic.Int64
+}
+
+func (r *countingRates) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.ExchangeRate], error) {
+	r.reads.Add(1)
+	return r.ExchangeRateRepository.List(ctx, query)
+}
+
+func (r *countingRates) Effective(ctx context.Context, from, to string, at time.Time) (*models.ExchangeRate, error) {
+	r.reads.Add(1)
+	return r.ExchangeRateRepository.Effective(ctx, from, to, at)
+}
+
+func TestSalesReportReadsRatesOnce(t *testing.T) {
+	repos := memory.NewRepositories()
+	rates := &countingRates{ExchangeRateRepository: repos.ExchangeRates}
+	repos.ExchangeRates = rates
+	r := mustSetupRouter(repos, testConfig())
+	cat := seedCatalogue(t, repos)
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	postRate(t, r, "USD", "IDR", 15000, day)
+	postRate(t, r, "USD", "IDR", 16000, day.AddDate(0, 0, 10))
+	for i := 0; i < 20; i++ {
+		transaction := models.Transaction{
+			ID: primitive.NewObjectID(), CustomerID: cat.Customer.ID, Currency: "USD",
+			TransactionDate: day.AddDate(0, 0, i), TotalAmount: money.MustParse("1", "USD"), TotalQty: 1,
+		}
+		if err := repos.Transactions.Create(context.Background(), &transaction); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rates.reads.Store(0)
+	var report controllers.SalesReport
+	decodeBody(t, performRequest(t, r, "GET", "/reports/sales?date_from=2024-03-01&date_to=2024-03-31", nil), &report)
+	assert.Equal(t, 20, report.Transactions)
+	// Ten days at 15000 and ten at 16000
+	assert.Equal(t, idr(310000), report.TotalAmount)
+	// Each direction of the pair is read once
+	assert.Equal(t, int64(2), rates.reads.Load())
+}
+
+// TestForeignCurrencyRoundTrip checks that foreign prices and a payment in
+// a foreign currency read back as they were written.
+func TestForeignCurrencyRoundTrip(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			r, _ := backend.router(t, testConfig())
+
+			day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+			postRate(t, r, "USD", "IDR", 15000, day)
+
+			var customer models.Customer
+			decodeBody(t, performRequest(t, r, "POST", "/customers", models.Customer{Name: "Budi", Code: "C001", Email: "budi@example.com"}), &customer)
+			var product models.Product
+			decodeBody(t, performRequest(t, r, "POST", "/product", models.Product{
+				Code: "P001", Name: "Kopi", Price: idr(12000), Prices: []money.Money{money.MustParse("0.80", "USD")}, StockQuantity: 10,
+			}), &product)
+			assert.Equal(t, []money.Money{money.MustParse("0.80", "USD")}, product.Prices)
+			var method models.PaymentMethod
+			decodeBody(t, performRequest(t, r, "POST", "/payment-method", models.PaymentMethod{Name: "Cash", IsActive: true}), &method)
+
+			var response controllers.CreateTransactionResponse
+			w := performRequest(t, r, "POST", "/transaction", transactionRequest{
+				Transaction: models.Transaction{CustomerID: customer.ID},
+				Details:     []models.TransactionDetail{{ProductID: product.ID, Quantity: 2}},
+				Payments: []models.TransactionPayment{{
+					PaymentMethodID: method.ID, Status: models.PaymentPending, PaidAmount: money.MustParse("1", "USD"), PaymentDate: day,
+				}},
+			})
+			assert.Equal(t, http.StatusCreated, w.Code)
+			decodeBody(t, w, &response)
+			transactionPath := "/transaction/" + response.Transaction.ID.Hex()
+			pending := response.Transaction.Payments[0]
+			assert.Equal(t, idr(15000), pending.SettledAmount)
+
+			w = performRequest(t, r, "PUT", transactionPath+"/payments/"+pending.ID.Hex(), controllers.PaymentUpdateRequest{
+				PaidAmount: money.MustParse("1.60", "USD"), PaymentDate: day,
+			})
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var transaction models.Transaction
+			decodeBody(t, performRequest(t, r, "GET", transactionPath, nil), &transaction)
+			if assert.Len(t, transaction.Payments, 1) {
+				assert.Equal(t, money.MustParse("1.60", "USD"), transaction.Payments[0].PaidAmount)
+				assert.Equal(t, idr(24000), transaction.Payments[0].SettledAmount)
+				assert.Equal(t, 15000.0, transaction.Payments[0].ExchangeRate)
+			}
+			assert.Equal(t, "IDR", transaction.Currency)
+			assert.Equal(t, idr(24000), transaction.BalanceDue)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/mifaabiyyu/go-test.git/controllers"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCreateCustomerRejectsDuplicateEmail(t *testing.T) {
+	r, _ := newTestRouter()
+	customer := models.Customer{Name: "Budi", Code: "C001", Email: "budi@example.com"}
+
+	w := performRequest(t, r, "POST", "/customers", customer)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = performRequest(t, r, "POST", "/customers", customer)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestGetCustomerWithAddresses(t *testing.T) {
+	r, _ := newTestRouter()
+
+	var customer models.Customer
+	w := performRequest(t, r, "POST", "/customers", models.Customer{Name: "Budi", Code: "C001", Email: "budi@example.com"})
+	decodeBody(t, w, &customer)
+
+	w = performRequest(t, r, "POST", "/customer-addresses", models.CustomerAddress{
+		CustomerID: customer.ID,
+		Street:     "Jl. Merdeka 1",
+		City:       "Jakarta",
+		PostalCode: "10110",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = performRequest(t, r, "GET", "/customers/"+customer.ID.Hex(), nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var result controllers.CustomerWithAddresses
+	decodeBody(t, w, &result)
+	assert.Equal(t, "Budi", result.Name)
+	assert.Len(t, result.Addresses, 1)
+}
+
+func TestDeleteAndRestoreCustomer(t *testing.T) {
+	r, _ := newTestRouter()
+
+	var customer models.Customer
+	w := performRequest(t, r, "POST", "/customers", models.Customer{Name: "Budi", Code: "C001", Email: "budi@example.com"})
+	decodeBody(t, w, &customer)
+	performRequest(t, r, "POST", "/customer-addresses", models.CustomerAddress{CustomerID: customer.ID, City: "Jakarta"})
+
+	w = performRequest(t, r, "DELETE", "/customers/"+customer.ID.Hex(), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = performRequest(t, r, "DELETE", "/customers/"+customer.ID.Hex(), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = performRequest(t, r, "GET", "/customers/"+customer.ID.Hex(), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = performRequest(t, r, "GET", "/customers/"+customer.ID.Hex()+"?include_deleted=true", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var deleted controllers.CustomerWithAddresses
+	decodeBody(t, w, &deleted)
+	assert.NotNil(t, deleted.DeletedAt)
+
+	var list controllers.ListResponse[models.Customer]
+	decodeBody(t, performRequest(t, r, "GET", "/customers", nil), &list)
+	assert.Empty(t, list.Data)
+	decodeBody(t, performRequest(t, r, "GET", "/customers?include_deleted=true", nil), &list)
+	assert.Len(t, list.Data, 1)
+
+	// New records cannot refer to a deleted customer
+	w = performRequest(t, r, "POST", "/customer-addresses", models.CustomerAddress{CustomerID: customer.ID, City: "Bandung"})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	w = performRequest(t, r, "POST", "/customers/"+customer.ID.Hex()+"/restore", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var restored controllers.CustomerWithAddresses
+	decodeBody(t, w, &restored)
+	assert.Nil(t, restored.DeletedAt)
+	assert.Len(t, restored.Addresses, 1)
+
+	w = performRequest(t, r, "POST", "/customers/"+customer.ID.Hex()+"/restore", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCreateCustomersConcurrently(t *testing.T) {
+	r, _ := newTestRouter()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			performRequest(t, r, "POST", "/customers", models.Customer{
+				Name:  "Customer",
+				Code:  fmt.Sprintf("C%03d", i),
+				Email: fmt.Sprintf("customer%d@example.com", i),
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	w := performRequest(t, r, "GET", "/customers", nil)
+	var customers controllers.ListResponse[controllers.CustomerWithAddresses]
+	decodeBody(t, w, &customers)
+	assert.Len(t, customers.Data, 20)
+	assert.Equal(t, int64(20), customers.Total)
+}
+
+func TestCreateCustomerAddressRejectsUnknownCustomer(t *testing.T) {
+	r, _ := newTestRouter()
+
+	w := performRequest(t, r, "POST", "/customer-addresses", models.CustomerAddress{
+		CustomerID: primitive.NewObjectID(),
+		City:       "Jakarta",
+	})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "customer_id")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/middleware"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository/memory"
+)
+
+func TestErrorResponseCarriesRequestID(t *testing.T) {
+	r, _ := newTestRouter()
+
+	req, _ := http.NewRequest("GET", "/product/not-an-id", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "req-123", w.Header().Get(middleware.RequestIDHeader))
+	body := decodeError(t, w)
+	assert.Equal(t, apperror.CodeValidation, body.Code)
+	assert.Equal(t, "req-123", body.RequestID)
+	assert.Equal(t, []apperror.FieldError{{Field: "id", Message: "Invalid product ID format"}}, body.Fields)
+
+	// Without a client ID one is generated
+	w = performRequest(t, r, "GET", "/product/not-an-id", nil)
+	assert.NotEmpty(t, w.Header().Get(middleware.RequestIDHeader))
+	assert.Equal(t, w.Header().Get(middleware.RequestIDHeader), decodeError(t, w).RequestID)
+}
+
+func TestErrorResponseListsEveryInvalidField(t *testing.T) {
+	r, _ := newTestRouter()
+
+	w := performRequest(t, r, "POST", "/product", models.Product{Description: "no code, name or price"})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	body := decodeError(t, w)
+	assert.Equal(t, apperror.CodeValidation, body.Code)
+	var fields []string
+	for _, field := range body.Fields {
+		fields = append(fields, field.Field)
+	}
+	assert.Equal(t, []string{"code", "name", "price"}, fields)
+}
+
+func TestErrorResponseCodes(t *testing.T) {
+	r, _ := newTestRouter()
+	performRequest(t, r, "POST", "/customers", models.Customer{Name: "Budi", Code: "C001", Email: "budi@example.com"})
+
+	for _, tc := range []struct {
+		method, path string
+		body         interface{}
+		status       int
+		code         string
+	}{
+		{"GET", "/customers/64b7f0c2a1b2c3d4e5f60718", nil, http.StatusNotFound, apperror.CodeNotFound},
+		{"POST", "/customers", models.Customer{Name: "Budi", Code: "C002", Email: "budi@example.com"}, http.StatusConflict, "duplicate"},
+		{"GET", "/products?page_size=0", nil, http.StatusBadRequest, apperror.CodeValidation},
+		{"POST", "/product", "not an object", http.StatusBadRequest, apperror.CodeValidation},
+		{"GET", "/no-such-route", nil, http.StatusNotFound, apperror.CodeNotFound},
+	} {
+		w := performRequest(t, r, tc.method, tc.path, tc.body)
+		assert.Equal(t, tc.status, w.Code, tc.path)
+		assert.Equal(t, tc.code, decodeError(t, w).Code, tc.path)
+	}
+}
+
+func TestInternalErrorsHideTheirCause(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repos := memory.NewRepositories()
+	repos.TransactionPayments = failingPayments{repos.TransactionPayments}
+	r := mustSetupRouter(repos, testConfig())
+	cat := seedCatalogue(t, repos)
+
+	w := performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	body := decodeError(t, w)
+	assert.Equal(t, apperror.CodeInternal, body.Code)
+	assert.Equal(t, "internal server error", body.Message)
+	assert.NotContains(t, w.Body.String(), "payment store unavailable")
+}
+
+func TestPanicsAreReportedAsInternalErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.Errors(), middleware.Recovery())
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	w := performRequest(t, r, "GET", "/panic", nil)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, apperror.CodeInternal, decodeError(t, w).Code)
+	assert.NotContains(t, w.Body.String(), "boom")
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mifaabiyyu/go-test.git/controllers"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/money"
+)
+
+// seedProducts stores products whose prices repeat, so keyset pagination
+// has to fall back to the ID to order ties.
+func seedProducts(t *testing.T, r *gin.Engine) {
+	t.Helper()
+
+	for _, product := range []models.Product{
+		{Code: "P001", Name: "Kopi Susu", Price: idr(18000)},
+		{Code: "P002", Name: "Teh Manis", Price: idr(8000)},
+		{Code: "P003", Name: "Kopi Hitam", Price: idr(15000)},
+		{Code: "P004", Name: "Air Mineral", Price: idr(5000)},
+		{Code: "P005", Name: "Es Kopi", Price: idr(15000)},
+	} {
+		w := performRequest(t, r, "POST", "/product", product)
+		assert.Equal(t, http.StatusCreated, w.Code)
+	}
+}
+
+// walkProducts follows next_cursor from the first page to the last.
+func walkProducts(t *testing.T, r *gin.Engine, query url.Values) []string {
+	t.Helper()
+
+	var codes []string
+	for {
+		w := performRequest(t, r, "GET", "/products?"+query.Encode(), nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		var page controllers.ListResponse[models.Product]
+		decodeBody(t, w, &page)
+		assert.Equal(t, int64(5), page.Total)
+		for _, product := range page.Data {
+			codes = append(codes, product.Code)
+		}
+		if page.NextCursor == "" {
+			return codes
+		}
+		query = url.Values{"cursor": {page.NextCursor}, "page_size": query["page_size"]}
+	}
+}
+
+func TestListProductsPagination(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			r, _ := backend.router(t, testConfig())
+
+			seedProducts(t, r)
+
+			codes := walkProducts(t, r, url.Values{"page_size": {"2"}, "order_by": {"price"}, "order_direction": {"desc"}})
+			assert.Equal(t, []string{"P001", "P005", "P003", "P002", "P004"}, codes)
+
+			w := performRequest(t, r, "GET", "/products?page=2&page_size=2&order_by=price", nil)
+			var page controllers.ListResponse[models.Product]
+			decodeBody(t, w, &page)
+			assert.Equal(t, int64(2), page.Page)
+			assert.Equal(t, "P003", page.Data[0].Code)
+			assert.Equal(t, "P005", page.Data[1].Code)
+
+			w = performRequest(t, r, "GET", "/products?name=kopi&min_price=15000&max_price=16000", nil)
+			page = controllers.ListResponse[models.Product]{}
+			decodeBody(t, w, &page)
+			assert.Equal(t, int64(2), page.Total)
+			assert.Empty(t, page.NextCursor)
+		})
+	}
+}
+
+func TestListDeletedProducts(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			r, _ := backend.router(t, testConfig())
+
+			seedProducts(t, r)
+
+			var page controllers.ListResponse[models.Product]
+			decodeBody(t, performRequest(t, r, "GET", "/products?code=P003", nil), &page)
+			w := performRequest(t, r, "DELETE", "/product/"+page.Data[0].ID.Hex(), nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			page = controllers.ListResponse[models.Product]{}
+			decodeBody(t, performRequest(t, r, "GET", "/products?name=kopi", nil), &page)
+			assert.Equal(t, int64(2), page.Total)
+
+			page = controllers.ListResponse[models.Product]{}
+			decodeBody(t, performRequest(t, r, "GET", "/products?name=kopi&include_deleted=true", nil), &page)
+			assert.Equal(t, int64(3), page.Total)
+		})
+	}
+}
+
+func TestListMixedCurrencies(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			r, _ := backend.router(t, testConfig())
+
+			seedProducts(t, r)
+			for _, product := range []models.Product{
+				{Code: "P006", Name: "Kopi Impor", Price: money.MustParse("100", "USD")},
+				{Code: "P007", Name: "Teh Impor", Price: money.MustParse("5", "USD")},
+			} {
+				assert.Equal(t, http.StatusCreated, performRequest(t, r, "POST", "/product", product).Code)
+			}
+
+			// Amounts only match amounts in their own currency, rupiah by default
+			var page controllers.ListResponse[models.Product]
+			decodeBody(t, performRequest(t, r, "GET", "/products?max_price=100", nil), &page)
+			assert.Zero(t, page.Total)
+			page = controllers.ListResponse[models.Product]{}
+			decodeBody(t, performRequest(t, r, "GET", "/products?currency=usd&min_price=50", nil), &page)
+			if assert.Equal(t, int64(1), page.Total) {
+				assert.Equal(t, "P006", page.Data[0].Code)
+			}
+
+			// Sorting by an amount groups currencies, across pages too
+			var codes []string
+			query := url.Values{"page_size": {"3"}, "order_by": {"price"}}
+			for {
+				page = controllers.ListResponse[models.Product]{}
+				decodeBody(t, performRequest(t, r, "GET", "/products?"+query.Encode(), nil), &page)
+				for _, product := range page.Data {
+					codes = append(codes, product.Code)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				query = url.Values{"cursor": {page.NextCursor}, "page_size": {"3"}}
+			}
+			assert.Equal(t, []string{"P004", "P002", "P003", "P005", "P001", "P007", "P006"}, codes)
+
+			w := performRequest(t, r, "GET", "/products?currency=XYZ&min_price=1", nil)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, map[string]string{"currency": "currency must be a supported currency code"}, fieldErrors(decodeError(t, w)))
+		})
+	}
+}
+
+func TestListTransactionsFilters(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat))
+	other := seedCatalogue(t, repos)
+	performRequest(t, r, "POST", "/transaction", newTransactionRequest(other))
+
+	today := time.Now().Format("2006-01-02")
+	w := performRequest(t, r, "GET", "/transactions?customer_id="+cat.Customer.ID.Hex()+"&date_from="+today+"&date_to="+today, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var page controllers.ListResponse[models.Transaction]
+	decodeBody(t, w, &page)
+	assert.Equal(t, int64(1), page.Total)
+	assert.Equal(t, cat.Customer.ID, page.Data[0].CustomerID)
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	w = performRequest(t, r, "GET", "/transactions?date_to="+yesterday, nil)
+	page = controllers.ListResponse[models.Transaction]{}
+	decodeBody(t, w, &page)
+	assert.Zero(t, page.Total)
+}
+
+func TestListPaymentMethodsByActive(t *testing.T) {
+	r, _ := newTestRouter()
+	performRequest(t, r, "POST", "/payment-method", models.PaymentMethod{Name: "Cash", IsActive: true})
+	performRequest(t, r, "POST", "/payment-method", models.PaymentMethod{Name: "Voucher", IsActive: false})
+
+	w := performRequest(t, r, "GET", "/payment-methods?is_active=false", nil)
+	var page controllers.ListResponse[models.PaymentMethod]
+	decodeBody(t, w, &page)
+	assert.Len(t, page.Data, 1)
+	assert.Equal(t, "Voucher", page.Data[0].Name)
+}
+
+func TestListRejectsInvalidParameters(t *testing.T) {
+	r, _ := newTestRouter()
+	seedProducts(t, r)
+
+	var first controllers.ListResponse[models.Product]
+	decodeBody(t, performRequest(t, r, "GET", "/products?page_size=1", nil), &first)
+
+	for _, query := range []string{
+		"order_by=description",
+		"order_direction=up",
+		"page_size=0",
+		"page_size=1000",
+		"page=0",
+		"page=9223372036854775807",
+		"page=461168601842738792&page_size=20",
+		"min_price=cheap",
+		"cursor=not-a-cursor",
+		"cursor=" + first.NextCursor + "&page=2",
+		"cursor=" + first.NextCursor + "&order_by=price",
+		"include_deleted=maybe",
+		"order_by=deleted_at",
+	} {
+		w := performRequest(t, r, "GET", "/products?"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+
+	// The last page whose offset fits is empty rather than an error
+	var last controllers.ListResponse[models.Product]
+	w := performRequest(t, r, "GET", "/products?page=461168601842738791&page_size=20", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	decodeBody(t, w, &last)
+	assert.Empty(t, last.Data)
+}
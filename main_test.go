@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/money"
+	"github.com/mifaabiyyu/go-test.git/repository"
+	"github.com/mifaabiyyu/go-test.git/repository/memory"
+)
+
+// newTestRouter returns the full application router backed by a fresh
+// in-memory store, so tests never need a database.
+func newTestRouter() (*gin.Engine, *repository.Repositories) {
+	gin.SetMode(gin.TestMode) // Set Gin to test mode
+
+	repos := memory.NewRepositories()
+	return mustSetupRouter(repos, testConfig()), repos
+}
+
+// testConfig returns the default configuration with authentication
+// disabled, for the tests of everything but authentication.
+func testConfig() *config.Config {
+	cfg := config.Default()
+	cfg.Auth.Enabled = false
+	return cfg
+}
+
+// idr returns a whole amount of rupiah.
+func idr(amount int64) money.Money {
+	return money.New(amount*100, "IDR")
+}
+
+// mustSetupRouter is setupRouter for configurations that cannot fail.
+func mustSetupRouter(repos *repository.Repositories, cfg *config.Config) *gin.Engine {
+	router, err := setupRouter(repos, cfg)
+	if err != nil {
+		panic(err)
+	}
+	return router
+}
+
+// performRequest sends body (marshalled to JSON when not nil) to the router
+// and returns the recorded response.
+func performRequest(t *testing.T, r http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatalf("Failed to marshal JSON data: %v", err)
+		}
+	}
+
+	req, _ := http.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// decodeBody unmarshals a JSON response body into v.
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("Failed to decode response %q: %v", w.Body.String(), err)
+	}
+}
+
+// decodeError unmarshals an error response body.
+func decodeError(t *testing.T, w *httptest.ResponseRecorder) apperror.Body {
+	t.Helper()
+
+	var response apperror.Response
+	decodeBody(t, w, &response)
+	return response.Error
+}
+
+// decodeErrorDetail unmarshals one detail of an error response into v.
+func decodeErrorDetail(t *testing.T, w *httptest.ResponseRecorder, key string, v interface{}) {
+	t.Helper()
+
+	var response struct {
+		Error struct {
+			Details map[string]json.RawMessage `json:"details"`
+		} `json:"error"`
+	}
+	decodeBody(t, w, &response)
+	if err := json.Unmarshal(response.Error.Details[key], v); err != nil {
+		t.Fatalf("Failed to decode error detail %q of %q: %v", key, w.Body.String(), err)
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/auth"
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+const principalKey = "principal"
+
+// Authenticate rejects requests without valid credentials with 401 and
+// records who made the others. Install it after Errors.
+func Authenticate(authenticator *auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := authenticator.Authenticate(c.Request.Context(), c.Request.Header)
+		if err != nil {
+			if errors.Is(err, auth.ErrNoCredentials) {
+				c.Header("WWW-Authenticate", `Bearer realm="api"`)
+				err = apperror.Unauthorized("authentication required")
+			} else if errors.Is(err, auth.ErrInvalidCredentials) {
+				c.Header("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+				err = apperror.Unauthorized(strings.TrimPrefix(err.Error(), "auth: "))
+			}
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// Authorize lets a request through only when its principal has one of
+// roles, and rejects it with 403 otherwise. Install it after Authenticate.
+func Authorize(roles ...models.Role) gin.HandlerFunc {
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = string(role)
+	}
+	message := "this action requires one of the roles: " + strings.Join(names, ", ")
+
+	return func(c *gin.Context) {
+		principal := GetPrincipal(c)
+		if principal == nil || !principal.HasAnyRole(roles...) {
+			c.Error(apperror.Forbidden(message).WithDetail("required_roles", names))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetPrincipal returns who Authenticate found made the request, or nil
+// when authentication is disabled.
+func GetPrincipal(c *gin.Context) *auth.Principal {
+	principal, _ := c.Get(principalKey)
+	p, _ := principal.(*auth.Principal)
+	return p
+}
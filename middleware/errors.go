@@ -0,0 +1,68 @@
+// middleware/errors.go
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// Errors renders the last error a handler attached with c.Error as an
+// apperror.Response. Errors that are not an *apperror.Error or a Reporter
+// are mapped from the repository sentinel errors, or reported as internal
+// errors without their message. Install it after RequestID.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			return
+		}
+		err := c.Errors.Last().Err
+		appErr := Translate(err)
+		if appErr.Kind == apperror.KindInternal || appErr.Kind == apperror.KindTimeout {
+			log.Printf("request %s: %s %s: %v", GetRequestID(c), c.Request.Method, c.Request.URL.Path, err)
+		}
+		if c.Writer.Written() {
+			return
+		}
+		c.AbortWithStatusJSON(appErr.Kind.Status(), apperror.NewResponse(appErr, GetRequestID(c)))
+	}
+}
+
+// Translate maps any error onto the *apperror.Error it is reported as.
+func Translate(err error) *apperror.Error {
+	if appErr, ok := apperror.As(err); ok {
+		return appErr
+	}
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return apperror.NotFound("resource not found")
+	case errors.Is(err, repository.ErrDuplicate):
+		return apperror.Conflict("duplicate", "a record with the same unique value already exists")
+	case errors.Is(err, repository.ErrForeignKey):
+		return apperror.Conflict("foreign_key", "the change would break a reference between records")
+	case errors.Is(err, repository.ErrInvalidCursor):
+		return apperror.Validation("cursor is invalid").WithField("cursor", "cursor is invalid")
+	case errors.Is(err, repository.ErrInsufficientStock):
+		return apperror.Unprocessable("insufficient_stock", "insufficient stock")
+	case errors.Is(err, context.DeadlineExceeded):
+		return apperror.Timeout(err)
+	}
+	return apperror.Internal(err)
+}
+
+// Recovery turns a panic into an internal error reported by Errors.
+// Install it after Errors.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+		c.Error(apperror.Internal(fmt.Errorf("panic: %v", recovered)))
+		c.Abort()
+	})
+}
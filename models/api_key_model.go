@@ -0,0 +1,31 @@
+// models/api_key_model.go
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKey is a credential issued to an integration. Only a hash of the key
+// is stored; the key itself is shown once, when it is issued.
+type APIKey struct {
+	ID   primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name string             `bson:"name" json:"name"`
+	// Prefix is the start of the key, so that it can be recognised in
+	// lists without being stored.
+	Prefix string `bson:"prefix" json:"prefix"`
+	Hash   string `bson:"hash" json:"-"`
+	Roles  []Role `bson:"roles" json:"roles"`
+
+	CreatedBy string     `bson:"created_by" json:"created_by"`
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+	ExpiresAt *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	RevokedAt *time.Time `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+// ActiveAt reports whether the key is accepted at time t: it is neither
+// revoked nor expired.
+func (k APIKey) ActiveAt(t time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || t.Before(*k.ExpiresAt))
+}
@@ -0,0 +1,74 @@
+// models/audit_entry_model.go
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuditAction is what an audited request did to an entity.
+type AuditAction string
+
+const (
+	AuditCreate  AuditAction = "create"
+	AuditUpdate  AuditAction = "update"
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+)
+
+// Entity types recorded in the audit log.
+const (
+	EntityCustomer           = "customer"
+	EntityCustomerAddress    = "customer_address"
+	EntityProduct            = "product"
+	EntityPaymentMethod      = "payment_method"
+	EntityTransaction        = "transaction"
+	EntityTransactionPayment = "transaction_payment"
+	EntityAPIKey             = "api_key"
+	EntityExchangeRate       = "exchange_rate"
+	EntityPromotion          = "promotion"
+)
+
+// AuditEntry records who changed an entity, how and when. Entries are only
+// ever appended.
+type AuditEntry struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Actor      string             `bson:"actor" json:"actor"`
+	Action     AuditAction        `bson:"action" json:"action"`
+	EntityType string             `bson:"entity_type" json:"entity_type"`
+	EntityID   primitive.ObjectID `bson:"entity_id" json:"entity_id"`
+	// Changes lists the fields that differ between the entity before and
+	// after the change, in field order.
+	Changes   []AuditChange `bson:"changes" json:"changes"`
+	RequestID string        `bson:"request_id" json:"request_id"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+}
+
+// AuditChange is one changed field. Before is empty for a created entity
+// and After for a removed one.
+type AuditChange struct {
+	Field  string     `bson:"field" json:"field"`
+	Before AuditValue `bson:"before,omitempty" json:"before"`
+	After  AuditValue `bson:"after,omitempty" json:"after"`
+}
+
+// AuditValue is a field value encoded as JSON. It is stored as a string,
+// so every backend keeps it exactly, and rendered as the JSON it holds.
+type AuditValue string
+
+func (v AuditValue) MarshalJSON() ([]byte, error) {
+	if v == "" {
+		return []byte("null"), nil
+	}
+	return []byte(v), nil
+}
+
+func (v *AuditValue) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*v = ""
+		return nil
+	}
+	*v = AuditValue(data)
+	return nil
+}
@@ -6,8 +6,8 @@ import (
 
 type CustomerAddress struct {
 	ID         primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	CustomerID primitive.ObjectID `json:"customer_id" bson:"customer_id"`
-	Street     string             `json:"street"`
-	City       string             `json:"city"`
-	PostalCode string             `json:"postal_code"`
+	CustomerID primitive.ObjectID `json:"customer_id" bson:"customer_id" binding:"objectid"`
+	Street     string             `json:"street" binding:"max=200"`
+	City       string             `json:"city" binding:"max=100"`
+	PostalCode string             `json:"postal_code" binding:"omitempty,postal_code"`
 }
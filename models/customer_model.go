@@ -1,13 +1,18 @@
 package models
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Customer struct {
 	ID    primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	Name  string             `json:"name" binding:"required"`
-	Code  string             `json:"code" binding:"required"`
-	Email string             `json:"email" bson:"email,omitempty" binding:"required" unique:"true"`
+	Name  string             `json:"name" binding:"required,max=100"`
+	Code  string             `json:"code" binding:"required,max=20"`
+	Email string             `json:"email" bson:"email,omitempty" binding:"required,email,max=254" unique:"true"`
+
+	// DeletedAt is set when the customer is soft-deleted.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
 }
 
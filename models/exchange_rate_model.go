@@ -0,0 +1,46 @@
+// models/exchange_rate_model.go
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/money"
+)
+
+// ExchangeRate is the rate between two currencies from EffectiveFrom until
+// a later rate of the same pair takes over. Rates are never edited: a new
+// rate supersedes an old one, and a wrong one is deleted.
+type ExchangeRate struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	FromCurrency string             `bson:"from_currency" binding:"currency" json:"from_currency"`
+	ToCurrency   string             `bson:"to_currency" binding:"currency" json:"to_currency"`
+	// Rate is the units of ToCurrency that one unit of FromCurrency buys.
+	Rate          float64   `bson:"rate" binding:"gt=0" json:"rate"`
+	EffectiveFrom time.Time `bson:"effective_from" binding:"required" json:"effective_from"`
+
+	CreatedBy string    `bson:"created_by" json:"created_by"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// Convert converts an amount in either currency of the rate into the
+// other one.
+func (r ExchangeRate) Convert(amount money.Money) (money.Money, error) {
+	switch amount.Currency() {
+	case r.FromCurrency:
+		return amount.Convert(r.ToCurrency, r.Rate)
+	case r.ToCurrency:
+		return amount.ConvertInverse(r.FromCurrency, r.Rate)
+	}
+	return money.Money{}, money.ErrCurrencyMismatch
+}
+
+// RateTo returns the units of currency that one unit of the rate's other
+// currency buys.
+func (r ExchangeRate) RateTo(currency string) float64 {
+	if currency == r.FromCurrency {
+		return 1 / r.Rate
+	}
+	return r.Rate
+}
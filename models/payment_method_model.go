@@ -1,10 +1,17 @@
 // models/payment_method.go
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type PaymentMethod struct {
 	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name     string             `bson:"name" json:"name"`
+	Name     string             `bson:"name" json:"name" binding:"required,max=50"`
 	IsActive bool               `bson:"is_active" json:"is_active"`
+
+	// DeletedAt is set when the payment method is soft-deleted.
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
 }
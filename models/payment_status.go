@@ -0,0 +1,126 @@
+// models/payment_status.go
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PaymentStatus is the lifecycle state of a TransactionPayment. It is
+// stored as an integer and rendered as its name in JSON.
+type PaymentStatus int64
+
+const (
+	PaymentPending PaymentStatus = iota
+	PaymentAuthorized
+	PaymentPaid
+	PaymentFailed
+	PaymentRefunded
+	PaymentCancelled
+)
+
+var paymentStatusNames = map[PaymentStatus]string{
+	PaymentPending:    "pending",
+	PaymentAuthorized: "authorized",
+	PaymentPaid:       "paid",
+	PaymentFailed:     "failed",
+	PaymentRefunded:   "refunded",
+	PaymentCancelled:  "cancelled",
+}
+
+// paymentTransitions lists, for every state, the states a payment may move
+// to next. Failed, refunded and cancelled payments are final.
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentPending:    {PaymentAuthorized, PaymentPaid, PaymentFailed, PaymentCancelled},
+	PaymentAuthorized: {PaymentPaid, PaymentFailed, PaymentCancelled},
+	PaymentPaid:       {PaymentRefunded},
+}
+
+func (s PaymentStatus) String() string {
+	if name, ok := paymentStatusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("PaymentStatus(%d)", int64(s))
+}
+
+// IsValid reports whether s is one of the named states.
+func (s PaymentStatus) IsValid() bool {
+	_, ok := paymentStatusNames[s]
+	return ok
+}
+
+// IsInitial reports whether a payment may be recorded in state s, as
+// opposed to only reaching it through a transition.
+func (s PaymentStatus) IsInitial() bool {
+	return s == PaymentPending || s == PaymentAuthorized || s == PaymentPaid
+}
+
+// CanTransitionTo reports whether a payment in state s may move to next.
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	for _, allowed := range paymentTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition returns next if the move from s is allowed, and a
+// *TransitionError otherwise.
+func (s PaymentStatus) Transition(next PaymentStatus) (PaymentStatus, error) {
+	if !s.CanTransitionTo(next) {
+		return s, &TransitionError{From: s, To: next}
+	}
+	return next, nil
+}
+
+func (s PaymentStatus) MarshalJSON() ([]byte, error) {
+	if !s.IsValid() {
+		return nil, fmt.Errorf("invalid payment status %d", int64(s))
+	}
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON accepts the state name or, for older clients, its number.
+func (s *PaymentStatus) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		parsed, err := ParsePaymentStatus(name)
+		if err != nil {
+			return err
+		}
+		*s = parsed
+		return nil
+	}
+
+	var number int64
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("payment status must be a name or number, got %s", data)
+	}
+	if !PaymentStatus(number).IsValid() {
+		return fmt.Errorf("unknown payment status %d", number)
+	}
+	*s = PaymentStatus(number)
+	return nil
+}
+
+// ParsePaymentStatus returns the state with the given name.
+func ParsePaymentStatus(name string) (PaymentStatus, error) {
+	for status, statusName := range paymentStatusNames {
+		if statusName == name {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown payment status %q", name)
+}
+
+// TransitionError reports a payment state change that the lifecycle does
+// not allow.
+type TransitionError struct {
+	From PaymentStatus `json:"from"`
+	To   PaymentStatus `json:"to"`
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("payment cannot move from %s to %s", e.From, e.To)
+}
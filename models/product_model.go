@@ -1,11 +1,79 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/money"
+)
 
 type Product struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Code        string             `bson:"code" binding:"required" json:"code"`
-	Name        string             `bson:"name" binding:"required" json:"name"`
-	Price       float64            `bson:"price" binding:"required" json:"price"`
-	Description string             `bson:"description" json:"description"`
+	Code        string             `bson:"code" binding:"required,max=20" json:"code"`
+	Name        string             `bson:"name" binding:"required,max=100" json:"name"`
+	Category    string             `bson:"category" binding:"max=50" json:"category"`
+	Price       money.Money        `bson:"price" binding:"positive_money" json:"price"`
+	Prices      []money.Money      `bson:"prices,omitempty" binding:"dive,positive_money" json:"prices,omitempty"`
+	Description string             `bson:"description" binding:"max=1000" json:"description"`
+
+	// StockQuantity is the quantity on hand. Part of it may be held back as
+	// ReservedQuantity; only the rest can be sold.
+	StockQuantity    float64 `bson:"stock_quantity" binding:"gte=0" json:"stock_quantity"`
+	ReservedQuantity float64 `bson:"reserved_quantity" binding:"gte=0" json:"reserved_quantity"`
+	// ReorderLevel is the available quantity at or below which the product
+	// should be restocked.
+	ReorderLevel float64 `bson:"reorder_level" binding:"gte=0" json:"reorder_level"`
+
+	// DeletedAt is set when the product is soft-deleted.
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// PriceIn returns the product's price in currency, if it has one: Price,
+// or one of Prices, its prices in other currencies.
+func (p *Product) PriceIn(currency string) (money.Money, bool) {
+	if p.Price.Currency() == currency {
+		return p.Price, true
+	}
+	for _, price := range p.Prices {
+		if price.Currency() == currency {
+			return price, true
+		}
+	}
+	return money.Money{}, false
+}
+
+// DuplicatePrice returns the index in Prices of the first price in a
+// currency the product already has a price in, or -1.
+func (p *Product) DuplicatePrice() int {
+	seen := map[string]bool{p.Price.Currency(): true}
+	for i, price := range p.Prices {
+		if seen[price.Currency()] {
+			return i
+		}
+		seen[price.Currency()] = true
+	}
+	return -1
+}
+
+// AvailableQuantity is the quantity that can still be sold.
+func (p *Product) AvailableQuantity() float64 {
+	return p.StockQuantity - p.ReservedQuantity
+}
+
+// NeedsReorder reports whether the available quantity has fallen to the
+// reorder level.
+func (p *Product) NeedsReorder() bool {
+	return p.AvailableQuantity() <= p.ReorderLevel
+}
+
+// MarshalJSON adds the derived available_quantity and needs_reorder fields.
+func (p Product) MarshalJSON() ([]byte, error) {
+	type product Product
+	return json.Marshal(struct {
+		product
+		AvailableQuantity float64 `json:"available_quantity"`
+		NeedsReorder      bool    `json:"needs_reorder"`
+	}{product(p), p.AvailableQuantity(), p.NeedsReorder()})
 }
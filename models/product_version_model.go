@@ -0,0 +1,73 @@
+// models/product_version_model.go
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/money"
+)
+
+// ProductVersion records what a product looked like after it was created
+// or changed, and who changed it. Versions of a product are numbered from 1
+// and are never updated or deleted.
+type ProductVersion struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID primitive.ObjectID `bson:"product_id" json:"product_id"`
+	Version   int                `bson:"version" json:"version"`
+
+	Code             string        `bson:"code" json:"code"`
+	Name             string        `bson:"name" json:"name"`
+	Category         string        `bson:"category" json:"category"`
+	Price            money.Money   `bson:"price" json:"price"`
+	Prices           []money.Money `bson:"prices,omitempty" json:"prices,omitempty"`
+	Description      string        `bson:"description" json:"description"`
+	ReservedQuantity float64       `bson:"reserved_quantity" json:"reserved_quantity"`
+	ReorderLevel     float64       `bson:"reorder_level" json:"reorder_level"`
+
+	User      string    `bson:"user" json:"user"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// NewProductVersion captures the versioned fields of product. Stock is left
+// out: its history is the stock ledger.
+func NewProductVersion(product *Product) ProductVersion {
+	return ProductVersion{
+		ProductID:        product.ID,
+		Code:             product.Code,
+		Name:             product.Name,
+		Category:         product.Category,
+		Price:            product.Price,
+		Prices:           product.Prices,
+		Description:      product.Description,
+		ReservedQuantity: product.ReservedQuantity,
+		ReorderLevel:     product.ReorderLevel,
+	}
+}
+
+// SameAs reports whether v and other capture the same product state,
+// ignoring their numbering and authorship.
+func (v ProductVersion) SameAs(other ProductVersion) bool {
+	return v.ProductID == other.ProductID &&
+		v.Code == other.Code &&
+		v.Name == other.Name &&
+		v.Category == other.Category &&
+		v.Price.Equal(other.Price) &&
+		samePrices(v.Prices, other.Prices) &&
+		v.Description == other.Description &&
+		v.ReservedQuantity == other.ReservedQuantity &&
+		v.ReorderLevel == other.ReorderLevel
+}
+
+func samePrices(a, b []money.Money) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+// models/promotion_model.go
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/money"
+)
+
+// PromotionType is how a promotion works out its discount.
+type PromotionType string
+
+const (
+	// PromotionPercentage takes Percent off the amount it applies to.
+	PromotionPercentage PromotionType = "percentage"
+	// PromotionFixed takes Amount off every unit of a product, or once off
+	// the cart.
+	PromotionFixed PromotionType = "fixed"
+	// PromotionBuyXGetY gives GetQuantity units of a product free for
+	// every BuyQuantity bought.
+	PromotionBuyXGetY PromotionType = "buy_x_get_y"
+)
+
+// PromotionScope is what a promotion applies to.
+type PromotionScope string
+
+const (
+	// ScopeProduct applies to the details of the products in ProductIDs.
+	ScopeProduct PromotionScope = "product"
+	// ScopeCart applies to the whole transaction, after the product
+	// promotions.
+	ScopeCart PromotionScope = "cart"
+)
+
+// Promotion is a discount rule. One without a Code applies by itself to
+// every transaction it matches; one with a Code only to transactions that
+// name it as their coupon code.
+type Promotion struct {
+	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Name        string               `bson:"name" binding:"required,max=100" json:"name"`
+	Code        string               `bson:"code,omitempty" binding:"max=50" json:"code,omitempty"`
+	Type        PromotionType        `bson:"type" binding:"oneof=percentage fixed buy_x_get_y" json:"type"`
+	Scope       PromotionScope       `bson:"scope" binding:"oneof=product cart" json:"scope"`
+	ProductIDs  []primitive.ObjectID `bson:"product_ids,omitempty" binding:"dive,objectid" json:"product_ids,omitempty"`
+	Percent     float64              `bson:"percent,omitempty" binding:"gte=0,lte=100" json:"percent,omitempty"`
+	Amount      money.Money          `bson:"amount" binding:"money" json:"amount"`
+	BuyQuantity float64              `bson:"buy_quantity,omitempty" binding:"gte=0" json:"buy_quantity,omitempty"`
+	GetQuantity float64              `bson:"get_quantity,omitempty" binding:"gte=0" json:"get_quantity,omitempty"`
+
+	// StartsAt and EndsAt bound when the promotion applies; either may be
+	// left open. EndsAt itself is outside the window.
+	StartsAt *time.Time `bson:"starts_at,omitempty" json:"starts_at,omitempty"`
+	EndsAt   *time.Time `bson:"ends_at,omitempty" json:"ends_at,omitempty"`
+	// UsageLimit caps the transactions the promotion applies to; zero is
+	// no limit. UsageCount is how many it applies to now, kept by the
+	// transactions themselves and never taken from the request.
+	UsageLimit int  `bson:"usage_limit" binding:"gte=0" json:"usage_limit"`
+	UsageCount int  `bson:"usage_count" json:"usage_count"`
+	IsActive   bool `bson:"is_active" json:"is_active"`
+
+	// DeletedAt is set when the promotion is soft-deleted.
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+}
+
+// ActiveAt reports whether the promotion applies at the given time, usage
+// aside.
+func (p *Promotion) ActiveAt(at time.Time) bool {
+	return p.IsActive && p.DeletedAt == nil &&
+		(p.StartsAt == nil || !at.Before(*p.StartsAt)) &&
+		(p.EndsAt == nil || at.Before(*p.EndsAt))
+}
+
+// Exhausted reports whether the promotion has reached its usage limit.
+func (p *Promotion) Exhausted() bool {
+	return p.UsageLimit > 0 && p.UsageCount >= p.UsageLimit
+}
+
+// AppliedDiscount is what one promotion took off a transaction or one of
+// its details. Name and Code are snapshots, like a detail's product name.
+type AppliedDiscount struct {
+	PromotionID primitive.ObjectID `bson:"promotion_id" json:"promotion_id"`
+	Name        string             `bson:"name" json:"name"`
+	Code        string             `bson:"code,omitempty" json:"code,omitempty"`
+	Amount      money.Money        `bson:"amount" json:"amount"`
+}
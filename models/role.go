@@ -0,0 +1,28 @@
+// models/role.go
+package models
+
+// Role decides which routes a caller may use. API keys and JWTs carry one
+// or more roles; a role that is not one of the constants below grants
+// nothing.
+type Role string
+
+const (
+	// RoleAdmin may do everything, including managing API keys.
+	RoleAdmin Role = "admin"
+	// RoleAccountant manages payments, payment methods and corrections to
+	// transactions.
+	RoleAccountant Role = "accountant"
+	// RoleCashier records sales and takes payments.
+	RoleCashier Role = "cashier"
+	// RoleViewer may only read.
+	RoleViewer Role = "viewer"
+)
+
+// IsValid reports whether r is one of the named roles.
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleAdmin, RoleAccountant, RoleCashier, RoleViewer:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+// models/stock_movement_model.go
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StockMovementType says why a product's stock changed.
+type StockMovementType string
+
+const (
+	// MovementReceipt is stock received from a supplier.
+	MovementReceipt StockMovementType = "receipt"
+	// MovementSale is stock sold by a transaction detail.
+	MovementSale StockMovementType = "sale"
+	// MovementAdjustment corrects stock by hand, e.g. after a count.
+	MovementAdjustment StockMovementType = "adjustment"
+	// MovementReturn puts back the stock of a sale that was undone.
+	MovementReturn StockMovementType = "return"
+)
+
+// IsValid reports whether t is one of the known movement types.
+func (t StockMovementType) IsValid() bool {
+	switch t {
+	case MovementReceipt, MovementSale, MovementAdjustment, MovementReturn:
+		return true
+	}
+	return false
+}
+
+// StockMovement is one entry of the append-only stock ledger. Summing the
+// quantities of a product's movements gives its stock quantity.
+type StockMovement struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID primitive.ObjectID `bson:"product_id" json:"product_id"`
+	Type      StockMovementType  `bson:"type" json:"type"`
+	// Quantity is positive for stock coming in and negative for stock
+	// going out.
+	Quantity float64 `bson:"quantity" json:"quantity"`
+
+	// Set for sales and returns, linking the movement to the detail it
+	// moved stock for. The detail may since have been deleted.
+	TransactionID       primitive.ObjectID `bson:"transaction_id,omitempty" json:"transaction_id,omitempty"`
+	TransactionDetailID primitive.ObjectID `bson:"transaction_detail_id,omitempty" json:"transaction_detail_id,omitempty"`
+
+	Reason    string    `bson:"reason" json:"reason"`
+	User      string    `bson:"user" json:"user"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
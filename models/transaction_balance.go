@@ -0,0 +1,45 @@
+// models/transaction_balance.go
+package models
+
+import "github.com/mifaabiyyu/go-test.git/money"
+
+// BalanceStatus summarises how far a transaction has been paid.
+type BalanceStatus string
+
+const (
+	BalanceUnpaid   BalanceStatus = "unpaid"
+	BalancePartial  BalanceStatus = "partial"
+	BalancePaid     BalanceStatus = "paid"
+	BalanceOverpaid BalanceStatus = "overpaid"
+)
+
+// SummarizePayments sets PaidAmount, BalanceDue and PaymentStatus from
+// TotalAmount and the settled amounts of the payments in Payments. Only
+// payments in the paid state count; pending, failed, refunded and
+// cancelled ones do not. Settled amounts are in the currency of
+// TotalAmount; any that are not are left out.
+func (t *Transaction) SummarizePayments() {
+	paid := money.Zero(t.TotalAmount.Currency())
+	for _, payment := range t.Payments {
+		if payment.Status != PaymentPaid {
+			continue
+		}
+		if sum, err := paid.Add(payment.SettledAmount); err == nil {
+			paid = sum
+		}
+	}
+
+	t.PaidAmount = paid
+	t.BalanceDue, _ = t.TotalAmount.Sub(paid)
+
+	switch {
+	case t.BalanceDue.IsZero():
+		t.PaymentStatus = BalancePaid
+	case t.PaidAmount.IsZero():
+		t.PaymentStatus = BalanceUnpaid
+	case t.BalanceDue.Sign() > 0:
+		t.PaymentStatus = BalancePartial
+	default:
+		t.PaymentStatus = BalanceOverpaid
+	}
+}
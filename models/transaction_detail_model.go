@@ -1,13 +1,40 @@
 // models/transaction_detail.go
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/money"
+)
 
 type TransactionDetail struct {
 	ID            primitive.ObjectID `bson:"_id,omitempty"  json:"id"`
 	TransactionID primitive.ObjectID `bson:"transaction_id" json:"transaction_id"`
-	ProductID     primitive.ObjectID `bson:"product_id" json:"product_id"`
-	Quantity      float64            `bson:"quantity" binding:"required" json:"quantity"`
-	Subtotal      float64            `bson:"subtotal" binding:"required" json:"subtotal"`
-	Price         float64            `bson:"price" binding:"required" json:"price"`
+	ProductID     primitive.ObjectID `bson:"product_id" binding:"objectid" json:"product_id"`
+	Quantity      float64            `bson:"quantity" binding:"gt=0" json:"quantity"`
+	Subtotal      money.Money        `bson:"subtotal" binding:"money" json:"subtotal"`
+	Price         money.Money        `bson:"price" binding:"money" json:"price"`
+
+	// DiscountAmount is what the promotions in Discounts took off
+	// Subtotal, the cart promotions' share included. Both are worked out
+	// from the promotions in effect, never taken from the request.
+	DiscountAmount money.Money       `bson:"discount_amount" json:"discount_amount"`
+	Discounts      []AppliedDiscount `bson:"discounts" json:"discounts"`
+
+	// TaxRate is the tax, in percent, charged on the line. TaxableAmount
+	// plus TaxAmount is Total; Subtotal less DiscountAmount is one of the
+	// two, depending on whether prices included the tax. They are worked
+	// out from the tax configuration, never taken from the request.
+	TaxRate       float64     `bson:"tax_rate" json:"tax_rate"`
+	TaxableAmount money.Money `bson:"taxable_amount" json:"taxable_amount"`
+	TaxAmount     money.Money `bson:"tax_amount" json:"tax_amount"`
+	Total         money.Money `bson:"total" json:"total"`
+
+	// ProductCode and ProductName snapshot the product as it was sold,
+	// together with Price, its unit price at the time. They are set from
+	// the catalogue, never from the request, and later product changes
+	// leave them alone. Details stored before snapshots existed have them
+	// empty; ?embed=product fills in the current name for those.
+	ProductCode string `bson:"product_code,omitempty" json:"product_code,omitempty"`
+	ProductName string `bson:"product_name,omitempty" json:"product_name,omitempty"`
 }
@@ -5,14 +5,36 @@ import (
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/money"
 )
 
 type Transaction struct {
 	ID              primitive.ObjectID   `bson:"_id,omitempty"  json:"id"`
-	CustomerID      primitive.ObjectID   `bson:"customer_id" binding:"required" json:"customer_id"`
-	TotalAmount     float64              `bson:"total_amount"  json:"total_amount"`
+	CustomerID      primitive.ObjectID   `bson:"customer_id" binding:"objectid" json:"customer_id"`
+	Currency        string               `bson:"currency" binding:"omitempty,currency" json:"currency"`
+	CouponCode      string               `bson:"coupon_code,omitempty" binding:"max=50" json:"coupon_code,omitempty"`
+	DiscountAmount  money.Money          `bson:"discount_amount" json:"discount_amount"`
+	Discounts       []AppliedDiscount    `bson:"discounts" json:"discounts"`
+	Subtotal        money.Money          `bson:"subtotal" json:"subtotal"`
+	TaxAmount       money.Money          `bson:"tax_amount" json:"tax_amount"`
+	TotalAmount     money.Money          `bson:"total_amount"  json:"total_amount"`
+	TaxInclusive    bool                 `bson:"tax_inclusive" json:"tax_inclusive"`
+	Taxes           []TaxTotal           `bson:"taxes" json:"taxes"`
 	TotalQty        float64              `bson:"total_qty"  json:"total_qty"`
 	TransactionDate time.Time            `bson:"transaction_date"  json:"transaction_date"`
-	Details         []TransactionDetail  `bson:"details"  json:"details"`
-	Payments        []TransactionPayment `bson:"payments"  json:"payments"`
+	Details         []TransactionDetail  `bson:"details" binding:"dive" json:"details"`
+	Payments        []TransactionPayment `bson:"payments" binding:"dive" json:"payments"`
+
+	// Computed from Payments by SummarizePayments; never stored.
+	PaidAmount    money.Money   `bson:"-" json:"paid_amount"`
+	BalanceDue    money.Money   `bson:"-" json:"balance_due"`
+	PaymentStatus BalanceStatus `bson:"-" json:"payment_status"`
+}
+
+// TaxTotal sums the details of a transaction taxed at one rate.
+type TaxTotal struct {
+	Rate          float64     `bson:"rate" json:"rate"`
+	TaxableAmount money.Money `bson:"taxable_amount" json:"taxable_amount"`
+	TaxAmount     money.Money `bson:"tax_amount" json:"tax_amount"`
 }
@@ -4,13 +4,25 @@ import (
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/money"
 )
 
 type TransactionPayment struct {
 	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	TransactionID   primitive.ObjectID `bson:"transaction_id" binding:"required" json:"transaction_id"`
-	PaymentMethodID primitive.ObjectID `bson:"payment_method_id" binding:"required" json:"payment_method_id"`
-	Status          int64              `bson:"status" binding:"required" json:"status"`
-	PaidAmount      float64            `bson:"paid_amount" binding:"required" json:"paid_amount"`
+	TransactionID   primitive.ObjectID `bson:"transaction_id" json:"transaction_id"`
+	PaymentMethodID primitive.ObjectID `bson:"payment_method_id" binding:"objectid" json:"payment_method_id"`
+	Status          PaymentStatus      `bson:"status" json:"status"`
+	PaidAmount      money.Money        `bson:"paid_amount" binding:"money" json:"paid_amount"`
 	PaymentDate     time.Time          `bson:"payment_date"  json:"payment_date"`
+
+	// SettledAmount is PaidAmount in the currency of the transaction, which
+	// is what counts towards its balance. ExchangeRate is the units of the
+	// transaction's currency that one unit of PaidAmount's bought; 1 when
+	// both are the same. Both are set when the payment is stored.
+	SettledAmount money.Money `bson:"settled_amount" json:"settled_amount"`
+	ExchangeRate  float64     `bson:"exchange_rate" json:"exchange_rate"`
+
+	// Filled in on request when listing transactions; never stored.
+	PaymentMethodName string `bson:"-" json:"payment_method_name,omitempty"`
 }
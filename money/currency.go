@@ -0,0 +1,28 @@
+package money
+
+import "strings"
+
+// DefaultCurrency is the currency of amounts given without one.
+const DefaultCurrency = "IDR"
+
+// exponents holds the number of decimals of each supported currency, by
+// ISO 4217 code.
+var exponents = map[string]int{
+	"AUD": 2,
+	"CNY": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"IDR": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"MYR": 2,
+	"SGD": 2,
+	"USD": 2,
+}
+
+// Exponent returns the number of decimals of currency and whether it is
+// supported.
+func Exponent(currency string) (int, bool) {
+	exponent, ok := exponents[strings.ToUpper(currency)]
+	return exponent, ok
+}
@@ -0,0 +1,119 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// jsonMoney is the JSON form of an amount. Amounts are strings so that
+// clients read them without going through floating point.
+type jsonMoney struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency,omitempty"`
+}
+
+// MarshalJSON writes the amount as {"amount": "12000.50", "currency": "IDR"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.Decimal(), m.Currency()})
+}
+
+// UnmarshalJSON reads an amount written by MarshalJSON. The amount may
+// also be a JSON number, and a bare number or string stands for an amount
+// in DefaultCurrency, so clients can keep sending plain prices.
+//
+// A value that is not an amount, or has more decimals than its currency,
+// leaves m invalid instead of failing the whole document, so validation
+// can report it by name along with every other field.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	amount, currency := data, DefaultCurrency
+	if len(data) > 0 && data[0] == '{' {
+		var decoded jsonMoney
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			*m = Money{invalid: true}
+			return nil
+		}
+		amount = decoded.Amount
+		if decoded.Currency != "" {
+			currency = decoded.Currency
+		}
+	}
+
+	var text string
+	if len(amount) > 0 && amount[0] == '"' {
+		if err := json.Unmarshal(amount, &text); err != nil {
+			*m = Money{invalid: true}
+			return nil
+		}
+	} else {
+		text = string(amount)
+	}
+	parsed, err := Parse(text, currency)
+	if err != nil {
+		*m = Money{invalid: true}
+		return nil
+	}
+	*m = parsed
+	return nil
+}
+
+// bsonMoney is the BSON form of an amount.
+type bsonMoney struct {
+	Minor    int64  `bson:"minor"`
+	Currency string `bson:"currency"`
+}
+
+// MarshalBSONValue stores the amount as {minor: 1200050, currency: "IDR"},
+// which sorts and filters by minor.
+func (m Money) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	data, err := bson.Marshal(bsonMoney{Minor: m.minor, Currency: m.Currency()})
+	return bsontype.EmbeddedDocument, data, err
+}
+
+// UnmarshalBSONValue reads an amount stored by MarshalBSONValue. Plain
+// numbers are amounts in major units of DefaultCurrency, stored before
+// amounts had a currency.
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	value := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bsontype.EmbeddedDocument:
+		var decoded bsonMoney
+		if err := value.Unmarshal(&decoded); err != nil {
+			return err
+		}
+		if _, ok := Exponent(decoded.Currency); !ok {
+			return fmt.Errorf("money: unknown currency %q", decoded.Currency)
+		}
+		*m = New(decoded.Minor, decoded.Currency)
+		return nil
+	case bsontype.Double:
+		converted, err := FromFloat(value.Double(), DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		*m = converted
+		return nil
+	case bsontype.Int32, bsontype.Int64:
+		converted, err := FromFloat(float64(value.AsInt64()), DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		*m = converted
+		return nil
+	case bsontype.Null, bsontype.Undefined:
+		*m = Money{}
+		return nil
+	}
+	return fmt.Errorf("money: cannot decode BSON %s as an amount", t)
+}
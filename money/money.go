@@ -0,0 +1,296 @@
+// Package money represents amounts of money exactly, as a whole number of
+// the minor units of a currency (cents, sen), so that totals never pick up
+// the rounding errors of floating point. Amounts only combine with amounts
+// of the same currency.
+//
+// Multiplying an amount, by a quantity or a tax rate for instance, and
+// converting it to another currency both round the result to the nearest
+// minor unit, with halves rounded away from zero. Parsing never rounds: an
+// amount with more decimals than its currency has is rejected.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrCurrencyMismatch is returned when amounts of different currencies
+	// are combined.
+	ErrCurrencyMismatch = errors.New("money: currencies differ")
+	// ErrOverflow is returned when a result does not fit in minor units.
+	ErrOverflow = errors.New("money: amount out of range")
+	// ErrInvalidRate is returned when converting at a rate that is not a
+	// positive number.
+	ErrInvalidRate = errors.New("money: exchange rate must be above zero")
+)
+
+// decimalPattern matches the amounts Parse accepts: JSON numbers, with a
+// short exponent so parsing stays cheap.
+var decimalPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][+-]?[0-9]{1,3})?$`)
+
+// Money is an amount in a currency. The zero value is zero in
+// DefaultCurrency.
+type Money struct {
+	minor    int64
+	currency string
+	// invalid marks a JSON value that is not an amount; see UnmarshalJSON.
+	invalid bool
+}
+
+// New returns the amount of minor units of currency, so New(150, "IDR") is
+// IDR 1.50.
+func New(minor int64, currency string) Money {
+	return Money{minor: minor, currency: strings.ToUpper(currency)}
+}
+
+// Zero returns no money in currency.
+func Zero(currency string) Money {
+	return New(0, currency)
+}
+
+// Parse reads a decimal amount in major units, such as "12000.50", in
+// currency. Exponents are allowed; more decimals than the currency has
+// are not.
+func Parse(amount, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	exponent, ok := Exponent(currency)
+	if !ok {
+		return Money{}, fmt.Errorf("money: unknown currency %q", currency)
+	}
+	if !decimalPattern.MatchString(amount) {
+		return Money{}, fmt.Errorf("money: %q is not a decimal amount", amount)
+	}
+	value, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return Money{}, fmt.Errorf("money: %q is not a decimal amount", amount)
+	}
+	value.Mul(value, scale(exponent))
+	if !value.IsInt() {
+		return Money{}, fmt.Errorf("money: %q has more than %d decimals for %s", amount, exponent, currency)
+	}
+	if !value.Num().IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return New(value.Num().Int64(), currency), nil
+}
+
+// MustParse is Parse for amounts known to be valid; it panics otherwise.
+func MustParse(amount, currency string) Money {
+	m, err := Parse(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// FromFloat converts a floating point amount in major units, rounding it
+// to the nearest minor unit. It is meant for amounts stored before Money
+// existed.
+func FromFloat(amount float64, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+	exponent, ok := Exponent(currency)
+	if !ok {
+		return Money{}, fmt.Errorf("money: unknown currency %q", currency)
+	}
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return Money{}, ErrOverflow
+	}
+	minor, ok := round(new(big.Rat).Mul(decimal(amount), scale(exponent)))
+	if !ok {
+		return Money{}, ErrOverflow
+	}
+	return New(minor, currency), nil
+}
+
+// Valid reports whether the amount was read successfully. Only
+// UnmarshalJSON makes invalid amounts.
+func (m Money) Valid() bool { return !m.invalid }
+
+// Minor returns the amount in minor units.
+func (m Money) Minor() int64 { return m.minor }
+
+// Currency returns the ISO 4217 code of the amount's currency.
+func (m Money) Currency() string {
+	if m.currency == "" {
+		return DefaultCurrency
+	}
+	return m.currency
+}
+
+// IsZero reports whether the amount is zero, in any currency.
+func (m Money) IsZero() bool { return m.minor == 0 }
+
+// Sign returns -1, 0 or 1 as the amount is negative, zero or positive.
+func (m Money) Sign() int {
+	switch {
+	case m.minor < 0:
+		return -1
+	case m.minor > 0:
+		return 1
+	}
+	return 0
+}
+
+// Equal reports whether m and other are the same amount of the same
+// currency.
+func (m Money) Equal(other Money) bool {
+	return m.minor == other.minor && m.Currency() == other.Currency()
+}
+
+// Neg returns the amount with its sign flipped.
+func (m Money) Neg() Money {
+	return Money{minor: -m.minor, currency: m.currency}
+}
+
+// Add returns m plus other.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency() != other.Currency() {
+		return Money{}, ErrCurrencyMismatch
+	}
+	sum := m.minor + other.minor
+	if (other.minor > 0 && sum < m.minor) || (other.minor < 0 && sum > m.minor) {
+		return Money{}, ErrOverflow
+	}
+	return New(sum, m.Currency()), nil
+}
+
+// Sub returns m minus other.
+func (m Money) Sub(other Money) (Money, error) {
+	if other.minor == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(other.Neg())
+}
+
+// Mul returns m times factor, rounded to a minor unit. The factor is taken
+// as the shortest decimal that reads back as it, so a quantity of 0.1 is
+// exactly a tenth.
+func (m Money) Mul(factor float64) (Money, error) {
+	if math.IsNaN(factor) || math.IsInf(factor, 0) {
+		return Money{}, ErrOverflow
+	}
+	product := new(big.Rat).Mul(new(big.Rat).SetInt64(m.minor), decimal(factor))
+	minor, ok := round(product)
+	if !ok {
+		return Money{}, ErrOverflow
+	}
+	return New(minor, m.Currency()), nil
+}
+
+// MulRatio returns m times num/den, rounded to a minor unit, so a tax of
+// 11% on a price that includes it is MulRatio(11, 111). Like Mul, it takes
+// num and den as the shortest decimals that read back as them.
+func (m Money) MulRatio(num, den float64) (Money, error) {
+	if math.IsNaN(num) || math.IsInf(num, 0) || math.IsNaN(den) || math.IsInf(den, 0) || den == 0 {
+		return Money{}, ErrOverflow
+	}
+	ratio := new(big.Rat).Quo(decimal(num), decimal(den))
+	minor, ok := round(new(big.Rat).Mul(new(big.Rat).SetInt64(m.minor), ratio))
+	if !ok {
+		return Money{}, ErrOverflow
+	}
+	return New(minor, m.Currency()), nil
+}
+
+// Convert returns m in currency at rate, the units of currency that one
+// unit of m's currency buys, rounded to a minor unit of currency. Like
+// Mul, it takes rate as the shortest decimal that reads back as it.
+func (m Money) Convert(currency string, rate float64) (Money, error) {
+	if math.IsNaN(rate) || math.IsInf(rate, 0) || rate <= 0 {
+		return Money{}, ErrInvalidRate
+	}
+	return m.convert(currency, decimal(rate))
+}
+
+// ConvertInverse is Convert at a rate quoted the other way round: rate is
+// the units of m's currency that one unit of currency buys.
+func (m Money) ConvertInverse(currency string, rate float64) (Money, error) {
+	if math.IsNaN(rate) || math.IsInf(rate, 0) || rate <= 0 {
+		return Money{}, ErrInvalidRate
+	}
+	return m.convert(currency, new(big.Rat).Inv(decimal(rate)))
+}
+
+func (m Money) convert(currency string, rate *big.Rat) (Money, error) {
+	currency = strings.ToUpper(currency)
+	to, ok := Exponent(currency)
+	if !ok {
+		return Money{}, fmt.Errorf("money: unknown currency %q", currency)
+	}
+	from, _ := Exponent(m.Currency())
+
+	// Minor units of one currency to minor units of the other
+	factor := new(big.Rat).Mul(rate, new(big.Rat).Quo(scale(to), scale(from)))
+	minor, ok := round(new(big.Rat).Mul(new(big.Rat).SetInt64(m.minor), factor))
+	if !ok {
+		return Money{}, ErrOverflow
+	}
+	return New(minor, currency), nil
+}
+
+// Sum adds amounts, which must all be in currency. No amounts sum to zero.
+func Sum(currency string, amounts ...Money) (Money, error) {
+	total := Zero(currency)
+	for _, amount := range amounts {
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// Decimal formats the amount in major units with every decimal of its
+// currency, such as "12000.50".
+func (m Money) Decimal() string {
+	exponent, _ := Exponent(m.Currency())
+	digits := strconv.FormatInt(m.minor, 10)
+	sign := ""
+	if m.minor < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	cut := len(digits) - exponent
+	return sign + digits[:cut] + "." + digits[cut:]
+}
+
+// String formats the amount with its currency, such as "12000.50 IDR".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency()
+}
+
+// scale returns 10 to the power exponent.
+func scale(exponent int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil))
+}
+
+// decimal converts f exactly as the shortest decimal that reads back as f.
+func decimal(f float64) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	return r
+}
+
+// round rounds r to the nearest integer, halves away from zero, and
+// reports whether the result fits in an int64.
+func round(r *big.Rat) (int64, bool) {
+	quotient, remainder := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	// Away from zero when the remainder is at least half the denominator
+	if new(big.Int).Abs(new(big.Int).Lsh(remainder, 1)).Cmp(r.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(r.Num().Sign())))
+	}
+	if !quotient.IsInt64() {
+		return 0, false
+	}
+	return quotient.Int64(), true
+}
@@ -0,0 +1,63 @@
+package money
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		name  string
+		value *big.Rat
+		want  int64
+	}{
+		{"whole", big.NewRat(12, 1), 12},
+		{"below half", big.NewRat(149, 100), 1},
+		{"half", big.NewRat(3, 2), 2},
+		{"above half", big.NewRat(151, 100), 2},
+		{"negative below half", big.NewRat(-149, 100), -1},
+		{"negative half", big.NewRat(-3, 2), -2},
+		{"negative above half", big.NewRat(-151, 100), -2},
+		{"small negative half", big.NewRat(-1, 2), -1},
+		{"small negative", big.NewRat(-1, 3), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := round(tt.value)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, ok := round(new(big.Rat).SetFrac(new(big.Int).Lsh(big.NewInt(1), 63), big.NewInt(1)))
+	assert.False(t, ok)
+}
+
+func TestMulRatio(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Money
+		num, den float64
+		want     Money
+	}{
+		{"exact", New(10000, "IDR"), 11, 100, New(1100, "IDR")},
+		{"half up", New(150, "IDR"), 1, 100, New(2, "IDR")},
+		{"negative half", New(-150, "IDR"), 1, 100, New(-2, "IDR")},
+		{"inclusive tax", New(11100, "IDR"), 11, 111, New(1100, "IDR")},
+		{"repeating", New(100, "IDR"), 1, 3, New(33, "IDR")},
+		{"negative repeating", New(-200, "IDR"), 1, 3, New(-67, "IDR")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.amount.MulRatio(tt.num, tt.den)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+
+	_, err := New(100, "IDR").MulRatio(1, 0)
+	assert.ErrorIs(t, err, ErrOverflow)
+}
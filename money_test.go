@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/controllers"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/money"
+	"github.com/mifaabiyyu/go-test.git/repository/gormdb"
+)
+
+func TestMoneyParse(t *testing.T) {
+	for _, tc := range []struct {
+		amount   string
+		currency string
+		minor    int64
+		ok       bool
+	}{
+		{"12000.50", "IDR", 1200050, true},
+		{"12000", "idr", 1200000, true},
+		{"-0.05", "USD", -5, true},
+		{"1.5e3", "IDR", 150000, true},
+		{"1500", "JPY", 1500, true},
+		{"1500.5", "JPY", 0, false},
+		{"0.001", "IDR", 0, false},
+		{"12,000", "IDR", 0, false},
+		{"", "IDR", 0, false},
+		{"100", "XYZ", 0, false},
+		{"99999999999999999999", "IDR", 0, false},
+	} {
+		m, err := money.Parse(tc.amount, tc.currency)
+		if !tc.ok {
+			assert.Error(t, err, tc.amount)
+			continue
+		}
+		if assert.NoError(t, err, tc.amount) {
+			assert.Equal(t, tc.minor, m.Minor(), tc.amount)
+		}
+	}
+
+	assert.Equal(t, "12000.50 IDR", money.MustParse("12000.5", "IDR").String())
+	assert.Equal(t, "-0.05", money.New(-5, "USD").Decimal())
+	assert.Equal(t, "1500", money.New(1500, "JPY").Decimal())
+	assert.Equal(t, "IDR", money.Money{}.Currency())
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	sum, err := money.Sum("IDR", money.MustParse("0.10", "IDR"), money.MustParse("0.20", "IDR"))
+	assert.NoError(t, err)
+	assert.Equal(t, money.MustParse("0.30", "IDR"), sum)
+
+	_, err = idr(1).Add(money.New(1, "USD"))
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+	_, err = money.New(1<<62, "IDR").Add(money.New(1<<62, "IDR"))
+	assert.ErrorIs(t, err, money.ErrOverflow)
+
+	// Multiplying rounds to the nearest minor unit, halves away from zero
+	for _, tc := range []struct {
+		minor  int64
+		factor float64
+		want   int64
+	}{
+		{5, 0.5, 3},
+		{-5, 0.5, -3},
+		{4, 0.5, 2},
+		{1000, 0.1, 100},
+		{1999, 1.5, 2999},
+		{333, 3, 999},
+	} {
+		product, err := money.New(tc.minor, "IDR").Mul(tc.factor)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.want, product.Minor(), "%d × %v", tc.minor, tc.factor)
+	}
+
+	converted, err := money.FromFloat(0.1+0.2, "IDR")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(30), converted.Minor())
+}
+
+func TestMoneyJSON(t *testing.T) {
+	data, err := json.Marshal(money.MustParse("12000.5", "IDR"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"12000.50","currency":"IDR"}`, string(data))
+
+	for _, input := range []string{
+		`{"amount":"12000.50","currency":"IDR"}`,
+		`{"amount":12000.5,"currency":"idr"}`,
+		`{"amount":"12000.50"}`,
+		`"12000.5"`,
+		`12000.50`,
+	} {
+		var m money.Money
+		assert.NoError(t, json.Unmarshal([]byte(input), &m), input)
+		assert.True(t, m.Valid(), input)
+		assert.Equal(t, money.New(1200050, "IDR"), m, input)
+	}
+
+	for _, input := range []string{`"murah"`, `12000.505`, `{"amount":"1","currency":"XYZ"}`, `true`, `{"amount":[1]}`} {
+		var m money.Money
+		assert.NoError(t, json.Unmarshal([]byte(input), &m), input)
+		assert.False(t, m.Valid(), input)
+	}
+}
+
+func TestMoneyBSON(t *testing.T) {
+	type document struct {
+		Price money.Money `bson:"price"`
+	}
+
+	data, err := bson.Marshal(document{Price: money.New(1200050, "USD")})
+	assert.NoError(t, err)
+	var raw bson.M
+	assert.NoError(t, bson.Unmarshal(data, &raw))
+	assert.Equal(t, bson.M{"minor": int64(1200050), "currency": "USD"}, raw["price"])
+
+	var decoded document
+	assert.NoError(t, bson.Unmarshal(data, &decoded))
+	assert.Equal(t, money.New(1200050, "USD"), decoded.Price)
+
+	// Amounts stored before Money were plain numbers of rupiah
+	for _, tc := range []struct {
+		stored interface{}
+		want   money.Money
+	}{
+		{12000.5, money.New(1200050, "IDR")},
+		{int32(12000), idr(12000)},
+		{int64(12000), idr(12000)},
+		{nil, money.Money{}},
+	} {
+		data, err := bson.Marshal(bson.M{"price": tc.stored})
+		assert.NoError(t, err)
+		var decoded document
+		assert.NoError(t, bson.Unmarshal(data, &decoded), tc.stored)
+		assert.Equal(t, tc.want, decoded.Price, tc.stored)
+	}
+}
+
+func TestTransactionTotalsAreExact(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+	ctx := context.Background()
+
+	cheap := models.Product{ID: primitive.NewObjectID(), Code: "P003", Name: "Permen", Price: money.MustParse("0.10", "IDR"), StockQuantity: 100}
+	if err := repos.Products.Create(ctx, &cheap); err != nil {
+		t.Fatal(err)
+	}
+
+	w := performRequest(t, r, "POST", "/transaction", transactionRequest{
+		Transaction: models.Transaction{CustomerID: cat.Customer.ID},
+		Details: []models.TransactionDetail{
+			{ProductID: cheap.ID, Quantity: 1},
+			{ProductID: cheap.ID, Quantity: 2},
+		},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response controllers.CreateTransactionResponse
+	decodeBody(t, w, &response)
+	assert.Equal(t, money.MustParse("0.30", "IDR"), response.Transaction.TotalAmount)
+	assert.Contains(t, w.Body.String(), `"total_amount":{"amount":"0.30","currency":"IDR"}`)
+
+	// Payments in another currency need an exchange rate
+	w = performRequest(t, r, "POST", "/transaction/"+response.Transaction.ID.Hex()+"/payments", controllers.PaymentRequest{
+		PaymentMethodID: cat.PaymentMethod.ID, Status: models.PaymentPaid, PaidAmount: money.New(30, "USD"),
+	})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	body := decodeError(t, w)
+	assert.Equal(t, "exchange_rate_missing", body.Code)
+	assert.Equal(t, "paid_amount", body.Fields[0].Field)
+}
+
+func TestTransactionRejectsUnpricedProducts(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	imported := models.Product{ID: primitive.NewObjectID(), Code: "P003", Name: "Kopi Luwak", Price: money.New(2500, "USD"), StockQuantity: 10}
+	if err := repos.Products.Create(context.Background(), &imported); err != nil {
+		t.Fatal(err)
+	}
+
+	w := performRequest(t, r, "POST", "/transaction", transactionRequest{
+		Transaction: models.Transaction{CustomerID: cat.Customer.ID},
+		Details: []models.TransactionDetail{
+			{ProductID: cat.Products[0].ID, Quantity: 1},
+			{ProductID: imported.ID, Quantity: 1},
+		},
+	})
+	// A USD price cannot be sold in rupiah without a rate to convert it at
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	body := decodeError(t, w)
+	assert.Equal(t, "pricing_failed", body.Code)
+	var unpriced []primitive.ObjectID
+	decodeErrorDetail(t, w, "unpriced_products", &unpriced)
+	assert.Equal(t, []primitive.ObjectID{imported.ID}, unpriced)
+}
+
+func TestSQLiteMigratesFloatAmounts(t *testing.T) {
+	db := openSQLite(t, testConfig())
+	// Bring back the column a database from before Money had
+	id := primitive.NewObjectID()
+	assert.NoError(t, db.Exec("ALTER TABLE products ADD COLUMN price REAL").Error)
+	assert.NoError(t, db.Exec("INSERT INTO products (id, code, name, price) VALUES (?, 'P001', 'Kopi', 12000.5)", id.Hex()).Error)
+
+	assert.NoError(t, gormdb.Migrate(db))
+	assert.False(t, db.Migrator().HasColumn("products", "price"))
+
+	product, err := gormdb.NewRepositories(db).Products.FindByID(context.Background(), id)
+	if assert.NoError(t, err) {
+		assert.Equal(t, money.MustParse("12000.50", "IDR"), product.Price)
+	}
+}
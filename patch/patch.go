@@ -0,0 +1,234 @@
+// Package patch applies partial updates to JSON documents, either as a
+// JSON Merge Patch (RFC 7396) or as a JSON Patch (RFC 6902). Documents and
+// patches are handled as plain JSON, so the same code patches every
+// resource; decoding and validating the result is up to the caller.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+)
+
+// Media types of the two patch formats.
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// ErrUnsupportedType is returned by Apply for a media type that is not a
+// patch format.
+var ErrUnsupportedType = errors.New("patch: unsupported media type")
+
+// Reason tells why a patch was rejected.
+type Reason int
+
+const (
+	// Malformed is a patch that is not valid in its own format.
+	Malformed Reason = iota
+	// Unapplicable is a valid patch that does not fit the document, such
+	// as one that replaces a field that is not there.
+	Unapplicable
+	// TestFailed is a JSON Patch whose test operation did not match.
+	TestFailed
+)
+
+// Error is a patch that was rejected. Operation is the index of the
+// JSON Patch operation at fault, or -1 when the patch as a whole is.
+type Error struct {
+	Reason    Reason
+	Operation int
+	Path      string
+	Message   string
+}
+
+func (e *Error) Error() string {
+	if e.Operation < 0 {
+		return "patch: " + e.Message
+	}
+	return fmt.Sprintf("patch: operation %d on %q: %s", e.Operation, e.Path, e.Message)
+}
+
+// Apply patches doc with a patch of the given media type, which may carry
+// parameters such as a charset. A plain application/json patch is taken
+// to be a merge patch.
+func Apply(mediaType string, doc, patch []byte) ([]byte, error) {
+	base, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	switch base {
+	case MergePatchType, "application/json":
+		return MergePatch(doc, patch)
+	case JSONPatchType:
+		return JSONPatch(doc, patch)
+	}
+	return nil, ErrUnsupportedType
+}
+
+// MergePatch applies an RFC 7396 merge patch: objects in the patch are
+// merged into the document field by field, a null removes a field and
+// anything else replaces what was there.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	merge, err := decode(patch)
+	if err != nil {
+		return nil, &Error{Reason: Malformed, Operation: -1, Message: "not valid JSON"}
+	}
+	return json.Marshal(mergeValue(target, merge))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	fields, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	result, ok := target.(map[string]interface{})
+	if !ok {
+		result = map[string]interface{}{}
+	}
+	for name, value := range fields {
+		if value == nil {
+			delete(result, name)
+		} else {
+			result[name] = mergeValue(result[name], value)
+		}
+	}
+	return result
+}
+
+// JSONPatch applies an RFC 6902 patch, a list of add, remove, replace,
+// move, copy and test operations. Operations apply in order and the patch
+// is rejected as a whole if any of them fails.
+func JSONPatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var operations []map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, &Error{Reason: Malformed, Operation: -1, Message: "must be an array of operations"}
+	}
+	for i, raw := range operations {
+		op, err := parseOperation(raw)
+		if err != nil {
+			return nil, &Error{Reason: Malformed, Operation: i, Path: op.path, Message: err.Error()}
+		}
+		target, err = op.apply(target)
+		if err != nil {
+			reason := Unapplicable
+			if err == errTestFailed {
+				reason = TestFailed
+			}
+			return nil, &Error{Reason: reason, Operation: i, Path: op.path, Message: err.Error()}
+		}
+	}
+	return json.Marshal(target)
+}
+
+var errTestFailed = errors.New("value does not match")
+
+type operation struct {
+	op       string
+	path     string
+	from     string
+	value    interface{}
+	hasValue bool
+}
+
+func parseOperation(raw map[string]json.RawMessage) (operation, error) {
+	var op operation
+	if err := json.Unmarshal(raw["path"], &op.path); err != nil {
+		return op, errors.New(`"path" must be a string`)
+	}
+	if err := json.Unmarshal(raw["op"], &op.op); err != nil {
+		return op, errors.New(`"op" must be a string`)
+	}
+
+	switch op.op {
+	case "add", "replace", "test":
+		value, ok := raw["value"]
+		if !ok {
+			return op, fmt.Errorf(`%q needs a "value"`, op.op)
+		}
+		v, err := decode(value)
+		if err != nil {
+			return op, err
+		}
+		op.value, op.hasValue = v, true
+	case "move", "copy":
+		if err := json.Unmarshal(raw["from"], &op.from); err != nil {
+			return op, fmt.Errorf(`%q needs a "from" string`, op.op)
+		}
+		if _, err := parsePointer(op.from); err != nil {
+			return op, err
+		}
+	case "remove":
+	default:
+		return op, fmt.Errorf("unknown operation %q", op.op)
+	}
+	if _, err := parsePointer(op.path); err != nil {
+		return op, err
+	}
+	return op, nil
+}
+
+func (op operation) apply(doc interface{}) (interface{}, error) {
+	path, _ := parsePointer(op.path)
+	switch op.op {
+	case "add":
+		return add(doc, path, op.value)
+	case "remove":
+		return remove(doc, path)
+	case "replace":
+		if _, err := get(doc, path); err != nil {
+			return nil, err
+		}
+		return replace(doc, path, op.value)
+	case "test":
+		value, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(value, op.value) {
+			return nil, errTestFailed
+		}
+		return doc, nil
+	}
+
+	from, _ := parsePointer(op.from)
+	value, err := get(doc, from)
+	if err != nil {
+		return nil, err
+	}
+	if op.op == "copy" {
+		return add(doc, path, deepCopy(value))
+	}
+	if len(path) > len(from) && isPrefix(from, path) {
+		return nil, errors.New("cannot move a value into itself")
+	}
+	doc, err = remove(doc, from)
+	if err != nil {
+		return nil, err
+	}
+	return add(doc, path, value)
+}
+
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("trailing data after JSON value")
+	}
+	return value, nil
+}
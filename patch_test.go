@@ -0,0 +1,240 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mifaabiyyu/go-test.git/controllers"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/patch"
+)
+
+// jsonPatch is a JSON Patch operation as sent by clients.
+type jsonPatch map[string]interface{}
+
+func TestMergePatch(t *testing.T) {
+	// Examples from RFC 7396, appendix A
+	cases := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tc := range cases {
+		got, err := patch.MergePatch([]byte(tc.doc), []byte(tc.patch))
+		if assert.NoError(t, err, tc.patch) {
+			assert.JSONEq(t, tc.want, string(got), tc.patch)
+		}
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	// Mostly examples from RFC 6902, appendix A
+	cases := []struct{ doc, patch, want string }{
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{`{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{`{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{`{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{`{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{`{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{`{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2.0}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{`{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10},{"op":"copy","from":"/~1","path":"/a"}]`, `{"/":9,"~1":10,"a":9}`},
+		{`{"foo":null}`, `[{"op":"test","path":"/foo","value":null}]`, `{"foo":null}`},
+	}
+	for _, tc := range cases {
+		got, err := patch.JSONPatch([]byte(tc.doc), []byte(tc.patch))
+		if assert.NoError(t, err, tc.patch) {
+			assert.JSONEq(t, tc.want, string(got), tc.patch)
+		}
+	}
+
+	failures := []struct {
+		doc, patch string
+		reason     patch.Reason
+	}{
+		{`{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, patch.TestFailed},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, patch.Unapplicable},
+		{`{"foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"qux"}]`, patch.Unapplicable},
+		{`{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":"qux"}]`, patch.Unapplicable},
+		{`{"foo":["bar"]}`, `[{"op":"remove","path":"/foo/01"}]`, patch.Unapplicable},
+		{`{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar/baz"}]`, patch.Unapplicable},
+		{`{"foo":"bar"}`, `[{"op":"add","path":"/baz"}]`, patch.Malformed},
+		{`{"foo":"bar"}`, `[{"op":"frobnicate","path":"/foo"}]`, patch.Malformed},
+		{`{"foo":"bar"}`, `[{"op":"remove","path":"foo"}]`, patch.Malformed},
+		{`{"foo":"bar"}`, `{"op":"remove","path":"/foo"}`, patch.Malformed},
+	}
+	for _, tc := range failures {
+		_, err := patch.JSONPatch([]byte(tc.doc), []byte(tc.patch))
+		var patchErr *patch.Error
+		if assert.ErrorAs(t, err, &patchErr, tc.patch) {
+			assert.Equal(t, tc.reason, patchErr.Reason, tc.patch)
+		}
+	}
+}
+
+// TestPatchEndpoints patches a customer, a product and a transaction and
+// checks what was stored.
+func TestPatchEndpoints(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			r, _ := backend.router(t, testConfig())
+
+			var customer models.Customer
+			decodeBody(t, performRequest(t, r, "POST", "/customers", models.Customer{Name: "Budi", Code: "C001", Email: "budi@example.com"}), &customer)
+			customerPath := "/customers/" + customer.ID.Hex()
+
+			// A merge patch leaves the fields it does not name alone
+			w := performRequestWithHeader(t, r, "PATCH", customerPath, map[string]string{"email": "budi@example.org"}, "Content-Type", patch.MergePatchType)
+			assert.Equal(t, http.StatusOK, w.Code)
+			var patched models.Customer
+			decodeBody(t, w, &patched)
+			assert.Equal(t, customer.ID, patched.ID)
+			assert.Equal(t, "Budi", patched.Name)
+			assert.Equal(t, "budi@example.org", patched.Email)
+
+			// PUT responds with the stored customer, ID included
+			w = performRequest(t, r, "PUT", customerPath, models.Customer{Name: "Budi S", Code: "C001", Email: "budi@example.org"})
+			assert.Equal(t, http.StatusOK, w.Code)
+			decodeBody(t, w, &patched)
+			assert.Equal(t, customer.ID, patched.ID)
+
+			// Removing a required field is rejected
+			w = performRequestWithHeader(t, r, "PATCH", customerPath, map[string]interface{}{"name": nil}, "Content-Type", patch.MergePatchType)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, "name", decodeError(t, w).Fields[0].Field)
+
+			var product models.Product
+			decodeBody(t, performRequest(t, r, "POST", "/product", models.Product{Code: "P001", Name: "Kopi", Price: idr(12000), StockQuantity: 10}), &product)
+			productPath := "/product/" + product.ID.Hex()
+
+			w = performRequestWithHeader(t, r, "PATCH", productPath, []jsonPatch{
+				{"op": "test", "path": "/price/amount", "value": "12000.00"},
+				{"op": "replace", "path": "/price/amount", "value": "15000"},
+				{"op": "add", "path": "/description", "value": "Arabica"},
+				// Stock only changes through the ledger
+				{"op": "replace", "path": "/stock_quantity", "value": 99},
+			}, "Content-Type", patch.JSONPatchType)
+			assert.Equal(t, http.StatusOK, w.Code)
+			var patchedProduct models.Product
+			decodeBody(t, w, &patchedProduct)
+			assert.Equal(t, product.ID, patchedProduct.ID)
+			assert.Equal(t, "Kopi", patchedProduct.Name)
+			assert.Equal(t, idr(15000), patchedProduct.Price)
+			assert.Equal(t, "Arabica", patchedProduct.Description)
+			assert.Equal(t, 10.0, patchedProduct.StockQuantity)
+
+			var history controllers.ListResponse[models.ProductVersion]
+			decodeBody(t, performRequest(t, r, "GET", productPath+"/history", nil), &history)
+			assert.Len(t, history.Data, 2)
+
+			// A failed test leaves the product as it was
+			w = performRequestWithHeader(t, r, "PATCH", productPath, []jsonPatch{
+				{"op": "test", "path": "/price/amount", "value": "12000.00"},
+				{"op": "replace", "path": "/price/amount", "value": "9000"},
+			}, "Content-Type", patch.JSONPatchType)
+			assert.Equal(t, http.StatusConflict, w.Code)
+			body := decodeError(t, w)
+			assert.Equal(t, "patch_test_failed", body.Code)
+			assert.Equal(t, "/price/amount", body.Details["path"])
+
+			w = performRequestWithHeader(t, r, "PATCH", productPath, []jsonPatch{{"op": "replace", "path": "/colour", "value": "red"}}, "Content-Type", patch.JSONPatchType)
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+			assert.Equal(t, "patch_unapplicable", decodeError(t, w).Code)
+			w = performRequestWithHeader(t, r, "PATCH", productPath, []jsonPatch{{"op": "replace", "path": "/price"}}, "Content-Type", patch.JSONPatchType)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			w = performRequestWithHeader(t, r, "PATCH", productPath, []jsonPatch{{"op": "replace", "path": "/price", "value": "free"}}, "Content-Type", patch.JSONPatchType)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, "price", decodeError(t, w).Fields[0].Field)
+			w = performRequestWithHeader(t, r, "PATCH", productPath, map[string]int{"price": 1}, "Content-Type", "text/plain")
+			assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+			assert.Equal(t, "unsupported_media_type", decodeError(t, w).Code)
+
+			var stored models.Product
+			decodeBody(t, performRequest(t, r, "GET", productPath, nil), &stored)
+			assert.Equal(t, idr(15000), stored.Price)
+
+			w = performRequest(t, r, "POST", "/transaction", transactionRequest{
+				Transaction: models.Transaction{CustomerID: customer.ID},
+				Details:     []models.TransactionDetail{{ProductID: product.ID, Quantity: 2}},
+			})
+			assert.Equal(t, http.StatusCreated, w.Code)
+			var created controllers.CreateTransactionResponse
+			decodeBody(t, w, &created)
+			transactionPath := "/transaction/" + created.Transaction.ID.Hex()
+
+			// Changing a quantity moves stock and recomputes the totals
+			w = performRequestWithHeader(t, r, "PATCH", transactionPath, []jsonPatch{
+				{"op": "replace", "path": "/details/0/quantity", "value": 3},
+			}, "Content-Type", patch.JSONPatchType)
+			assert.Equal(t, http.StatusOK, w.Code)
+			var transaction models.Transaction
+			decodeBody(t, w, &transaction)
+			assert.Equal(t, created.Transaction.ID, transaction.ID)
+			assert.Equal(t, idr(45000), transaction.TotalAmount)
+			if assert.Len(t, transaction.Details, 1) {
+				assert.Equal(t, 3.0, transaction.Details[0].Quantity)
+			}
+			decodeBody(t, performRequest(t, r, "GET", productPath, nil), &stored)
+			assert.Equal(t, 7.0, stored.StockQuantity)
+
+			w = performRequestWithHeader(t, r, "PATCH", "/transaction/64b7f0c2a1b2c3d4e5f60718", map[string]int{"total_qty": 1}, "Content-Type", patch.MergePatchType)
+			assert.Equal(t, http.StatusNotFound, w.Code)
+		})
+	}
+}
+
+func TestPatchAddressAndPaymentMethod(t *testing.T) {
+	r, _ := newTestRouter()
+
+	var customer models.Customer
+	decodeBody(t, performRequest(t, r, "POST", "/customers", models.Customer{Name: "Budi", Code: "C001", Email: "budi@example.com"}), &customer)
+	var address models.CustomerAddress
+	decodeBody(t, performRequest(t, r, "POST", "/customer-addresses", models.CustomerAddress{CustomerID: customer.ID, Street: "Jl. Merdeka 1", City: "Bandung", PostalCode: "40111"}), &address)
+
+	// Plain JSON bodies are taken as merge patches
+	w := performRequest(t, r, "PATCH", "/customer-addresses/"+address.ID.Hex(), map[string]string{"city": "Jakarta"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var patched models.CustomerAddress
+	decodeBody(t, w, &patched)
+	assert.Equal(t, address.ID, patched.ID)
+	assert.Equal(t, "Jl. Merdeka 1", patched.Street)
+	assert.Equal(t, "Jakarta", patched.City)
+
+	w = performRequest(t, r, "PATCH", "/customer-addresses/"+address.ID.Hex(), map[string]string{"customer_id": "64b7f0c2a1b2c3d4e5f60718"})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	var method models.PaymentMethod
+	decodeBody(t, performRequest(t, r, "POST", "/payment-method", models.PaymentMethod{Name: "Cash", IsActive: true}), &method)
+	w = performRequestWithHeader(t, r, "PATCH", "/payment-method/"+method.ID.Hex(), []jsonPatch{{"op": "replace", "path": "/is_active", "value": false}}, "Content-Type", patch.JSONPatchType)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var patchedMethod models.PaymentMethod
+	decodeBody(t, w, &patchedMethod)
+	assert.Equal(t, method.ID, patchedMethod.ID)
+	assert.Equal(t, "Cash", patchedMethod.Name)
+	assert.False(t, patchedMethod.IsActive)
+
+	// Deleted resources cannot be patched
+	performRequest(t, r, "DELETE", "/payment-method/"+method.ID.Hex(), nil)
+	w = performRequest(t, r, "PATCH", "/payment-method/"+method.ID.Hex(), map[string]bool{"is_active": true})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	entries := getAuditLog(t, r, "?entity_type=payment_method&action=update")
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, []models.AuditChange{{Field: "is_active", Before: "true", After: "false"}}, entries[0].Changes)
+	}
+}
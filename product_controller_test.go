@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mifaabiyyu/go-test.git/controllers"
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+// TestProductHistory changes a product that has been sold and checks that
+// the sale keeps what was sold while the history records every change.
+func TestProductHistory(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			r, _ := backend.router(t, testConfig())
+
+			var customer models.Customer
+			decodeBody(t, performRequest(t, r, "POST", "/customers", models.Customer{Name: "Budi", Code: "C001", Email: "budi@example.com"}), &customer)
+			var product models.Product
+			decodeBody(t, performRequest(t, r, "POST", "/product", models.Product{Code: "P001", Name: "Kopi", Price: idr(12000), StockQuantity: 10}), &product)
+			productPath := "/product/" + product.ID.Hex()
+
+			w := performRequest(t, r, "POST", "/transaction", transactionRequest{
+				Transaction: models.Transaction{CustomerID: customer.ID},
+				Details:     []models.TransactionDetail{{ProductID: product.ID, Quantity: 2}},
+			})
+			assert.Equal(t, http.StatusCreated, w.Code)
+			var created controllers.CreateTransactionResponse
+			decodeBody(t, w, &created)
+
+			product.Name = "Kopi Susu"
+			product.Price = idr(15000)
+			w = performRequest(t, r, "PUT", productPath, product)
+			assert.Equal(t, http.StatusOK, w.Code)
+			// Saving the same values again is not a new version
+			w = performRequest(t, r, "PUT", productPath, product)
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var transaction models.Transaction
+			decodeBody(t, performRequest(t, r, "GET", "/transaction/"+created.Transaction.ID.Hex()+"?embed=product", nil), &transaction)
+			if assert.Len(t, transaction.Details, 1) {
+				assert.Equal(t, "P001", transaction.Details[0].ProductCode)
+				assert.Equal(t, "Kopi", transaction.Details[0].ProductName)
+				assert.Equal(t, idr(12000), transaction.Details[0].Price)
+			}
+
+			w = performRequest(t, r, "GET", productPath+"/history", nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+			var history controllers.ListResponse[models.ProductVersion]
+			decodeBody(t, w, &history)
+			if assert.Len(t, history.Data, 2) {
+				// Newest first
+				assert.Equal(t, 2, history.Data[0].Version)
+				assert.Equal(t, "Kopi Susu", history.Data[0].Name)
+				assert.Equal(t, idr(15000), history.Data[0].Price)
+				assert.Equal(t, 1, history.Data[1].Version)
+				assert.Equal(t, "Kopi", history.Data[1].Name)
+				assert.Equal(t, idr(12000), history.Data[1].Price)
+			}
+
+			decodeBody(t, performRequest(t, r, "GET", productPath+"/history?order_by=version&order_direction=asc&page_size=1", nil), &history)
+			assert.Equal(t, int64(2), history.Total)
+			assert.Equal(t, 1, history.Data[0].Version)
+		})
+	}
+}
+
+func TestProductHistoryOfUnknownProduct(t *testing.T) {
+	r, _ := newTestRouter()
+
+	w := performRequest(t, r, "GET", "/product/64b7f0c2a1b2c3d4e5f60718/history", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
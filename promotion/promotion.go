@@ -0,0 +1,238 @@
+// Package promotion works out the discounts promotions give on the lines
+// of a transaction.
+//
+// Product promotions apply first, each to the lines of its products, then
+// cart promotions, each to what is left of the whole transaction. Every
+// promotion works on what the ones before it left, so a line is never
+// discounted below zero. A cart discount is shared out over the lines in
+// proportion to what is left of them, rounding so that the shares add up
+// to the discount exactly.
+//
+// Buy X get Y counts every line of a product together: two lines of one
+// unit each earn a free unit under buy 1 get 1, which goes to the first of
+// them.
+package promotion
+
+import (
+	"fmt"
+	"math"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/money"
+)
+
+// CurrencyError is returned when a fixed-amount promotion is in another
+// currency than the lines it would discount.
+type CurrencyError struct {
+	Promotion models.Promotion
+	Currency  string
+}
+
+func (e *CurrencyError) Error() string {
+	return fmt.Sprintf("promotion %q is in %s, not %s", e.Promotion.Name, e.Promotion.Amount.Currency(), e.Currency)
+}
+
+// Unwrap makes the error match money.ErrCurrencyMismatch.
+func (e *CurrencyError) Unwrap() error { return money.ErrCurrencyMismatch }
+
+// Line is a priced line of a transaction.
+type Line struct {
+	ProductID primitive.ObjectID
+	Quantity  float64
+	Price     money.Money
+	Amount    money.Money
+}
+
+// Discount is what one promotion took off: Amount in all, Lines per line.
+type Discount struct {
+	Promotion models.Promotion
+	Amount    money.Money
+	Lines     []money.Money
+}
+
+// Result is the discounts on a set of lines: the promotions that took
+// something off, in the order they applied, and the discount on every
+// line in all.
+type Result struct {
+	Discounts []Discount
+	Lines     []money.Money
+	Total     money.Money
+}
+
+// Apply applies promotions to lines, which must all be in currency, as
+// must fixed-amount promotions; one that is not fails with a
+// *CurrencyError. Promotions that take nothing off are left out of the
+// result; which of them apply at all is up to the caller.
+func Apply(currency string, lines []Line, promotions []models.Promotion) (*Result, error) {
+	result := &Result{Lines: make([]money.Money, len(lines)), Total: money.Zero(currency)}
+	remaining := make([]money.Money, len(lines))
+	for i, line := range lines {
+		if line.Amount.Currency() != currency || line.Price.Currency() != currency {
+			return nil, money.ErrCurrencyMismatch
+		}
+		result.Lines[i] = money.Zero(currency)
+		remaining[i] = line.Amount
+	}
+	for _, promotion := range promotions {
+		if promotion.Type == models.PromotionFixed && promotion.Amount.Currency() != currency {
+			return nil, &CurrencyError{Promotion: promotion, Currency: currency}
+		}
+	}
+
+	for _, scope := range []models.PromotionScope{models.ScopeProduct, models.ScopeCart} {
+		for _, promotion := range promotions {
+			if promotion.Scope != scope {
+				continue
+			}
+			var shares []money.Money
+			var err error
+			if scope == models.ScopeCart {
+				shares, err = cartDiscount(currency, remaining, promotion)
+			} else {
+				shares, err = productDiscount(currency, lines, remaining, promotion)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			discount := Discount{Promotion: promotion, Amount: money.Zero(currency), Lines: shares}
+			for i, share := range shares {
+				if remaining[i], err = remaining[i].Sub(share); err != nil {
+					return nil, err
+				}
+				if result.Lines[i], err = result.Lines[i].Add(share); err != nil {
+					return nil, err
+				}
+				if discount.Amount, err = discount.Amount.Add(share); err != nil {
+					return nil, err
+				}
+			}
+			if discount.Amount.IsZero() {
+				continue
+			}
+			if result.Total, err = result.Total.Add(discount.Amount); err != nil {
+				return nil, err
+			}
+			result.Discounts = append(result.Discounts, discount)
+		}
+	}
+	return result, nil
+}
+
+// productDiscount works out what promotion takes off each of the lines of
+// its products, capped at what is left of them.
+func productDiscount(currency string, lines []Line, remaining []money.Money, promotion models.Promotion) ([]money.Money, error) {
+	products := make(map[primitive.ObjectID]bool, len(promotion.ProductIDs))
+	for _, id := range promotion.ProductIDs {
+		products[id] = true
+	}
+
+	// The units each product gets free, over all of its lines
+	free := make(map[primitive.ObjectID]float64)
+	if promotion.Type == models.PromotionBuyXGetY && promotion.BuyQuantity > 0 && promotion.GetQuantity > 0 {
+		quantities := make(map[primitive.ObjectID]float64)
+		for _, line := range lines {
+			if products[line.ProductID] {
+				quantities[line.ProductID] += line.Quantity
+			}
+		}
+		for id, quantity := range quantities {
+			free[id] = math.Floor(quantity/(promotion.BuyQuantity+promotion.GetQuantity)) * promotion.GetQuantity
+		}
+	}
+
+	shares := make([]money.Money, len(lines))
+	for i, line := range lines {
+		shares[i] = money.Zero(currency)
+		if !products[line.ProductID] {
+			continue
+		}
+
+		var share money.Money
+		var err error
+		switch promotion.Type {
+		case models.PromotionPercentage:
+			share, err = remaining[i].MulRatio(promotion.Percent, 100)
+		case models.PromotionFixed:
+			share, err = promotion.Amount.Mul(line.Quantity)
+		case models.PromotionBuyXGetY:
+			units := math.Min(free[line.ProductID], line.Quantity)
+			free[line.ProductID] -= units
+			share, err = line.Price.Mul(units)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		shares[i] = capAt(share, remaining[i])
+	}
+	return shares, nil
+}
+
+// cartDiscount works out what promotion takes off what is left of the
+// transaction and shares it out over the lines.
+func cartDiscount(currency string, remaining []money.Money, promotion models.Promotion) ([]money.Money, error) {
+	base := money.Zero(currency)
+	for _, amount := range remaining {
+		var err error
+		if base, err = base.Add(amount); err != nil {
+			return nil, err
+		}
+	}
+
+	var discount money.Money
+	var err error
+	switch promotion.Type {
+	case models.PromotionPercentage:
+		discount, err = base.MulRatio(promotion.Percent, 100)
+	case models.PromotionFixed:
+		discount = capAt(promotion.Amount, base)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return spread(currency, discount, remaining, base)
+}
+
+// spread splits discount over the lines in proportion to amounts, which add
+// up to base. Each line takes the rounded share of the running total less
+// that of the lines before it, so the shares add up to discount and none
+// exceeds its line's amount.
+func spread(currency string, discount money.Money, amounts []money.Money, base money.Money) ([]money.Money, error) {
+	shares := make([]money.Money, len(amounts))
+	before := money.Zero(currency)
+	running := money.Zero(currency)
+	for i, amount := range amounts {
+		shares[i] = money.Zero(currency)
+		if discount.Currency() != currency || discount.IsZero() || base.Sign() <= 0 {
+			continue
+		}
+		var err error
+		if running, err = running.Add(amount); err != nil {
+			return nil, err
+		}
+		after, err := discount.MulRatio(float64(running.Minor()), float64(base.Minor()))
+		if err != nil {
+			return nil, err
+		}
+		if shares[i], err = after.Sub(before); err != nil {
+			return nil, err
+		}
+		before = after
+	}
+	return shares, nil
+}
+
+// capAt returns amount kept between zero and limit.
+func capAt(amount, limit money.Money) money.Money {
+	if amount.Minor() > limit.Minor() {
+		return limit
+	}
+	if amount.Sign() < 0 {
+		return money.Zero(limit.Currency())
+	}
+	return amount
+}
@@ -0,0 +1,56 @@
+package promotion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mifaabiyyu/go-test.git/money"
+)
+
+func TestSpread(t *testing.T) {
+	sen := func(minors ...int64) []money.Money {
+		amounts := make([]money.Money, len(minors))
+		for i, minor := range minors {
+			amounts[i] = money.New(minor, "IDR")
+		}
+		return amounts
+	}
+
+	tests := []struct {
+		name     string
+		discount int64
+		amounts  []money.Money
+		want     []money.Money
+	}{
+		{"proportional", 500, sen(2000, 3000), sen(200, 300)},
+		{"thirds", 100, sen(100, 100, 100), sen(33, 34, 33)},
+		{"rounding halves", 1, sen(1, 1), sen(1, 0)},
+		{"uneven", 1000, sen(2160, 3000), sen(419, 581)},
+		{"whole amount", 7, sen(3, 4), sen(3, 4)},
+		{"zero line", 10, sen(0, 5, 5), sen(0, 5, 5)},
+		{"nothing left", 10, sen(0, 0), sen(0, 0)},
+		{"no discount", 0, sen(3, 4), sen(0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, err := money.Sum("IDR", tt.amounts...)
+			if !assert.NoError(t, err) {
+				return
+			}
+			shares, err := spread("IDR", money.New(tt.discount, "IDR"), tt.amounts, base)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, shares)
+
+			total, err := money.Sum("IDR", shares...)
+			if assert.NoError(t, err) && base.Sign() > 0 {
+				assert.Equal(t, money.New(tt.discount, "IDR"), total)
+			}
+			for i, share := range shares {
+				assert.LessOrEqual(t, share.Minor(), tt.amounts[i].Minor())
+			}
+		})
+	}
+}
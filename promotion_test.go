@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/controllers"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/money"
+	"github.com/mifaabiyyu/go-test.git/promotion"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// createPromotion stores promotion through the API and returns it as stored.
+func createPromotion(t *testing.T, r *gin.Engine, promotion models.Promotion) models.Promotion {
+	t.Helper()
+	w := performRequest(t, r, "POST", "/promotion", promotion)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Failed to create promotion %q: %s", promotion.Name, w.Body.String())
+	}
+	var created models.Promotion
+	decodeBody(t, w, &created)
+	return created
+}
+
+func TestPromotionApply(t *testing.T) {
+	kopi, teh := primitive.NewObjectID(), primitive.NewObjectID()
+	lines := []promotion.Line{
+		{ProductID: kopi, Quantity: 2, Price: idr(12000), Amount: idr(24000)},
+		{ProductID: teh, Quantity: 3, Price: idr(15000), Amount: idr(45000)},
+	}
+	promotions := []models.Promotion{
+		// Cart promotions apply after product ones, whatever their order
+		{ID: primitive.NewObjectID(), Name: "Hemat", Type: models.PromotionFixed, Scope: models.ScopeCart, Amount: idr(10000)},
+		{ID: primitive.NewObjectID(), Name: "Kopi 10%", Type: models.PromotionPercentage, Scope: models.ScopeProduct, ProductIDs: []primitive.ObjectID{kopi}, Percent: 10},
+		{ID: primitive.NewObjectID(), Name: "Teh 2+1", Type: models.PromotionBuyXGetY, Scope: models.ScopeProduct, ProductIDs: []primitive.ObjectID{teh}, BuyQuantity: 2, GetQuantity: 1},
+		// Nothing of it is in the cart
+		{ID: primitive.NewObjectID(), Name: "Roti", Type: models.PromotionPercentage, Scope: models.ScopeProduct, ProductIDs: []primitive.ObjectID{primitive.NewObjectID()}, Percent: 50},
+	}
+
+	result, err := promotion.Apply("IDR", lines, promotions)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if assert.Len(t, result.Discounts, 3) {
+		assert.Equal(t, "Kopi 10%", result.Discounts[0].Promotion.Name)
+		assert.Equal(t, idr(2400), result.Discounts[0].Amount)
+		assert.Equal(t, "Teh 2+1", result.Discounts[1].Promotion.Name)
+		assert.Equal(t, idr(15000), result.Discounts[1].Amount)
+		// 10000 shared over the 21600 and 30000 left of the lines
+		assert.Equal(t, "Hemat", result.Discounts[2].Promotion.Name)
+		assert.Equal(t, []money.Money{money.MustParse("4186.05", "IDR"), money.MustParse("5813.95", "IDR")}, result.Discounts[2].Lines)
+	}
+	assert.Equal(t, []money.Money{money.MustParse("6586.05", "IDR"), money.MustParse("20813.95", "IDR")}, result.Lines)
+	assert.Equal(t, idr(27400), result.Total)
+}
+
+func TestPromotionApplyCapsDiscounts(t *testing.T) {
+	kopi := primitive.NewObjectID()
+	lines := []promotion.Line{{ProductID: kopi, Quantity: 1, Price: idr(12000), Amount: idr(12000)}}
+	promotions := []models.Promotion{
+		{Name: "Potong 20000", Type: models.PromotionFixed, Scope: models.ScopeProduct, ProductIDs: []primitive.ObjectID{kopi}, Amount: idr(20000)},
+		{Name: "Diskon 50%", Type: models.PromotionPercentage, Scope: models.ScopeCart, Percent: 50},
+	}
+
+	// A line is never discounted below zero, and the promotions that find
+	// nothing left take nothing off
+	result, err := promotion.Apply("IDR", lines, promotions)
+	if assert.NoError(t, err) && assert.Len(t, result.Discounts, 1) {
+		assert.Equal(t, idr(12000), result.Discounts[0].Amount)
+		assert.Equal(t, idr(12000), result.Total)
+	}
+
+	// A fixed amount in another currency is an error, not a discount of nothing
+	dollar := models.Promotion{Name: "Dollar", Type: models.PromotionFixed, Scope: models.ScopeCart, Amount: money.New(500, "USD")}
+	_, err = promotion.Apply("IDR", lines, append(promotions, dollar))
+	var currencyErr *promotion.CurrencyError
+	if assert.ErrorAs(t, err, &currencyErr) {
+		assert.Equal(t, "Dollar", currencyErr.Promotion.Name)
+		assert.Equal(t, "IDR", currencyErr.Currency)
+	}
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+}
+
+func TestPromotionApplyBuyXGetYAcrossLines(t *testing.T) {
+	teh := primitive.NewObjectID()
+	promotions := []models.Promotion{
+		{Name: "Teh 2+1", Type: models.PromotionBuyXGetY, Scope: models.ScopeProduct, ProductIDs: []primitive.ObjectID{teh}, BuyQuantity: 2, GetQuantity: 1},
+	}
+
+	// Neither line reaches three units, but together they make two sets,
+	// whose free units go to the lines in order
+	lines := []promotion.Line{
+		{ProductID: teh, Quantity: 1, Price: idr(15000), Amount: idr(15000)},
+		{ProductID: teh, Quantity: 2, Price: idr(15000), Amount: idr(30000)},
+		{ProductID: primitive.NewObjectID(), Quantity: 5, Price: idr(12000), Amount: idr(60000)},
+		{ProductID: teh, Quantity: 3, Price: idr(15000), Amount: idr(45000)},
+	}
+	result, err := promotion.Apply("IDR", lines, promotions)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []money.Money{idr(15000), idr(15000), idr(0), idr(0)}, result.Lines)
+		assert.Equal(t, idr(30000), result.Total)
+	}
+}
+
+func TestPromotionValidation(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	w := performRequest(t, r, "POST", "/promotion", models.Promotion{Name: "Kosong", Type: models.PromotionPercentage, Scope: models.ScopeCart})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	body := decodeError(t, w)
+	assert.Equal(t, "percent must be greater than 0", body.Message)
+
+	w = performRequest(t, r, "POST", "/promotion", models.Promotion{Name: "Gratis", Type: models.PromotionBuyXGetY, Scope: models.ScopeCart})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, map[string]string{
+		"scope":        "must be product for buy_x_get_y promotions",
+		"buy_quantity": "must be greater than 0",
+		"get_quantity": "must be greater than 0",
+	}, fieldErrors(decodeError(t, w)))
+
+	starts := time.Now()
+	ends := starts.Add(-time.Hour)
+	w = performRequest(t, r, "POST", "/promotion", models.Promotion{Name: "Mundur", Type: models.PromotionPercentage, Scope: models.ScopeCart, Percent: 5, StartsAt: &starts, EndsAt: &ends})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, map[string]string{"ends_at": "must be after starts_at"}, fieldErrors(decodeError(t, w)))
+
+	missing := primitive.NewObjectID()
+	w = performRequest(t, r, "POST", "/promotion", models.Promotion{Name: "Hilang", Type: models.PromotionPercentage, Scope: models.ScopeProduct, Percent: 5, ProductIDs: []primitive.ObjectID{missing}})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var references []controllers.BrokenReference
+	decodeErrorDetail(t, w, "references", &references)
+	assert.Equal(t, []controllers.BrokenReference{{Field: "product_ids[0]", ID: missing, Reason: controllers.ReferenceNotFound}}, references)
+
+	// Codes are stored in capitals and unique regardless of case
+	created := createPromotion(t, r, models.Promotion{Name: "Hemat", Code: " hemat ", Type: models.PromotionPercentage, Scope: models.ScopeCart, Percent: 10, IsActive: true, UsageCount: 99})
+	assert.Equal(t, "HEMAT", created.Code)
+	assert.Equal(t, 0, created.UsageCount)
+	w = performRequest(t, r, "POST", "/promotion", models.Promotion{Name: "Hemat lagi", Code: "Hemat", Type: models.PromotionFixed, Scope: models.ScopeProduct, Amount: idr(1000), ProductIDs: []primitive.ObjectID{cat.Products[0].ID}})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var list controllers.ListResponse[models.Promotion]
+	decodeBody(t, performRequest(t, r, "GET", "/promotions?code=HEMAT", nil), &list)
+	if assert.Len(t, list.Data, 1) {
+		assert.Equal(t, created.ID, list.Data[0].ID)
+	}
+
+	// Deleted promotions are hidden until restored
+	promotionPath := "/promotion/" + created.ID.Hex()
+	assert.Equal(t, http.StatusOK, performRequest(t, r, "DELETE", promotionPath, nil).Code)
+	assert.Equal(t, http.StatusNotFound, performRequest(t, r, "GET", promotionPath, nil).Code)
+	assert.Equal(t, http.StatusOK, performRequest(t, r, "POST", promotionPath+"/restore", nil).Code)
+	assert.Equal(t, http.StatusOK, performRequest(t, r, "GET", promotionPath, nil).Code)
+}
+
+func TestTransactionPromotions(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Tax = ppn()
+			r, repos := backend.router(t, cfg)
+			cat := seedCatalogue(t, repos)
+
+			automatic := createPromotion(t, r, models.Promotion{Name: "Kopi 10%", Type: models.PromotionPercentage, Scope: models.ScopeProduct, ProductIDs: []primitive.ObjectID{cat.Products[0].ID}, Percent: 10, IsActive: true})
+			coupon := createPromotion(t, r, models.Promotion{Name: "Hemat", Code: "HEMAT", Type: models.PromotionFixed, Scope: models.ScopeCart, Amount: idr(5000), IsActive: true})
+			// Inactive promotions never apply, nor fixed amounts in another currency
+			createPromotion(t, r, models.Promotion{Name: "Teh 50%", Type: models.PromotionPercentage, Scope: models.ScopeCart, Percent: 50})
+			dollar := createPromotion(t, r, models.Promotion{Name: "Dollar", Type: models.PromotionFixed, Scope: models.ScopeCart, Amount: money.New(500, "USD"), IsActive: true})
+
+			request := newTransactionRequest(cat)
+			request.Transaction.CouponCode = "hemat"
+			w := performRequest(t, r, "POST", "/transaction", request)
+			assert.Equal(t, http.StatusCreated, w.Code)
+			var response controllers.CreateTransactionResponse
+			decodeBody(t, w, &response)
+
+			transaction := response.Transaction
+			assert.Equal(t, "HEMAT", transaction.CouponCode)
+			assert.Equal(t, idr(7400), transaction.DiscountAmount)
+			assert.Equal(t, []models.AppliedDiscount{
+				{PromotionID: automatic.ID, Name: "Kopi 10%", Amount: idr(2400)},
+				{PromotionID: coupon.ID, Name: "Hemat", Code: "HEMAT", Amount: idr(5000)},
+			}, transaction.Discounts)
+			if assert.Len(t, transaction.Details, 2) {
+				// 5000 off the cart is shared over the 21600 and 30000 left
+				kopi := transaction.Details[0]
+				assert.Equal(t, idr(24000), kopi.Subtotal)
+				assert.Equal(t, money.MustParse("4493.02", "IDR"), kopi.DiscountAmount)
+				assert.Equal(t, []models.AppliedDiscount{
+					{PromotionID: automatic.ID, Name: "Kopi 10%", Amount: idr(2400)},
+					{PromotionID: coupon.ID, Name: "Hemat", Code: "HEMAT", Amount: money.MustParse("2093.02", "IDR")},
+				}, kopi.Discounts)
+				// Tax is charged on what is left after the discounts
+				assert.Equal(t, money.MustParse("19506.98", "IDR"), kopi.TaxableAmount)
+				assert.Equal(t, money.MustParse("2145.77", "IDR"), kopi.TaxAmount)
+				assert.Equal(t, money.MustParse("2906.98", "IDR"), transaction.Details[1].DiscountAmount)
+			}
+			assert.Equal(t, idr(46600), transaction.Subtotal)
+			assert.Equal(t, idr(5126), transaction.TaxAmount)
+			assert.Equal(t, idr(51726), transaction.TotalAmount)
+
+			promotionUses := func(p models.Promotion) int {
+				var stored models.Promotion
+				decodeBody(t, performRequest(t, r, "GET", "/promotion/"+p.ID.Hex(), nil), &stored)
+				return stored.UsageCount
+			}
+			assert.Equal(t, 1, promotionUses(automatic))
+			assert.Equal(t, 1, promotionUses(coupon))
+			assert.Equal(t, 0, promotionUses(dollar))
+
+			// Dropping the coupon gives its use back
+			transactionPath := "/transaction/" + transaction.ID.Hex()
+			w = performRequest(t, r, "PUT", transactionPath, controllers.TransactionUpdate{
+				Transaction: models.Transaction{CustomerID: cat.Customer.ID},
+				Details:     []models.TransactionDetail{{ProductID: cat.Products[0].ID, Quantity: 1}},
+			})
+			assert.Equal(t, http.StatusOK, w.Code)
+			transaction = models.Transaction{}
+			decodeBody(t, performRequest(t, r, "GET", transactionPath, nil), &transaction)
+			assert.Empty(t, transaction.CouponCode)
+			assert.Equal(t, idr(1200), transaction.DiscountAmount)
+			assert.Equal(t, idr(10800), transaction.Subtotal)
+			assert.Equal(t, 1, promotionUses(automatic))
+			assert.Equal(t, 0, promotionUses(coupon))
+
+			// Deleting the transaction gives back the rest
+			assert.Equal(t, http.StatusOK, performRequest(t, r, "DELETE", transactionPath, nil).Code)
+			assert.Equal(t, 0, promotionUses(automatic))
+		})
+	}
+}
+
+func TestTransactionCouponRejected(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	createPromotion(t, r, models.Promotion{Name: "Lama", Code: "LAMA", Type: models.PromotionPercentage, Scope: models.ScopeCart, Percent: 10, IsActive: true, EndsAt: &past})
+	createPromotion(t, r, models.Promotion{Name: "Besok", Code: "BESOK", Type: models.PromotionPercentage, Scope: models.ScopeCart, Percent: 10, IsActive: true, StartsAt: &future})
+	createPromotion(t, r, models.Promotion{Name: "Mati", Code: "MATI", Type: models.PromotionPercentage, Scope: models.ScopeCart, Percent: 10})
+	createPromotion(t, r, models.Promotion{Name: "Sekali", Code: "SEKALI", Type: models.PromotionPercentage, Scope: models.ScopeCart, Percent: 10, IsActive: true, UsageLimit: 1})
+	createPromotion(t, r, models.Promotion{Name: "Roti", Code: "ROTI", Type: models.PromotionPercentage, Scope: models.ScopeProduct, Percent: 10, IsActive: true, ProductIDs: []primitive.ObjectID{cat.Products[0].ID}})
+	createPromotion(t, r, models.Promotion{Name: "Dollar", Code: "DOLLAR", Type: models.PromotionFixed, Scope: models.ScopeCart, Amount: money.New(500, "USD"), IsActive: true})
+
+	withCoupon := func(code string, details ...models.TransactionDetail) transactionRequest {
+		request := newTransactionRequest(cat)
+		request.Transaction.CouponCode = code
+		if len(details) > 0 {
+			request.Details = details
+		}
+		return request
+	}
+
+	assert.Equal(t, http.StatusCreated, performRequest(t, r, "POST", "/transaction", withCoupon("sekali")).Code)
+
+	for code, reason := range map[string]string{
+		"NONE":   "not_found",
+		"LAMA":   "expired",
+		"BESOK":  "not_started",
+		"MATI":   "inactive",
+		"SEKALI": "usage_limit_reached",
+		"DOLLAR": "currency_mismatch",
+	} {
+		w := performRequest(t, r, "POST", "/transaction", withCoupon(code))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, code)
+		body := decodeError(t, w)
+		assert.Equal(t, "coupon_invalid", body.Code, code)
+		assert.Equal(t, reason, body.Details["reason"], code)
+		assert.Contains(t, fieldErrors(body), "transaction.coupon_code", code)
+	}
+
+	// A coupon for products the transaction does not have takes nothing off
+	w := performRequest(t, r, "POST", "/transaction", withCoupon("ROTI", models.TransactionDetail{ProductID: cat.Products[1].ID, Quantity: 1}))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "not_applicable", decodeError(t, w).Details["reason"])
+
+	// Rejected transactions take no stock
+	product, err := repos.Products.FindByID(context.Background(), cat.Products[1].ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 98.0, product.StockQuantity)
+	}
+}
+
+// TestBuyXGetYCoupon applies a buy_x_get_y coupon and checks the discount
+// and usage count that are stored.
+func TestBuyXGetYCoupon(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			r, repos := backend.router(t, testConfig())
+			cat := seedCatalogue(t, repos)
+
+			coupon := createPromotion(t, r, models.Promotion{Name: "Teh 1+1", Code: "TEH", Type: models.PromotionBuyXGetY, Scope: models.ScopeProduct, ProductIDs: []primitive.ObjectID{cat.Products[1].ID}, BuyQuantity: 1, GetQuantity: 1, IsActive: true, UsageLimit: 5})
+
+			request := newTransactionRequest(cat)
+			request.Transaction.CouponCode = "teh"
+			var response controllers.CreateTransactionResponse
+			decodeBody(t, performRequest(t, r, "POST", "/transaction", request), &response)
+			transactionPath := "/transaction/" + response.Transaction.ID.Hex()
+
+			var transaction models.Transaction
+			decodeBody(t, performRequest(t, r, "GET", transactionPath, nil), &transaction)
+			assert.Equal(t, "TEH", transaction.CouponCode)
+			assert.Equal(t, idr(15000), transaction.DiscountAmount)
+			assert.Equal(t, []models.AppliedDiscount{{PromotionID: coupon.ID, Name: "Teh 1+1", Code: "TEH", Amount: idr(15000)}}, transaction.Discounts)
+			assert.Equal(t, idr(39000), transaction.TotalAmount)
+			if assert.Len(t, transaction.Details, 2) {
+				assert.Equal(t, idr(0), transaction.Details[0].DiscountAmount)
+				assert.Empty(t, transaction.Details[0].Discounts)
+				assert.Equal(t, idr(15000), transaction.Details[1].DiscountAmount)
+				assert.Len(t, transaction.Details[1].Discounts, 1)
+			}
+
+			var stored models.Promotion
+			decodeBody(t, performRequest(t, r, "GET", "/promotion/"+coupon.ID.Hex(), nil), &stored)
+			assert.Equal(t, 1, stored.UsageCount)
+
+			// Editing the promotion keeps its usage count
+			w := performRequest(t, r, "PATCH", "/promotion/"+coupon.ID.Hex(), map[string]interface{}{"usage_limit": 10, "usage_count": 0})
+			assert.Equal(t, http.StatusOK, w.Code)
+			decodeBody(t, w, &stored)
+			assert.Equal(t, 10, stored.UsageLimit)
+			assert.Equal(t, 1, stored.UsageCount)
+		})
+	}
+}
+
+// TestPromotionCodesUnique checks that the backends themselves refuse a
+// coupon code that is taken, deleted or not, and accept any number of
+// promotions without one.
+func TestPromotionCodesUnique(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			_, repos := backend.router(t, testConfig())
+			promotions := repos.Promotions
+			ctx := context.Background()
+			newPromotion := func(code string) *models.Promotion {
+				return &models.Promotion{ID: primitive.NewObjectID(), Name: "Promo " + code, Code: code, Type: models.PromotionPercentage, Scope: models.ScopeCart, Percent: 5}
+			}
+
+			first := newPromotion("HEMAT")
+			assert.NoError(t, promotions.Create(ctx, first))
+			assert.Equal(t, repository.ErrDuplicate, promotions.Create(ctx, newPromotion("HEMAT")))
+			assert.NoError(t, promotions.Create(ctx, newPromotion("")))
+			assert.NoError(t, promotions.Create(ctx, newPromotion("")))
+
+			second := newPromotion("MURAH")
+			assert.NoError(t, promotions.Create(ctx, second))
+			second.Code = "HEMAT"
+			assert.Equal(t, repository.ErrDuplicate, promotions.Update(ctx, second))
+
+			assert.NoError(t, promotions.Delete(ctx, first.ID))
+			assert.Equal(t, repository.ErrDuplicate, promotions.Create(ctx, newPromotion("HEMAT")))
+			assert.NoError(t, promotions.Restore(ctx, first.ID))
+
+			found, err := promotions.FindByCode(ctx, "HEMAT")
+			if assert.NoError(t, err) {
+				assert.Equal(t, first.ID, found.ID)
+			}
+		})
+	}
+}
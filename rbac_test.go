@@ -0,0 +1,146 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/auth"
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+// samplePath fills the parameters of a route path with an ID that does not
+// exist, which is enough to tell a 403 from a handler's own response.
+func samplePath(path string) string {
+	const id = "64b7f0c2a1b2c3d4e5f60718"
+	return strings.NewReplacer(":id", id, ":pid", id).Replace(path)
+}
+
+func TestRolePermissions(t *testing.T) {
+	r, repos := newAuthRouter(t, config.Default())
+	keys := map[models.Role]string{}
+	for _, role := range []models.Role{models.RoleAdmin, models.RoleAccountant, models.RoleCashier, models.RoleViewer} {
+		keys[role] = issueTestKey(t, repos, string(role), role)
+	}
+
+	cases := []struct {
+		role    models.Role
+		method  string
+		path    string
+		allowed bool
+	}{
+		{models.RoleCashier, "POST", "/transaction", true},
+		{models.RoleCashier, "DELETE", "/transaction/:id", false},
+		{models.RoleCashier, "PUT", "/transaction/:id", false},
+		{models.RoleCashier, "POST", "/product", false},
+		{models.RoleCashier, "PUT", "/product/:id", false},
+		{models.RoleCashier, "POST", "/customers", true},
+		{models.RoleCashier, "POST", "/transaction/:id/payments/:pid/capture", true},
+		{models.RoleCashier, "POST", "/transaction/:id/payments/:pid/refund", false},
+		{models.RoleCashier, "GET", "/api-keys", false},
+
+		{models.RoleAccountant, "PUT", "/transaction/:id", true},
+		{models.RoleAccountant, "DELETE", "/transaction/:id", false},
+		{models.RoleAccountant, "POST", "/transaction", false},
+		{models.RoleAccountant, "POST", "/transaction/:id/payments/:pid/refund", true},
+		{models.RoleAccountant, "POST", "/payment-method", true},
+		{models.RoleAccountant, "POST", "/product/:id/adjustments", true},
+		{models.RoleAccountant, "POST", "/product/:id/receipts", false},
+		{models.RoleAccountant, "GET", "/audit", true},
+		{models.RoleCashier, "GET", "/audit", false},
+		{models.RoleAccountant, "POST", "/exchange-rates", true},
+		{models.RoleCashier, "POST", "/exchange-rates", false},
+		{models.RoleCashier, "GET", "/exchange-rates", true},
+		{models.RoleAccountant, "GET", "/reports/sales", true},
+		{models.RoleCashier, "GET", "/reports/sales", false},
+
+		{models.RoleViewer, "GET", "/transactions", true},
+		{models.RoleViewer, "GET", "/product/:id/history", true},
+		{models.RoleViewer, "POST", "/customers", false},
+		{models.RoleViewer, "POST", "/transaction", false},
+
+		{models.RoleAdmin, "DELETE", "/transaction/:id", true},
+		{models.RoleAdmin, "POST", "/api-keys", true},
+	}
+	for _, tc := range cases {
+		w := performRequestWithHeader(t, r, tc.method, samplePath(tc.path), nil, auth.APIKeyHeader, keys[tc.role])
+		name := string(tc.role) + " " + tc.method + " " + tc.path
+		if tc.allowed {
+			assert.NotContains(t, []int{http.StatusUnauthorized, http.StatusForbidden}, w.Code, name)
+		} else {
+			assert.Equal(t, http.StatusForbidden, w.Code, name)
+		}
+	}
+}
+
+// TestEveryRouteHasPermissions checks the permission table as a whole:
+// admins reach every route and viewers can change nothing, nor read keys,
+// the audit log or reports.
+func TestEveryRouteHasPermissions(t *testing.T) {
+	r, repos := newAuthRouter(t, config.Default())
+	admin := issueTestKey(t, repos, "admin", models.RoleAdmin)
+	viewer := issueTestKey(t, repos, "viewer", models.RoleViewer)
+
+	for _, info := range r.Routes() {
+		name := info.Method + " " + info.Path
+		w := performRequestWithHeader(t, r, info.Method, samplePath(info.Path), nil, auth.APIKeyHeader, admin)
+		assert.NotEqual(t, http.StatusForbidden, w.Code, name)
+
+		w = performRequestWithHeader(t, r, info.Method, samplePath(info.Path), nil, auth.APIKeyHeader, viewer)
+		if info.Method == "GET" && info.Path != "/api-keys" && info.Path != "/audit" && info.Path != "/reports/sales" {
+			assert.NotEqual(t, http.StatusForbidden, w.Code, name)
+		} else {
+			assert.Equal(t, http.StatusForbidden, w.Code, name)
+		}
+	}
+}
+
+func TestForbiddenResponse(t *testing.T) {
+	r, repos := newAuthRouter(t, config.Default())
+	cashier := issueTestKey(t, repos, "till-1", models.RoleCashier)
+
+	w := performRequestWithHeader(t, r, "DELETE", samplePath("/transaction/:id"), nil, auth.APIKeyHeader, cashier)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	body := decodeError(t, w)
+	assert.Equal(t, apperror.CodeForbidden, body.Code)
+	var required []string
+	decodeErrorDetail(t, w, "required_roles", &required)
+	assert.Equal(t, []string{"admin"}, required)
+}
+
+func TestCredentialsWithoutKnownRolesGrantNothing(t *testing.T) {
+	cfg := hs256Config()
+	r, repos := newAuthRouter(t, cfg)
+
+	noRoles := issueTestKey(t, repos, "legacy")
+	w := performRequestWithHeader(t, r, "GET", "/products", nil, auth.APIKeyHeader, noRoles)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	jwt, err := auth.NewJWT(cfg.Auth.JWT)
+	assert.NoError(t, err)
+	token, err := jwt.Sign(auth.Claims{Subject: "dashboard", Issuer: "go-test", Audience: auth.Audience{"api"}, ExpiresAt: time.Now().Add(time.Hour).Unix(), Roles: []string{"superuser"}})
+	assert.NoError(t, err)
+	w = performRequestWithHeader(t, r, "GET", "/products", nil, "Authorization", bearer(token))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	token, err = jwt.Issue("dashboard", []models.Role{models.RoleCashier}, time.Now())
+	assert.NoError(t, err)
+	w = performRequestWithHeader(t, r, "POST", "/product", models.Product{Code: "P001", Name: "Kopi", Price: idr(12000)}, "Authorization", bearer(token))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAPIKeysRequireKnownRoles(t *testing.T) {
+	r, repos := newAuthRouter(t, config.Default())
+	admin := issueTestKey(t, repos, "admin", models.RoleAdmin)
+
+	for _, roles := range [][]string{nil, {}, {"superuser"}} {
+		w := performRequestWithHeader(t, r, "POST", "/api-keys", map[string]interface{}{"name": "till-2", "roles": roles}, auth.APIKeyHeader, admin)
+		assert.Equal(t, http.StatusBadRequest, w.Code, roles)
+	}
+}
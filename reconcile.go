@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// reconcilePageSize is how many products reconcileStock loads at a time.
+const reconcilePageSize = 500
+
+// stockDrift is a product whose stock quantity disagrees with the sum of
+// its stock ledger.
+type stockDrift struct {
+	ProductID primitive.ObjectID
+	Code      string
+	Stock     float64
+	Ledger    float64
+}
+
+// reconcileStock recomputes the stock of every product from the stock
+// ledger and returns the products whose stock quantity has drifted from
+// it, along with the number of products checked.
+func reconcileStock(ctx context.Context, repos *repository.Repositories) ([]stockDrift, int, error) {
+	ledger, err := repos.StockMovements.SumByProduct(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var drifts []stockDrift
+	checked := 0
+	query := repository.ListQuery{Limit: reconcilePageSize, IncludeDeleted: true}
+	for {
+		page, err := repos.Products.List(ctx, query)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, product := range page.Items {
+			checked++
+			// Tolerate the rounding of summed fractional quantities
+			if math.Abs(product.StockQuantity-ledger[product.ID]) > 1e-6 {
+				drifts = append(drifts, stockDrift{
+					ProductID: product.ID,
+					Code:      product.Code,
+					Stock:     product.StockQuantity,
+					Ledger:    ledger[product.ID],
+				})
+			}
+		}
+		if page.Next == nil {
+			return drifts, checked, nil
+		}
+		query.After = page.Next
+	}
+}
+
+// writeStockReport prints the result of reconcileStock.
+func writeStockReport(w io.Writer, drifts []stockDrift, checked int) error {
+	if len(drifts) > 0 {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "PRODUCT\tCODE\tSTOCK\tLEDGER\tDRIFT")
+		for _, d := range drifts {
+			fmt.Fprintf(tw, "%s\t%s\t%g\t%g\t%+g\n", d.ProductID.Hex(), d.Code, d.Stock, d.Ledger, d.Stock-d.Ledger)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d product(s) checked, %d drifted from the stock ledger\n", checked, len(drifts))
+	return err
+}
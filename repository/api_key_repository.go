@@ -0,0 +1,24 @@
+// repository/api_key_repository.go
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+// APIKeyRepository stores issued API keys. Revoked keys are kept so that
+// they stay listed.
+type APIKeyRepository interface {
+	// Create fails with ErrDuplicate if another key has the same hash.
+	Create(ctx context.Context, key *models.APIKey) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.APIKey, error)
+	FindByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	List(ctx context.Context, query ListQuery) (*Page[models.APIKey], error)
+	// Revoke marks the key as revoked at the given time. It fails with
+	// ErrNotFound when there is no unrevoked key with the ID.
+	Revoke(ctx context.Context, id primitive.ObjectID, at time.Time) error
+}
@@ -0,0 +1,15 @@
+// repository/audit_repository.go
+package repository
+
+import (
+	"context"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+// AuditRepository stores the audit log. Entries are only ever appended;
+// there is no update or delete.
+type AuditRepository interface {
+	Create(ctx context.Context, entry *models.AuditEntry) error
+	List(ctx context.Context, query ListQuery) (*Page[models.AuditEntry], error)
+}
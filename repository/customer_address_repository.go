@@ -0,0 +1,21 @@
+// repository/customer_address_repository.go
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+type CustomerAddressRepository interface {
+	Create(ctx context.Context, address *models.CustomerAddress) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.CustomerAddress, error)
+	List(ctx context.Context, query ListQuery) (*Page[models.CustomerAddress], error)
+	// ListByCustomers returns the addresses belonging to any of the given customers.
+	ListByCustomers(ctx context.Context, customerIDs ...primitive.ObjectID) ([]models.CustomerAddress, error)
+	Update(ctx context.Context, address *models.CustomerAddress) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	DeleteByCustomer(ctx context.Context, customerID primitive.ObjectID) error
+}
@@ -0,0 +1,22 @@
+// repository/customer_repository.go
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+type CustomerRepository interface {
+	Create(ctx context.Context, customer *models.Customer) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Customer, error)
+	FindByEmail(ctx context.Context, email string) (*models.Customer, error)
+	List(ctx context.Context, query ListQuery) (*Page[models.Customer], error)
+	Update(ctx context.Context, customer *models.Customer) error
+	// Delete soft-deletes the customer.
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// Restore undoes Delete.
+	Restore(ctx context.Context, id primitive.ObjectID) error
+}
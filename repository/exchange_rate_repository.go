@@ -0,0 +1,27 @@
+// repository/exchange_rate_repository.go
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+// ExchangeRateRepository stores the exchange-rate table. Rates are never
+// updated; a wrong one is deleted.
+type ExchangeRateRepository interface {
+	// Create fails with ErrDuplicate if the pair already has a rate
+	// taking effect at the same time.
+	Create(ctx context.Context, rate *models.ExchangeRate) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.ExchangeRate, error)
+	List(ctx context.Context, query ListQuery) (*Page[models.ExchangeRate], error)
+	// Effective returns the rate from one currency to another in effect at
+	// the given time: the one with the latest EffectiveFrom not after it.
+	// It fails with ErrNotFound when there is none. Rates quoted the other
+	// way round are not considered.
+	Effective(ctx context.Context, from, to string, at time.Time) (*models.ExchangeRate, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
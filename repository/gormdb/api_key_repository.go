@@ -0,0 +1,38 @@
+// repository/gormdb/api_key_repository.go
+package gormdb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	return create(conn(ctx, r.db), toAPIKeyRecord(key))
+}
+
+func (r *APIKeyRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.APIKey, error) {
+	return first[apiKeyRecord, models.APIKey](conn(ctx, r.db), "id = ?", id.Hex())
+}
+
+func (r *APIKeyRepository) FindByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return first[apiKeyRecord, models.APIKey](conn(ctx, r.db), "hash = ?", hash)
+}
+
+func (r *APIKeyRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.APIKey], error) {
+	return findPage[apiKeyRecord](conn(ctx, r.db), repository.APIKeyFields, query)
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	unrevoked := conn(ctx, r.db).Where("revoked_at IS NULL").Session(&gorm.Session{})
+	return update(unrevoked, &apiKeyRecord{}, id.Hex(), map[string]interface{}{"revoked_at": at})
+}
@@ -0,0 +1,23 @@
+// repository/gormdb/audit_repository.go
+package gormdb
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	return create(conn(ctx, r.db), toAuditEntryRecord(entry))
+}
+
+func (r *AuditRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.AuditEntry], error) {
+	return findPage[auditEntryRecord](conn(ctx, r.db), repository.AuditFields, query)
+}
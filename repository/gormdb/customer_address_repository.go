@@ -0,0 +1,47 @@
+// repository/gormdb/customer_address_repository.go
+package gormdb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type CustomerAddressRepository struct {
+	db *gorm.DB
+}
+
+func (r *CustomerAddressRepository) Create(ctx context.Context, address *models.CustomerAddress) error {
+	return create(conn(ctx, r.db), toCustomerAddressRecord(address))
+}
+
+func (r *CustomerAddressRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.CustomerAddress, error) {
+	return first[customerAddressRecord, models.CustomerAddress](conn(ctx, r.db), "id = ?", id.Hex())
+}
+
+func (r *CustomerAddressRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.CustomerAddress], error) {
+	return findPage[customerAddressRecord](conn(ctx, r.db), repository.CustomerAddressFields, query)
+}
+
+func (r *CustomerAddressRepository) ListByCustomers(ctx context.Context, customerIDs ...primitive.ObjectID) ([]models.CustomerAddress, error) {
+	if len(customerIDs) == 0 {
+		return nil, nil
+	}
+	return all[customerAddressRecord, models.CustomerAddress](conn(ctx, r.db).Where("customer_id IN ?", hexIDs(customerIDs)))
+}
+
+func (r *CustomerAddressRepository) Update(ctx context.Context, address *models.CustomerAddress) error {
+	return replace(conn(ctx, r.db), toCustomerAddressRecord(address), address.ID.Hex())
+}
+
+func (r *CustomerAddressRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return deleteByID(conn(ctx, r.db), &customerAddressRecord{}, id.Hex())
+}
+
+func (r *CustomerAddressRepository) DeleteByCustomer(ctx context.Context, customerID primitive.ObjectID) error {
+	return translateError(conn(ctx, r.db).Where("customer_id = ?", customerID.Hex()).Delete(&customerAddressRecord{}).Error)
+}
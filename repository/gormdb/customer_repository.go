@@ -0,0 +1,44 @@
+// repository/gormdb/customer_repository.go
+package gormdb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type CustomerRepository struct {
+	db *gorm.DB
+}
+
+func (r *CustomerRepository) Create(ctx context.Context, customer *models.Customer) error {
+	return create(conn(ctx, r.db), toCustomerRecord(customer))
+}
+
+func (r *CustomerRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Customer, error) {
+	return first[customerRecord, models.Customer](conn(ctx, r.db), "id = ?", id.Hex())
+}
+
+func (r *CustomerRepository) FindByEmail(ctx context.Context, email string) (*models.Customer, error) {
+	return first[customerRecord, models.Customer](conn(ctx, r.db), "email = ?", email)
+}
+
+func (r *CustomerRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.Customer], error) {
+	return findPage[customerRecord](conn(ctx, r.db), repository.CustomerFields, query)
+}
+
+func (r *CustomerRepository) Update(ctx context.Context, customer *models.Customer) error {
+	return replace(notDeleted(conn(ctx, r.db)), toCustomerRecord(customer), customer.ID.Hex())
+}
+
+func (r *CustomerRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return softDelete(conn(ctx, r.db), &customerRecord{}, id.Hex())
+}
+
+func (r *CustomerRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	return restore(conn(ctx, r.db), &customerRecord{}, id.Hex())
+}
@@ -0,0 +1,38 @@
+// repository/gormdb/exchange_rate_repository.go
+package gormdb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type ExchangeRateRepository struct {
+	db *gorm.DB
+}
+
+func (r *ExchangeRateRepository) Create(ctx context.Context, rate *models.ExchangeRate) error {
+	return create(conn(ctx, r.db), toExchangeRateRecord(rate))
+}
+
+func (r *ExchangeRateRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ExchangeRate, error) {
+	return first[exchangeRateRecord, models.ExchangeRate](conn(ctx, r.db), "id = ?", id.Hex())
+}
+
+func (r *ExchangeRateRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.ExchangeRate], error) {
+	return findPage[exchangeRateRecord](conn(ctx, r.db), repository.ExchangeRateFields, query)
+}
+
+func (r *ExchangeRateRepository) Effective(ctx context.Context, from, to string, at time.Time) (*models.ExchangeRate, error) {
+	latest := conn(ctx, r.db).Order("effective_from DESC")
+	return first[exchangeRateRecord, models.ExchangeRate](latest, "from_currency = ? AND to_currency = ? AND effective_from <= ?", from, to, at)
+}
+
+func (r *ExchangeRateRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return deleteByID(conn(ctx, r.db), &exchangeRateRecord{}, id.Hex())
+}
@@ -0,0 +1,328 @@
+// repository/gormdb/gormdb.go
+package gormdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	gomysql "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/money"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// MySQL error numbers for foreign key violations on write and on delete.
+const (
+	mysqlNoReferencedRow = 1452
+	mysqlRowIsReferenced = 1451
+)
+
+// Open connects to the relational database selected by cfg.Storage.Driver
+// and, unless disabled, migrates the schema.
+func Open(cfg *config.Config) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch cfg.Storage.Driver {
+	case config.DriverMySQL:
+		dialector = mysql.Open(cfg.SQL.DSN)
+	case config.DriverSQLite:
+		dialector = sqlite.Open(cfg.SQL.DSN)
+	default:
+		return nil, fmt.Errorf("gormdb: unsupported driver %q", cfg.Storage.Driver)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		TranslateError: true,
+		Logger: logger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), logger.Config{
+			SlowThreshold:             200 * time.Millisecond,
+			LogLevel:                  logger.Warn,
+			IgnoreRecordNotFoundError: true,
+		}),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Storage.Driver == config.DriverSQLite {
+		// SQLite allows a single writer and enables foreign keys per
+		// connection, so keep exactly one long-lived connection.
+		sqlDB.SetMaxOpenConns(1)
+		if err := db.Exec("PRAGMA foreign_keys = ON").Error; err != nil {
+			return nil, err
+		}
+	} else {
+		sqlDB.SetMaxOpenConns(cfg.SQL.MaxOpenConns)
+		sqlDB.SetMaxIdleConns(cfg.SQL.MaxIdleConns)
+		sqlDB.SetConnMaxLifetime(cfg.SQL.ConnMaxLifetime.Duration)
+	}
+
+	if cfg.SQL.AutoMigrate {
+		if err := Migrate(db); err != nil {
+			return nil, err
+		}
+	}
+
+	return db, nil
+}
+
+// Migrate creates or updates every table, including foreign keys.
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(allRecords...); err != nil {
+		return err
+	}
+	if err := migrateFloatAmounts(db); err != nil {
+		return err
+	}
+	if err := backfillCurrencies(db); err != nil {
+		return err
+	}
+	if err := backfillTaxes(db); err != nil {
+		return err
+	}
+	return backfillDiscounts(db)
+}
+
+// floatAmounts lists the columns that held amounts as floating point
+// numbers, in major units of the default currency, before amounts were
+// stored as minor units and a currency.
+var floatAmounts = []struct {
+	table  string
+	column string
+}{
+	{"products", "price"},
+	{"transactions", "total_amount"},
+	{"transaction_details", "subtotal"},
+	{"transaction_details", "price"},
+	{"transaction_payments", "paid_amount"},
+	{"product_versions", "price"},
+}
+
+// migrateFloatAmounts moves amounts out of the floating point columns of
+// a database created before Money into the minor unit and currency
+// columns, then drops the old columns. A column is only dropped once its
+// amounts are copied, so an interrupted migration picks up where it
+// stopped.
+func migrateFloatAmounts(db *gorm.DB) error {
+	exponent, _ := money.Exponent(money.DefaultCurrency)
+	for _, amount := range floatAmounts {
+		if !db.Migrator().HasColumn(amount.table, amount.column) {
+			continue
+		}
+		err := db.Table(amount.table).Session(&gorm.Session{AllowGlobalUpdate: true}).Updates(map[string]interface{}{
+			amount.column + "_minor":    gorm.Expr("ROUND(? * ?)", clause.Column{Name: amount.column}, math.Pow10(exponent)),
+			amount.column + "_currency": money.DefaultCurrency,
+		}).Error
+		if err != nil {
+			return err
+		}
+		// Both SQLite and MySQL drop a column in place; recreating the
+		// table would cascade deletes to the rows referring to it
+		err = db.Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: amount.table}, clause.Column{Name: amount.column}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillCurrencies fills in the currency of transactions, and the
+// settled amount and exchange rate of payments, stored before
+// transactions had a currency of their own: it is the currency of their
+// total, which their payments were always in.
+func backfillCurrencies(db *gorm.DB) error {
+	err := db.Model(&transactionRecord{}).Where("currency = ''").
+		Update("currency", gorm.Expr("total_amount_currency")).Error
+	if err != nil {
+		return err
+	}
+	return db.Model(&transactionPaymentRecord{}).Where("settled_amount_currency = ''").Updates(map[string]interface{}{
+		"settled_amount_minor":    gorm.Expr("paid_amount_minor"),
+		"settled_amount_currency": gorm.Expr("paid_amount_currency"),
+		"exchange_rate":           1,
+	}).Error
+}
+
+// backfillTaxes fills in the tax breakdown of transactions and details
+// stored before taxes were charged: untaxed, their subtotal and total are
+// the amounts they were sold for.
+func backfillTaxes(db *gorm.DB) error {
+	err := db.Model(&transactionRecord{}).Where("subtotal_currency = ''").Updates(map[string]interface{}{
+		"subtotal_minor":      gorm.Expr("total_amount_minor"),
+		"subtotal_currency":   gorm.Expr("total_amount_currency"),
+		"tax_amount_currency": gorm.Expr("total_amount_currency"),
+	}).Error
+	if err != nil {
+		return err
+	}
+	return db.Model(&transactionDetailRecord{}).Where("total_currency = ''").Updates(map[string]interface{}{
+		"taxable_amount_minor":    gorm.Expr("subtotal_minor"),
+		"taxable_amount_currency": gorm.Expr("subtotal_currency"),
+		"tax_amount_currency":     gorm.Expr("subtotal_currency"),
+		"total_minor":             gorm.Expr("subtotal_minor"),
+		"total_currency":          gorm.Expr("subtotal_currency"),
+	}).Error
+}
+
+// backfillDiscounts sets the discount of transactions and details stored
+// before promotions to nothing, in their currency.
+func backfillDiscounts(db *gorm.DB) error {
+	err := db.Model(&transactionRecord{}).Where("discount_amount_currency = ''").
+		Update("discount_amount_currency", gorm.Expr("total_amount_currency")).Error
+	if err != nil {
+		return err
+	}
+	return db.Model(&transactionDetailRecord{}).Where("discount_amount_currency = ''").
+		Update("discount_amount_currency", gorm.Expr("subtotal_currency")).Error
+}
+
+// NewRepositories returns GORM-backed implementations of every store.
+func NewRepositories(db *gorm.DB) *repository.Repositories {
+	return &repository.Repositories{
+		Customers:           &CustomerRepository{db: db},
+		CustomerAddresses:   &CustomerAddressRepository{db: db},
+		Products:            &ProductRepository{db: db},
+		PaymentMethods:      &PaymentMethodRepository{db: db},
+		Transactions:        &TransactionRepository{db: db},
+		TransactionDetails:  &TransactionDetailRepository{db: db},
+		TransactionPayments: &TransactionPaymentRepository{db: db},
+		StockMovements:      &StockMovementRepository{db: db},
+		ProductVersions:     &ProductVersionRepository{db: db},
+		ExchangeRates:       &ExchangeRateRepository{db: db},
+		Promotions:          &PromotionRepository{db: db},
+		APIKeys:             &APIKeyRepository{db: db},
+		AuditLog:            &AuditRepository{db: db},
+		UnitOfWork:          &UnitOfWork{db: db},
+	}
+}
+
+// conn returns the session every repository call runs on: the open
+// transaction when ctx carries one, otherwise db itself.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// translateError maps GORM and driver errors onto the repository sentinel errors.
+func translateError(err error) error {
+	var mysqlErr *gomysql.MySQLError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return repository.ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return repository.ErrDuplicate
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return repository.ErrForeignKey
+	case errors.As(err, &mysqlErr) && (mysqlErr.Number == mysqlNoReferencedRow || mysqlErr.Number == mysqlRowIsReferenced):
+		return repository.ErrForeignKey
+	default:
+		return err
+	}
+}
+
+// modeler is implemented by pointers to record types.
+type modeler[R any, M any] interface {
+	*R
+	model() M
+}
+
+func first[R any, M any, P modeler[R, M]](db *gorm.DB, query interface{}, args ...interface{}) (*M, error) {
+	var record R
+	if err := db.Where(query, args...).First(&record).Error; err != nil {
+		return nil, translateError(err)
+	}
+	m := P(&record).model()
+	return &m, nil
+}
+
+func all[R any, M any, P modeler[R, M]](db *gorm.DB) ([]M, error) {
+	var records []R
+	if err := db.Find(&records).Error; err != nil {
+		return nil, translateError(err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	result := make([]M, len(records))
+	for i := range records {
+		result[i] = P(&records[i]).model()
+	}
+	return result, nil
+}
+
+func create(db *gorm.DB, record interface{}) error {
+	return translateError(db.Omit(clause.Associations).Create(record).Error)
+}
+
+// replace overwrites every column of an existing row but the omitted ones.
+func replace(db *gorm.DB, record interface{}, id string, omit ...string) error {
+	var count int64
+	if err := db.Model(record).Where("id = ?", id).Count(&count).Error; err != nil {
+		return translateError(err)
+	}
+	if count == 0 {
+		return repository.ErrNotFound
+	}
+	// Unlike Save, Updates never falls back to an upsert that would write
+	// the omitted columns when MySQL reports no changed rows.
+	query := db.Model(record).Where("id = ?", id).Select("*").Omit(append(omit, clause.Associations)...)
+	return translateError(query.Updates(record).Error)
+}
+
+// update sets the given columns on an existing row.
+func update(db *gorm.DB, model interface{}, id string, columns map[string]interface{}) error {
+	var count int64
+	if err := db.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return translateError(err)
+	}
+	if count == 0 {
+		return repository.ErrNotFound
+	}
+	return translateError(db.Model(model).Where("id = ?", id).Updates(columns).Error)
+}
+
+// notDeleted scopes db to rows that are not soft-deleted. The result is a
+// new session, so the helpers above can build more than one query on it.
+func notDeleted(db *gorm.DB) *gorm.DB {
+	return db.Where("deleted_at IS NULL").Session(&gorm.Session{})
+}
+
+// softDelete marks a row as deleted.
+func softDelete(db *gorm.DB, model interface{}, id string) error {
+	return update(notDeleted(db), model, id, map[string]interface{}{"deleted_at": time.Now()})
+}
+
+// restore clears the deletion mark of a soft-deleted row.
+func restore(db *gorm.DB, model interface{}, id string) error {
+	deleted := db.Where("deleted_at IS NOT NULL").Session(&gorm.Session{})
+	return update(deleted, model, id, map[string]interface{}{"deleted_at": nil})
+}
+
+func deleteByID(db *gorm.DB, model interface{}, id string) error {
+	result := db.Where("id = ?", id).Delete(model)
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+// repository/gormdb/payment_method_repository.go
+package gormdb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type PaymentMethodRepository struct {
+	db *gorm.DB
+}
+
+func (r *PaymentMethodRepository) Create(ctx context.Context, paymentMethod *models.PaymentMethod) error {
+	return create(conn(ctx, r.db), toPaymentMethodRecord(paymentMethod))
+}
+
+func (r *PaymentMethodRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.PaymentMethod, error) {
+	return first[paymentMethodRecord, models.PaymentMethod](conn(ctx, r.db), "id = ?", id.Hex())
+}
+
+func (r *PaymentMethodRepository) FindByName(ctx context.Context, name string) (*models.PaymentMethod, error) {
+	return first[paymentMethodRecord, models.PaymentMethod](conn(ctx, r.db), "name = ?", name)
+}
+
+func (r *PaymentMethodRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.PaymentMethod], error) {
+	return findPage[paymentMethodRecord](conn(ctx, r.db), repository.PaymentMethodFields, query)
+}
+
+func (r *PaymentMethodRepository) ListByIDs(ctx context.Context, ids ...primitive.ObjectID) ([]models.PaymentMethod, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return all[paymentMethodRecord, models.PaymentMethod](conn(ctx, r.db).Where("id IN ?", hexIDs(ids)))
+}
+
+func (r *PaymentMethodRepository) Update(ctx context.Context, paymentMethod *models.PaymentMethod) error {
+	return replace(notDeleted(conn(ctx, r.db)), toPaymentMethodRecord(paymentMethod), paymentMethod.ID.Hex())
+}
+
+func (r *PaymentMethodRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return softDelete(conn(ctx, r.db), &paymentMethodRecord{}, id.Hex())
+}
+
+func (r *PaymentMethodRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	return restore(conn(ctx, r.db), &paymentMethodRecord{}, id.Hex())
+}
@@ -0,0 +1,76 @@
+// repository/gormdb/product_repository.go
+package gormdb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type ProductRepository struct {
+	db *gorm.DB
+}
+
+func (r *ProductRepository) Create(ctx context.Context, product *models.Product) error {
+	return create(conn(ctx, r.db), toProductRecord(product))
+}
+
+func (r *ProductRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Product, error) {
+	return first[productRecord, models.Product](conn(ctx, r.db), "id = ?", id.Hex())
+}
+
+func (r *ProductRepository) FindByCode(ctx context.Context, code string) (*models.Product, error) {
+	return first[productRecord, models.Product](conn(ctx, r.db), "code = ?", code)
+}
+
+func (r *ProductRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.Product], error) {
+	return findPage[productRecord](conn(ctx, r.db), repository.ProductFields, query)
+}
+
+func (r *ProductRepository) ListByIDs(ctx context.Context, ids ...primitive.ObjectID) ([]models.Product, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return all[productRecord, models.Product](conn(ctx, r.db).Where("id IN ?", hexIDs(ids)))
+}
+
+func (r *ProductRepository) Update(ctx context.Context, product *models.Product) error {
+	return replace(notDeleted(conn(ctx, r.db)), toProductRecord(product), product.ID.Hex(), "stock_quantity")
+}
+
+func (r *ProductRepository) AdjustStock(ctx context.Context, id primitive.ObjectID, delta float64) error {
+	db := conn(ctx, r.db)
+	query := db.Model(&productRecord{}).Where("id = ?", id.Hex())
+	if delta < 0 {
+		query = query.Where("stock_quantity - reserved_quantity >= ?", -delta)
+	}
+	result := query.UpdateColumn("stock_quantity", gorm.Expr("stock_quantity + ?", delta))
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	// Tell a missing product from one without enough stock
+	var count int64
+	if err := db.Model(&productRecord{}).Where("id = ?", id.Hex()).Count(&count).Error; err != nil {
+		return translateError(err)
+	}
+	if count == 0 {
+		return repository.ErrNotFound
+	}
+	return repository.ErrInsufficientStock
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return softDelete(conn(ctx, r.db), &productRecord{}, id.Hex())
+}
+
+func (r *ProductRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	return restore(conn(ctx, r.db), &productRecord{}, id.Hex())
+}
@@ -0,0 +1,23 @@
+// repository/gormdb/product_version_repository.go
+package gormdb
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type ProductVersionRepository struct {
+	db *gorm.DB
+}
+
+func (r *ProductVersionRepository) Create(ctx context.Context, version *models.ProductVersion) error {
+	return create(conn(ctx, r.db), toProductVersionRecord(version))
+}
+
+func (r *ProductVersionRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.ProductVersion], error) {
+	return findPage[productVersionRecord](conn(ctx, r.db), repository.ProductVersionFields, query)
+}
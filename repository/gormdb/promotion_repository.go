@@ -0,0 +1,78 @@
+// repository/gormdb/promotion_repository.go
+package gormdb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type PromotionRepository struct {
+	db *gorm.DB
+}
+
+func (r *PromotionRepository) Create(ctx context.Context, promotion *models.Promotion) error {
+	return create(conn(ctx, r.db), toPromotionRecord(promotion))
+}
+
+func (r *PromotionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Promotion, error) {
+	return first[promotionRecord, models.Promotion](conn(ctx, r.db), "id = ?", id.Hex())
+}
+
+func (r *PromotionRepository) FindByCode(ctx context.Context, code string) (*models.Promotion, error) {
+	return first[promotionRecord, models.Promotion](conn(ctx, r.db), "code = ?", code)
+}
+
+func (r *PromotionRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.Promotion], error) {
+	return findPage[promotionRecord](conn(ctx, r.db), repository.PromotionFields, query)
+}
+
+func (r *PromotionRepository) Automatic(ctx context.Context, at time.Time) ([]models.Promotion, error) {
+	return all[promotionRecord, models.Promotion](notDeleted(conn(ctx, r.db)).
+		Where("code IS NULL AND is_active = ?", true).
+		Where("starts_at IS NULL OR starts_at <= ?", at).
+		Where("ends_at IS NULL OR ends_at > ?", at).
+		Order("id"))
+}
+
+func (r *PromotionRepository) Update(ctx context.Context, promotion *models.Promotion) error {
+	return replace(notDeleted(conn(ctx, r.db)), toPromotionRecord(promotion), promotion.ID.Hex(), "usage_count")
+}
+
+func (r *PromotionRepository) AdjustUsage(ctx context.Context, id primitive.ObjectID, delta int) error {
+	db := conn(ctx, r.db)
+	query := db.Model(&promotionRecord{}).Where("id = ?", id.Hex())
+	if delta > 0 {
+		query = query.Where("usage_limit = 0 OR usage_count + ? <= usage_limit", delta)
+	}
+	result := query.UpdateColumn("usage_count", gorm.Expr("usage_count + ?", delta))
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	// Tell a missing promotion from one used up
+	var count int64
+	if err := db.Model(&promotionRecord{}).Where("id = ?", id.Hex()).Count(&count).Error; err != nil {
+		return translateError(err)
+	}
+	if count == 0 {
+		return repository.ErrNotFound
+	}
+	return repository.ErrUsageLimit
+}
+
+func (r *PromotionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return softDelete(conn(ctx, r.db), &promotionRecord{}, id.Hex())
+}
+
+func (r *PromotionRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	return restore(conn(ctx, r.db), &promotionRecord{}, id.Hex())
+}
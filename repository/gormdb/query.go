@@ -0,0 +1,135 @@
+// repository/gormdb/query.go
+package gormdb
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mifaabiyyu/go-test.git/money"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// likeEscaper escapes LIKE wildcards with '!', which needs no quoting in
+// either MySQL or SQLite.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// findPage runs a list query, counting every match and fetching one row
+// beyond the page so the next cursor can be set. Listable fields are
+// stored in columns of the same name, but for amounts of money, which are
+// stored in a column of minor units and one of currencies.
+func findPage[R any, M any, P modeler[R, M]](db *gorm.DB, fields repository.Fields[M], q repository.ListQuery) (*repository.Page[M], error) {
+	q, err := fields.Prepare(q)
+	if err != nil {
+		return nil, err
+	}
+
+	query := db.Model(new(R))
+	for _, filter := range q.Filters {
+		if amount, ok := filter.Value.(money.Money); ok {
+			query = query.Where(condition(filter.Field+"_currency", repository.OpEq, amount.Currency()))
+		}
+		query = query.Where(condition(column(fields, filter.Field), filter.Op, filter.Value))
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, translateError(err)
+	}
+
+	after := repository.OpGt
+	if q.Sort.Descending {
+		after = repository.OpLt
+	}
+	var sortColumns []string
+	var sortValues []interface{}
+	if q.Sort.Field != "id" {
+		sortColumns, sortValues = sortKeys(fields, q.Sort.Field, q.After)
+	}
+	if q.After != nil {
+		// Rows after the cursor's in the order of every sort column in turn
+		keyset := condition("id", after, q.After.ID)
+		for i := len(sortColumns) - 1; i >= 0; i-- {
+			keyset = clause.Or(
+				condition(sortColumns[i], after, sortValues[i]),
+				clause.And(condition(sortColumns[i], repository.OpEq, sortValues[i]), keyset),
+			)
+		}
+		query = query.Where(keyset)
+	} else if q.Offset > 0 {
+		query = query.Offset(int(q.Offset))
+	}
+	for _, name := range sortColumns {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: name}, Desc: q.Sort.Descending})
+	}
+	query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: q.Sort.Descending})
+	if q.Limit > 0 {
+		query = query.Limit(int(q.Limit + 1))
+	}
+
+	items, err := all[R, M, P](query)
+	if err != nil {
+		return nil, err
+	}
+	return repository.NewPage(items, total, q, fields), nil
+}
+
+// column returns the column a listable field is stored in. Amounts of
+// money are compared by their minor units.
+func column[M any](fields repository.Fields[M], field string) string {
+	if fields[field].Kind == repository.MoneyField {
+		return field + "_minor"
+	}
+	return field
+}
+
+// sortKeys returns the columns a list sorted by field is ordered by, ahead
+// of the ID, with the value of each at cursor when there is one. Amounts of
+// money are ordered by currency and then by minor units.
+func sortKeys[M any](fields repository.Fields[M], field string, cursor *repository.Cursor) ([]string, []interface{}) {
+	var value interface{}
+	if cursor != nil {
+		value = cursor.Value
+	}
+	if fields[field].Kind != repository.MoneyField {
+		return []string{field}, []interface{}{value}
+	}
+	var currency interface{}
+	if amount, ok := value.(money.Money); ok {
+		currency = amount.Currency()
+	}
+	return []string{field + "_currency", column(fields, field)}, []interface{}{currency, value}
+}
+
+// condition builds the SQL comparison for one filter. IDs are stored as
+// hex strings.
+func condition(name string, op repository.Operator, value interface{}) clause.Expression {
+	switch v := value.(type) {
+	case primitive.ObjectID:
+		value = v.Hex()
+	case money.Money:
+		value = v.Minor()
+	}
+	column := clause.Column{Name: name}
+
+	switch op {
+	case repository.OpGt:
+		return clause.Gt{Column: column, Value: value}
+	case repository.OpGte:
+		return clause.Gte{Column: column, Value: value}
+	case repository.OpLt:
+		return clause.Lt{Column: column, Value: value}
+	case repository.OpLte:
+		return clause.Lte{Column: column, Value: value}
+	case repository.OpUnset:
+		return clause.Eq{Column: column, Value: nil}
+	case repository.OpContains:
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(value.(string))) + "%"
+		return clause.Expr{SQL: "LOWER(?) LIKE ? ESCAPE '!'", Vars: []interface{}{column, pattern}}
+	default:
+		return clause.Eq{Column: column, Value: value}
+	}
+}
@@ -0,0 +1,700 @@
+// repository/gormdb/records.go
+package gormdb
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/money"
+)
+
+// The record types below are the relational shape of the models. IDs keep
+// the ObjectID hex form so that documents look the same on every backend.
+// Amounts of money take two columns, the amount in minor units and the
+// currency.
+
+type customerRecord struct {
+	ID    string `gorm:"primaryKey;size:24"`
+	Name  string `gorm:"size:255;not null"`
+	Code  string `gorm:"size:100;not null"`
+	Email string `gorm:"size:255;index"`
+
+	DeletedAt *time.Time `gorm:"index"`
+}
+
+func (customerRecord) TableName() string { return "customers" }
+
+type customerAddressRecord struct {
+	ID         string          `gorm:"primaryKey;size:24"`
+	CustomerID string          `gorm:"size:24;not null;index"`
+	Customer   *customerRecord `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Street     string          `gorm:"size:255"`
+	City       string          `gorm:"size:100"`
+	PostalCode string          `gorm:"size:20"`
+}
+
+func (customerAddressRecord) TableName() string { return "customer_address" }
+
+type productRecord struct {
+	ID            string        `gorm:"primaryKey;size:24"`
+	Code          string        `gorm:"size:100;not null;index"`
+	Name          string        `gorm:"size:255;not null"`
+	Category      string        `gorm:"size:50;not null;default:'';index"`
+	PriceMinor    int64         `gorm:"not null;default:0"`
+	PriceCurrency string        `gorm:"size:3;not null;default:''"`
+	Prices        []money.Money `gorm:"type:text;serializer:json"`
+	Description   string        `gorm:"type:text"`
+
+	StockQuantity    float64 `gorm:"not null;default:0"`
+	ReservedQuantity float64 `gorm:"not null;default:0"`
+	ReorderLevel     float64 `gorm:"not null;default:0"`
+
+	DeletedAt *time.Time `gorm:"index"`
+}
+
+func (productRecord) TableName() string { return "products" }
+
+type paymentMethodRecord struct {
+	ID       string `gorm:"primaryKey;size:24"`
+	Name     string `gorm:"size:100;not null;index"`
+	IsActive bool   `gorm:"not null"`
+
+	DeletedAt *time.Time `gorm:"index"`
+}
+
+func (paymentMethodRecord) TableName() string { return "payment_methods" }
+
+type transactionRecord struct {
+	ID                     string                   `gorm:"primaryKey;size:24"`
+	CustomerID             string                   `gorm:"size:24;not null;index"`
+	Customer               *customerRecord          `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	Currency               string                   `gorm:"size:3;not null;default:'';index"`
+	CouponCode             string                   `gorm:"size:50;not null;default:'';index"`
+	DiscountAmountMinor    int64                    `gorm:"not null;default:0"`
+	DiscountAmountCurrency string                   `gorm:"size:3;not null;default:''"`
+	Discounts              []models.AppliedDiscount `gorm:"type:text;serializer:json"`
+	SubtotalMinor          int64                    `gorm:"not null;default:0"`
+	SubtotalCurrency       string                   `gorm:"size:3;not null;default:''"`
+	TaxAmountMinor         int64                    `gorm:"not null;default:0"`
+	TaxAmountCurrency      string                   `gorm:"size:3;not null;default:''"`
+	TotalAmountMinor       int64                    `gorm:"not null;default:0"`
+	TotalAmountCurrency    string                   `gorm:"size:3;not null;default:''"`
+	TaxInclusive           bool                     `gorm:"not null;default:false"`
+	Taxes                  []models.TaxTotal        `gorm:"type:text;serializer:json"`
+	TotalQty               float64
+	TransactionDate        time.Time `gorm:"index"`
+}
+
+func (transactionRecord) TableName() string { return "transactions" }
+
+type transactionDetailRecord struct {
+	ID               string             `gorm:"primaryKey;size:24"`
+	TransactionID    string             `gorm:"size:24;not null;index"`
+	Transaction      *transactionRecord `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ProductID        string             `gorm:"size:24;not null;index"`
+	Product          *productRecord     `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	Quantity         float64
+	SubtotalMinor    int64  `gorm:"not null;default:0"`
+	SubtotalCurrency string `gorm:"size:3;not null;default:''"`
+	PriceMinor       int64  `gorm:"not null;default:0"`
+	PriceCurrency    string `gorm:"size:3;not null;default:''"`
+	ProductCode      string `gorm:"size:100"`
+	ProductName      string `gorm:"size:255"`
+
+	DiscountAmountMinor    int64                    `gorm:"not null;default:0"`
+	DiscountAmountCurrency string                   `gorm:"size:3;not null;default:''"`
+	Discounts              []models.AppliedDiscount `gorm:"type:text;serializer:json"`
+
+	TaxRate               float64 `gorm:"not null;default:0"`
+	TaxableAmountMinor    int64   `gorm:"not null;default:0"`
+	TaxableAmountCurrency string  `gorm:"size:3;not null;default:''"`
+	TaxAmountMinor        int64   `gorm:"not null;default:0"`
+	TaxAmountCurrency     string  `gorm:"size:3;not null;default:''"`
+	TotalMinor            int64   `gorm:"not null;default:0"`
+	TotalCurrency         string  `gorm:"size:3;not null;default:''"`
+}
+
+func (transactionDetailRecord) TableName() string { return "transaction_details" }
+
+type transactionPaymentRecord struct {
+	ID                 string               `gorm:"primaryKey;size:24"`
+	TransactionID      string               `gorm:"size:24;not null;index"`
+	Transaction        *transactionRecord   `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	PaymentMethodID    string               `gorm:"size:24;not null;index"`
+	PaymentMethod      *paymentMethodRecord `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	Status             models.PaymentStatus
+	PaidAmountMinor    int64  `gorm:"not null;default:0"`
+	PaidAmountCurrency string `gorm:"size:3;not null;default:''"`
+	PaymentDate        time.Time
+
+	SettledAmountMinor    int64   `gorm:"not null;default:0"`
+	SettledAmountCurrency string  `gorm:"size:3;not null;default:''"`
+	ExchangeRate          float64 `gorm:"not null;default:0"`
+}
+
+func (transactionPaymentRecord) TableName() string { return "transaction_payments" }
+
+// stockMovementRecord has no foreign keys: the ledger outlives the details
+// it links to.
+type stockMovementRecord struct {
+	ID                  string                   `gorm:"primaryKey;size:24"`
+	ProductID           string                   `gorm:"size:24;not null;index"`
+	Type                models.StockMovementType `gorm:"size:20;not null;index"`
+	Quantity            float64                  `gorm:"not null"`
+	TransactionID       string                   `gorm:"size:24;index"`
+	TransactionDetailID string                   `gorm:"size:24"`
+	Reason              string                   `gorm:"size:255"`
+	User                string                   `gorm:"size:100"`
+	CreatedAt           time.Time                `gorm:"index"`
+}
+
+func (stockMovementRecord) TableName() string { return "stock_movements" }
+
+// productVersionRecord has no foreign key either: history is kept when
+// the product goes away.
+type productVersionRecord struct {
+	ID               string        `gorm:"primaryKey;size:24"`
+	ProductID        string        `gorm:"size:24;not null;uniqueIndex:idx_product_versions_product_version"`
+	Version          int           `gorm:"not null;uniqueIndex:idx_product_versions_product_version"`
+	Code             string        `gorm:"size:100;not null"`
+	Name             string        `gorm:"size:255;not null"`
+	Category         string        `gorm:"size:50;not null;default:''"`
+	PriceMinor       int64         `gorm:"not null;default:0"`
+	PriceCurrency    string        `gorm:"size:3;not null;default:''"`
+	Prices           []money.Money `gorm:"type:text;serializer:json"`
+	Description      string        `gorm:"type:text"`
+	ReservedQuantity float64       `gorm:"not null;default:0"`
+	ReorderLevel     float64       `gorm:"not null;default:0"`
+	User             string        `gorm:"size:100"`
+	CreatedAt        time.Time
+}
+
+func (productVersionRecord) TableName() string { return "product_versions" }
+
+// exchangeRateRecord has no foreign keys either: currencies are codes,
+// not rows.
+type exchangeRateRecord struct {
+	ID            string    `gorm:"primaryKey;size:24"`
+	FromCurrency  string    `gorm:"size:3;not null;uniqueIndex:idx_exchange_rates_pair_effective_from"`
+	ToCurrency    string    `gorm:"size:3;not null;uniqueIndex:idx_exchange_rates_pair_effective_from"`
+	Rate          float64   `gorm:"not null"`
+	EffectiveFrom time.Time `gorm:"not null;uniqueIndex:idx_exchange_rates_pair_effective_from"`
+	CreatedBy     string    `gorm:"size:100"`
+	CreatedAt     time.Time `gorm:"index"`
+}
+
+func (exchangeRateRecord) TableName() string { return "exchange_rates" }
+
+// promotionRecord keeps its products as a list rather than a join table:
+// like a transaction's snapshots, it outlives the products it names. A
+// promotion without a coupon code has a NULL code, which the unique index
+// on codes leaves out in both MySQL and SQLite.
+type promotionRecord struct {
+	ID             string                `gorm:"primaryKey;size:24"`
+	Name           string                `gorm:"size:100;not null"`
+	Code           *string               `gorm:"size:50;uniqueIndex:idx_promotions_coupon_code"`
+	Type           models.PromotionType  `gorm:"size:20;not null"`
+	Scope          models.PromotionScope `gorm:"size:20;not null"`
+	ProductIDs     []primitive.ObjectID  `gorm:"type:text;serializer:json"`
+	Percent        float64               `gorm:"not null;default:0"`
+	AmountMinor    int64                 `gorm:"not null;default:0"`
+	AmountCurrency string                `gorm:"size:3;not null;default:''"`
+	BuyQuantity    float64               `gorm:"not null;default:0"`
+	GetQuantity    float64               `gorm:"not null;default:0"`
+	StartsAt       *time.Time
+	EndsAt         *time.Time
+	UsageLimit     int  `gorm:"not null;default:0"`
+	UsageCount     int  `gorm:"not null;default:0"`
+	IsActive       bool `gorm:"not null"`
+
+	DeletedAt *time.Time `gorm:"index"`
+}
+
+func (promotionRecord) TableName() string { return "promotions" }
+
+type apiKeyRecord struct {
+	ID        string        `gorm:"primaryKey;size:24"`
+	Name      string        `gorm:"size:100;not null"`
+	Prefix    string        `gorm:"size:20;not null"`
+	Hash      string        `gorm:"size:64;not null;uniqueIndex"`
+	Roles     []models.Role `gorm:"type:text;serializer:json"`
+	CreatedBy string        `gorm:"size:100"`
+	CreatedAt time.Time     `gorm:"index"`
+	ExpiresAt *time.Time
+	RevokedAt *time.Time
+}
+
+func (apiKeyRecord) TableName() string { return "api_keys" }
+
+// auditEntryRecord has no foreign keys: the log outlives what it records.
+type auditEntryRecord struct {
+	ID         string               `gorm:"primaryKey;size:24"`
+	Actor      string               `gorm:"size:100;index"`
+	Action     models.AuditAction   `gorm:"size:20;not null"`
+	EntityType string               `gorm:"size:50;not null;index:idx_audit_log_entity"`
+	EntityID   string               `gorm:"size:24;not null;index:idx_audit_log_entity"`
+	Changes    []models.AuditChange `gorm:"type:text;serializer:json"`
+	RequestID  string               `gorm:"size:128"`
+	CreatedAt  time.Time            `gorm:"index"`
+}
+
+func (auditEntryRecord) TableName() string { return "audit_log" }
+
+// allRecords lists the tables in dependency order for auto-migration.
+var allRecords = []interface{}{
+	&customerRecord{},
+	&customerAddressRecord{},
+	&productRecord{},
+	&paymentMethodRecord{},
+	&transactionRecord{},
+	&transactionDetailRecord{},
+	&transactionPaymentRecord{},
+	&stockMovementRecord{},
+	&productVersionRecord{},
+	&exchangeRateRecord{},
+	&promotionRecord{},
+	&apiKeyRecord{},
+	&auditEntryRecord{},
+}
+
+// objectID parses an ID column. Columns are only ever written from
+// ObjectIDs, so a malformed value yields the zero ID rather than an error.
+func objectID(hex string) primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(hex)
+	return id
+}
+
+// optionalHex stores the zero ID of an unset reference as an empty column.
+func optionalHex(id primitive.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	return id.Hex()
+}
+
+func hexIDs(ids []primitive.ObjectID) []string {
+	hexes := make([]string, len(ids))
+	for i, id := range ids {
+		hexes[i] = id.Hex()
+	}
+	return hexes
+}
+
+func toCustomerRecord(customer *models.Customer) *customerRecord {
+	return &customerRecord{
+		ID:    customer.ID.Hex(),
+		Name:  customer.Name,
+		Code:  customer.Code,
+		Email: customer.Email,
+
+		DeletedAt: customer.DeletedAt,
+	}
+}
+
+func (r *customerRecord) model() models.Customer {
+	return models.Customer{
+		ID:    objectID(r.ID),
+		Name:  r.Name,
+		Code:  r.Code,
+		Email: r.Email,
+
+		DeletedAt: r.DeletedAt,
+	}
+}
+
+func toCustomerAddressRecord(address *models.CustomerAddress) *customerAddressRecord {
+	return &customerAddressRecord{
+		ID:         address.ID.Hex(),
+		CustomerID: address.CustomerID.Hex(),
+		Street:     address.Street,
+		City:       address.City,
+		PostalCode: address.PostalCode,
+	}
+}
+
+func (r *customerAddressRecord) model() models.CustomerAddress {
+	return models.CustomerAddress{
+		ID:         objectID(r.ID),
+		CustomerID: objectID(r.CustomerID),
+		Street:     r.Street,
+		City:       r.City,
+		PostalCode: r.PostalCode,
+	}
+}
+
+func toProductRecord(product *models.Product) *productRecord {
+	return &productRecord{
+		ID:            product.ID.Hex(),
+		Code:          product.Code,
+		Name:          product.Name,
+		Category:      product.Category,
+		PriceMinor:    product.Price.Minor(),
+		PriceCurrency: product.Price.Currency(),
+		Prices:        product.Prices,
+		Description:   product.Description,
+
+		StockQuantity:    product.StockQuantity,
+		ReservedQuantity: product.ReservedQuantity,
+		ReorderLevel:     product.ReorderLevel,
+
+		DeletedAt: product.DeletedAt,
+	}
+}
+
+func (r *productRecord) model() models.Product {
+	return models.Product{
+		ID:          objectID(r.ID),
+		Code:        r.Code,
+		Name:        r.Name,
+		Category:    r.Category,
+		Price:       money.New(r.PriceMinor, r.PriceCurrency),
+		Prices:      r.Prices,
+		Description: r.Description,
+
+		StockQuantity:    r.StockQuantity,
+		ReservedQuantity: r.ReservedQuantity,
+		ReorderLevel:     r.ReorderLevel,
+
+		DeletedAt: r.DeletedAt,
+	}
+}
+
+func toPaymentMethodRecord(paymentMethod *models.PaymentMethod) *paymentMethodRecord {
+	return &paymentMethodRecord{
+		ID:       paymentMethod.ID.Hex(),
+		Name:     paymentMethod.Name,
+		IsActive: paymentMethod.IsActive,
+
+		DeletedAt: paymentMethod.DeletedAt,
+	}
+}
+
+func (r *paymentMethodRecord) model() models.PaymentMethod {
+	return models.PaymentMethod{
+		ID:       objectID(r.ID),
+		Name:     r.Name,
+		IsActive: r.IsActive,
+
+		DeletedAt: r.DeletedAt,
+	}
+}
+
+func toTransactionRecord(transaction *models.Transaction) *transactionRecord {
+	return &transactionRecord{
+		ID:                     transaction.ID.Hex(),
+		CustomerID:             transaction.CustomerID.Hex(),
+		Currency:               transaction.Currency,
+		CouponCode:             transaction.CouponCode,
+		DiscountAmountMinor:    transaction.DiscountAmount.Minor(),
+		DiscountAmountCurrency: transaction.DiscountAmount.Currency(),
+		Discounts:              transaction.Discounts,
+		SubtotalMinor:          transaction.Subtotal.Minor(),
+		SubtotalCurrency:       transaction.Subtotal.Currency(),
+		TaxAmountMinor:         transaction.TaxAmount.Minor(),
+		TaxAmountCurrency:      transaction.TaxAmount.Currency(),
+		TotalAmountMinor:       transaction.TotalAmount.Minor(),
+		TotalAmountCurrency:    transaction.TotalAmount.Currency(),
+		TaxInclusive:           transaction.TaxInclusive,
+		Taxes:                  transaction.Taxes,
+		TotalQty:               transaction.TotalQty,
+		TransactionDate:        transaction.TransactionDate,
+	}
+}
+
+func (r *transactionRecord) model() models.Transaction {
+	return models.Transaction{
+		ID:              objectID(r.ID),
+		CustomerID:      objectID(r.CustomerID),
+		Currency:        r.Currency,
+		CouponCode:      r.CouponCode,
+		DiscountAmount:  money.New(r.DiscountAmountMinor, r.DiscountAmountCurrency),
+		Discounts:       r.Discounts,
+		Subtotal:        money.New(r.SubtotalMinor, r.SubtotalCurrency),
+		TaxAmount:       money.New(r.TaxAmountMinor, r.TaxAmountCurrency),
+		TotalAmount:     money.New(r.TotalAmountMinor, r.TotalAmountCurrency),
+		TaxInclusive:    r.TaxInclusive,
+		Taxes:           r.Taxes,
+		TotalQty:        r.TotalQty,
+		TransactionDate: r.TransactionDate,
+	}
+}
+
+func toTransactionDetailRecord(detail *models.TransactionDetail) *transactionDetailRecord {
+	return &transactionDetailRecord{
+		ID:               detail.ID.Hex(),
+		TransactionID:    detail.TransactionID.Hex(),
+		ProductID:        detail.ProductID.Hex(),
+		Quantity:         detail.Quantity,
+		SubtotalMinor:    detail.Subtotal.Minor(),
+		SubtotalCurrency: detail.Subtotal.Currency(),
+		PriceMinor:       detail.Price.Minor(),
+		PriceCurrency:    detail.Price.Currency(),
+		ProductCode:      detail.ProductCode,
+		ProductName:      detail.ProductName,
+
+		DiscountAmountMinor:    detail.DiscountAmount.Minor(),
+		DiscountAmountCurrency: detail.DiscountAmount.Currency(),
+		Discounts:              detail.Discounts,
+
+		TaxRate:               detail.TaxRate,
+		TaxableAmountMinor:    detail.TaxableAmount.Minor(),
+		TaxableAmountCurrency: detail.TaxableAmount.Currency(),
+		TaxAmountMinor:        detail.TaxAmount.Minor(),
+		TaxAmountCurrency:     detail.TaxAmount.Currency(),
+		TotalMinor:            detail.Total.Minor(),
+		TotalCurrency:         detail.Total.Currency(),
+	}
+}
+
+func (r *transactionDetailRecord) model() models.TransactionDetail {
+	return models.TransactionDetail{
+		ID:             objectID(r.ID),
+		TransactionID:  objectID(r.TransactionID),
+		ProductID:      objectID(r.ProductID),
+		Quantity:       r.Quantity,
+		Subtotal:       money.New(r.SubtotalMinor, r.SubtotalCurrency),
+		Price:          money.New(r.PriceMinor, r.PriceCurrency),
+		ProductCode:    r.ProductCode,
+		ProductName:    r.ProductName,
+		DiscountAmount: money.New(r.DiscountAmountMinor, r.DiscountAmountCurrency),
+		Discounts:      r.Discounts,
+		TaxRate:        r.TaxRate,
+		TaxableAmount:  money.New(r.TaxableAmountMinor, r.TaxableAmountCurrency),
+		TaxAmount:      money.New(r.TaxAmountMinor, r.TaxAmountCurrency),
+		Total:          money.New(r.TotalMinor, r.TotalCurrency),
+	}
+}
+
+func toTransactionPaymentRecord(payment *models.TransactionPayment) *transactionPaymentRecord {
+	return &transactionPaymentRecord{
+		ID:                 payment.ID.Hex(),
+		TransactionID:      payment.TransactionID.Hex(),
+		PaymentMethodID:    payment.PaymentMethodID.Hex(),
+		Status:             payment.Status,
+		PaidAmountMinor:    payment.PaidAmount.Minor(),
+		PaidAmountCurrency: payment.PaidAmount.Currency(),
+		PaymentDate:        payment.PaymentDate,
+
+		SettledAmountMinor:    payment.SettledAmount.Minor(),
+		SettledAmountCurrency: payment.SettledAmount.Currency(),
+		ExchangeRate:          payment.ExchangeRate,
+	}
+}
+
+func (r *transactionPaymentRecord) model() models.TransactionPayment {
+	return models.TransactionPayment{
+		ID:              objectID(r.ID),
+		TransactionID:   objectID(r.TransactionID),
+		PaymentMethodID: objectID(r.PaymentMethodID),
+		Status:          r.Status,
+		PaidAmount:      money.New(r.PaidAmountMinor, r.PaidAmountCurrency),
+		PaymentDate:     r.PaymentDate,
+		SettledAmount:   money.New(r.SettledAmountMinor, r.SettledAmountCurrency),
+		ExchangeRate:    r.ExchangeRate,
+	}
+}
+
+func toStockMovementRecord(movement *models.StockMovement) *stockMovementRecord {
+	return &stockMovementRecord{
+		ID:                  movement.ID.Hex(),
+		ProductID:           movement.ProductID.Hex(),
+		Type:                movement.Type,
+		Quantity:            movement.Quantity,
+		TransactionID:       optionalHex(movement.TransactionID),
+		TransactionDetailID: optionalHex(movement.TransactionDetailID),
+		Reason:              movement.Reason,
+		User:                movement.User,
+		CreatedAt:           movement.CreatedAt,
+	}
+}
+
+func (r *stockMovementRecord) model() models.StockMovement {
+	return models.StockMovement{
+		ID:                  objectID(r.ID),
+		ProductID:           objectID(r.ProductID),
+		Type:                r.Type,
+		Quantity:            r.Quantity,
+		TransactionID:       objectID(r.TransactionID),
+		TransactionDetailID: objectID(r.TransactionDetailID),
+		Reason:              r.Reason,
+		User:                r.User,
+		CreatedAt:           r.CreatedAt,
+	}
+}
+
+func toProductVersionRecord(version *models.ProductVersion) *productVersionRecord {
+	return &productVersionRecord{
+		ID:               version.ID.Hex(),
+		ProductID:        version.ProductID.Hex(),
+		Version:          version.Version,
+		Code:             version.Code,
+		Name:             version.Name,
+		Category:         version.Category,
+		PriceMinor:       version.Price.Minor(),
+		PriceCurrency:    version.Price.Currency(),
+		Prices:           version.Prices,
+		Description:      version.Description,
+		ReservedQuantity: version.ReservedQuantity,
+		ReorderLevel:     version.ReorderLevel,
+		User:             version.User,
+		CreatedAt:        version.CreatedAt,
+	}
+}
+
+func (r *productVersionRecord) model() models.ProductVersion {
+	return models.ProductVersion{
+		ID:               objectID(r.ID),
+		ProductID:        objectID(r.ProductID),
+		Version:          r.Version,
+		Code:             r.Code,
+		Name:             r.Name,
+		Category:         r.Category,
+		Price:            money.New(r.PriceMinor, r.PriceCurrency),
+		Prices:           r.Prices,
+		Description:      r.Description,
+		ReservedQuantity: r.ReservedQuantity,
+		ReorderLevel:     r.ReorderLevel,
+		User:             r.User,
+		CreatedAt:        r.CreatedAt,
+	}
+}
+
+func toExchangeRateRecord(rate *models.ExchangeRate) *exchangeRateRecord {
+	return &exchangeRateRecord{
+		ID:            rate.ID.Hex(),
+		FromCurrency:  rate.FromCurrency,
+		ToCurrency:    rate.ToCurrency,
+		Rate:          rate.Rate,
+		EffectiveFrom: rate.EffectiveFrom,
+		CreatedBy:     rate.CreatedBy,
+		CreatedAt:     rate.CreatedAt,
+	}
+}
+
+func (r *exchangeRateRecord) model() models.ExchangeRate {
+	return models.ExchangeRate{
+		ID:            objectID(r.ID),
+		FromCurrency:  r.FromCurrency,
+		ToCurrency:    r.ToCurrency,
+		Rate:          r.Rate,
+		EffectiveFrom: r.EffectiveFrom,
+		CreatedBy:     r.CreatedBy,
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+func toPromotionRecord(promotion *models.Promotion) *promotionRecord {
+	return &promotionRecord{
+		ID:             promotion.ID.Hex(),
+		Name:           promotion.Name,
+		Code:           couponCode(promotion.Code),
+		Type:           promotion.Type,
+		Scope:          promotion.Scope,
+		ProductIDs:     promotion.ProductIDs,
+		Percent:        promotion.Percent,
+		AmountMinor:    promotion.Amount.Minor(),
+		AmountCurrency: promotion.Amount.Currency(),
+		BuyQuantity:    promotion.BuyQuantity,
+		GetQuantity:    promotion.GetQuantity,
+		StartsAt:       promotion.StartsAt,
+		EndsAt:         promotion.EndsAt,
+		UsageLimit:     promotion.UsageLimit,
+		UsageCount:     promotion.UsageCount,
+		IsActive:       promotion.IsActive,
+
+		DeletedAt: promotion.DeletedAt,
+	}
+}
+
+func (r *promotionRecord) model() models.Promotion {
+	return models.Promotion{
+		ID:          objectID(r.ID),
+		Name:        r.Name,
+		Code:        stringValue(r.Code),
+		Type:        r.Type,
+		Scope:       r.Scope,
+		ProductIDs:  r.ProductIDs,
+		Percent:     r.Percent,
+		Amount:      money.New(r.AmountMinor, r.AmountCurrency),
+		BuyQuantity: r.BuyQuantity,
+		GetQuantity: r.GetQuantity,
+		StartsAt:    r.StartsAt,
+		EndsAt:      r.EndsAt,
+		UsageLimit:  r.UsageLimit,
+		UsageCount:  r.UsageCount,
+		IsActive:    r.IsActive,
+
+		DeletedAt: r.DeletedAt,
+	}
+}
+
+// couponCode returns the column value of a promotion's code: NULL for none.
+func couponCode(code string) *string {
+	if code == "" {
+		return nil
+	}
+	return &code
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func toAPIKeyRecord(key *models.APIKey) *apiKeyRecord {
+	return &apiKeyRecord{
+		ID:        key.ID.Hex(),
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Hash:      key.Hash,
+		Roles:     key.Roles,
+		CreatedBy: key.CreatedBy,
+		CreatedAt: key.CreatedAt,
+		ExpiresAt: key.ExpiresAt,
+		RevokedAt: key.RevokedAt,
+	}
+}
+
+func (r *apiKeyRecord) model() models.APIKey {
+	return models.APIKey{
+		ID:        objectID(r.ID),
+		Name:      r.Name,
+		Prefix:    r.Prefix,
+		Hash:      r.Hash,
+		Roles:     r.Roles,
+		CreatedBy: r.CreatedBy,
+		CreatedAt: r.CreatedAt,
+		ExpiresAt: r.ExpiresAt,
+		RevokedAt: r.RevokedAt,
+	}
+}
+
+func toAuditEntryRecord(entry *models.AuditEntry) *auditEntryRecord {
+	return &auditEntryRecord{
+		ID:         entry.ID.Hex(),
+		Actor:      entry.Actor,
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID.Hex(),
+		Changes:    entry.Changes,
+		RequestID:  entry.RequestID,
+		CreatedAt:  entry.CreatedAt,
+	}
+}
+
+func (r *auditEntryRecord) model() models.AuditEntry {
+	return models.AuditEntry{
+		ID:         objectID(r.ID),
+		Actor:      r.Actor,
+		Action:     r.Action,
+		EntityType: r.EntityType,
+		EntityID:   objectID(r.EntityID),
+		Changes:    r.Changes,
+		RequestID:  r.RequestID,
+		CreatedAt:  r.CreatedAt,
+	}
+}
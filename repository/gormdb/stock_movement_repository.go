@@ -0,0 +1,50 @@
+// repository/gormdb/stock_movement_repository.go
+package gormdb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type StockMovementRepository struct {
+	db *gorm.DB
+}
+
+func (r *StockMovementRepository) Create(ctx context.Context, movements ...*models.StockMovement) error {
+	if len(movements) == 0 {
+		return nil
+	}
+	records := make([]*stockMovementRecord, len(movements))
+	for i, movement := range movements {
+		records[i] = toStockMovementRecord(movement)
+	}
+	return create(conn(ctx, r.db), records)
+}
+
+func (r *StockMovementRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.StockMovement], error) {
+	return findPage[stockMovementRecord](conn(ctx, r.db), repository.StockMovementFields, query)
+}
+
+func (r *StockMovementRepository) SumByProduct(ctx context.Context) (map[primitive.ObjectID]float64, error) {
+	var totals []struct {
+		ProductID string
+		Quantity  float64
+	}
+	err := conn(ctx, r.db).Model(&stockMovementRecord{}).
+		Select("product_id, SUM(quantity) AS quantity").
+		Group("product_id").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	sums := make(map[primitive.ObjectID]float64, len(totals))
+	for _, total := range totals {
+		sums[objectID(total.ProductID)] = total.Quantity
+	}
+	return sums, nil
+}
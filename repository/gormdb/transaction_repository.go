@@ -0,0 +1,130 @@
+// repository/gormdb/transaction_repository.go
+package gormdb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type TransactionRepository struct {
+	db *gorm.DB
+}
+
+func (r *TransactionRepository) Create(ctx context.Context, transaction *models.Transaction) error {
+	return create(conn(ctx, r.db), toTransactionRecord(transaction))
+}
+
+func (r *TransactionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Transaction, error) {
+	return first[transactionRecord, models.Transaction](conn(ctx, r.db), "id = ?", id.Hex())
+}
+
+func (r *TransactionRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.Transaction], error) {
+	return findPage[transactionRecord](conn(ctx, r.db), repository.TransactionFields, query)
+}
+
+func (r *TransactionRepository) Update(ctx context.Context, transaction *models.Transaction) error {
+	return replace(conn(ctx, r.db), toTransactionRecord(transaction), transaction.ID.Hex())
+}
+
+func (r *TransactionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return deleteByID(conn(ctx, r.db), &transactionRecord{}, id.Hex())
+}
+
+func (r *TransactionRepository) CountByCustomer(ctx context.Context, customerID primitive.ObjectID) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).Model(&transactionRecord{}).Where("customer_id = ?", customerID.Hex()).Count(&count).Error
+	return count, translateError(err)
+}
+
+type TransactionDetailRepository struct {
+	db *gorm.DB
+}
+
+func (r *TransactionDetailRepository) Create(ctx context.Context, detail *models.TransactionDetail) error {
+	return create(conn(ctx, r.db), toTransactionDetailRecord(detail))
+}
+
+func (r *TransactionDetailRepository) ListByTransaction(ctx context.Context, transactionID primitive.ObjectID) ([]models.TransactionDetail, error) {
+	return all[transactionDetailRecord, models.TransactionDetail](conn(ctx, r.db).Where("transaction_id = ?", transactionID.Hex()))
+}
+
+func (r *TransactionDetailRepository) ListByTransactions(ctx context.Context, transactionIDs ...primitive.ObjectID) ([]models.TransactionDetail, error) {
+	if len(transactionIDs) == 0 {
+		return nil, nil
+	}
+	return all[transactionDetailRecord, models.TransactionDetail](conn(ctx, r.db).Where("transaction_id IN ?", hexIDs(transactionIDs)))
+}
+
+func (r *TransactionDetailRepository) Update(ctx context.Context, detail *models.TransactionDetail) error {
+	return update(conn(ctx, r.db), &transactionDetailRecord{}, detail.ID.Hex(), map[string]interface{}{
+		"quantity":                 detail.Quantity,
+		"subtotal_minor":           detail.Subtotal.Minor(),
+		"subtotal_currency":        detail.Subtotal.Currency(),
+		"price_minor":              detail.Price.Minor(),
+		"price_currency":           detail.Price.Currency(),
+		"discount_amount_minor":    detail.DiscountAmount.Minor(),
+		"discount_amount_currency": detail.DiscountAmount.Currency(),
+		"discounts":                detail.Discounts,
+		"tax_rate":                 detail.TaxRate,
+		"taxable_amount_minor":     detail.TaxableAmount.Minor(),
+		"taxable_amount_currency":  detail.TaxableAmount.Currency(),
+		"tax_amount_minor":         detail.TaxAmount.Minor(),
+		"tax_amount_currency":      detail.TaxAmount.Currency(),
+		"total_minor":              detail.Total.Minor(),
+		"total_currency":           detail.Total.Currency(),
+	})
+}
+
+func (r *TransactionDetailRepository) DeleteByTransaction(ctx context.Context, transactionID primitive.ObjectID) error {
+	return translateError(conn(ctx, r.db).Where("transaction_id = ?", transactionID.Hex()).Delete(&transactionDetailRecord{}).Error)
+}
+
+func (r *TransactionDetailRepository) CountByProduct(ctx context.Context, productID primitive.ObjectID) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).Model(&transactionDetailRecord{}).Where("product_id = ?", productID.Hex()).Count(&count).Error
+	return count, translateError(err)
+}
+
+type TransactionPaymentRepository struct {
+	db *gorm.DB
+}
+
+func (r *TransactionPaymentRepository) Create(ctx context.Context, payment *models.TransactionPayment) error {
+	return create(conn(ctx, r.db), toTransactionPaymentRecord(payment))
+}
+
+func (r *TransactionPaymentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.TransactionPayment, error) {
+	return first[transactionPaymentRecord, models.TransactionPayment](conn(ctx, r.db), "id = ?", id.Hex())
+}
+
+func (r *TransactionPaymentRepository) ListByTransaction(ctx context.Context, transactionID primitive.ObjectID) ([]models.TransactionPayment, error) {
+	return all[transactionPaymentRecord, models.TransactionPayment](conn(ctx, r.db).Where("transaction_id = ?", transactionID.Hex()))
+}
+
+func (r *TransactionPaymentRepository) ListByTransactions(ctx context.Context, transactionIDs ...primitive.ObjectID) ([]models.TransactionPayment, error) {
+	if len(transactionIDs) == 0 {
+		return nil, nil
+	}
+	return all[transactionPaymentRecord, models.TransactionPayment](conn(ctx, r.db).Where("transaction_id IN ?", hexIDs(transactionIDs)))
+}
+
+func (r *TransactionPaymentRepository) Update(ctx context.Context, payment *models.TransactionPayment) error {
+	return update(conn(ctx, r.db), &transactionPaymentRecord{}, payment.ID.Hex(), map[string]interface{}{
+		"status":                  payment.Status,
+		"paid_amount_minor":       payment.PaidAmount.Minor(),
+		"paid_amount_currency":    payment.PaidAmount.Currency(),
+		"settled_amount_minor":    payment.SettledAmount.Minor(),
+		"settled_amount_currency": payment.SettledAmount.Currency(),
+		"exchange_rate":           payment.ExchangeRate,
+		"payment_date":            payment.PaymentDate,
+	})
+}
+
+func (r *TransactionPaymentRepository) DeleteByTransaction(ctx context.Context, transactionID primitive.ObjectID) error {
+	return translateError(conn(ctx, r.db).Where("transaction_id = ?", transactionID.Hex()).Delete(&transactionPaymentRecord{}).Error)
+}
@@ -0,0 +1,51 @@
+// repository/gormdb/unit_of_work.go
+package gormdb
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	gomysql "github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// MySQL error numbers after which the whole transaction can safely be
+// retried.
+const (
+	mysqlLockWaitTimeout = 1205
+	mysqlDeadlock        = 1213
+)
+
+type txKey struct{}
+
+// UnitOfWork runs functions inside a database transaction. Repository
+// calls made with the transaction context are routed to the open *gorm.DB
+// transaction by conn.
+type UnitOfWork struct {
+	db *gorm.DB
+}
+
+func (u *UnitOfWork) WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	return repository.RetryTransient(ctx, isTransient, func() error {
+		return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, txKey{}, tx))
+		})
+	})
+}
+
+// isTransient reports whether err was caused by lock contention rather
+// than by the statements themselves.
+func isTransient(err error) bool {
+	var mysqlErr *gomysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDeadlock || mysqlErr.Number == mysqlLockWaitTimeout
+	}
+	return strings.Contains(err.Error(), "database is locked")
+}
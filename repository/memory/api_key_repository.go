@@ -0,0 +1,62 @@
+// repository/memory/api_key_repository.go
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type APIKeyRepository struct {
+	store *Store
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	if _, taken := r.store.apiKeys.first(func(k models.APIKey) bool { return k.Hash == key.Hash }); taken {
+		return repository.ErrDuplicate
+	}
+	return r.store.apiKeys.insert(key.ID, *key)
+}
+
+func (r *APIKeyRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.APIKey, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	key, ok := r.store.apiKeys.get(id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &key, nil
+}
+
+func (r *APIKeyRepository) FindByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	key, ok := r.store.apiKeys.first(func(k models.APIKey) bool { return k.Hash == hash })
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &key, nil
+}
+
+func (r *APIKeyRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.APIKey], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return findPage(r.store.apiKeys.find(nil), repository.APIKeyFields, query)
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	key, ok := r.store.apiKeys.get(id)
+	if !ok || key.RevokedAt != nil {
+		return repository.ErrNotFound
+	}
+	key.RevokedAt = &at
+	return r.store.apiKeys.replace(id, key)
+}
@@ -0,0 +1,25 @@
+// repository/memory/audit_repository.go
+package memory
+
+import (
+	"context"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type AuditRepository struct {
+	store *Store
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	return r.store.auditLog.insert(entry.ID, *entry)
+}
+
+func (r *AuditRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.AuditEntry], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return findPage(r.store.auditLog.find(nil), repository.AuditFields, query)
+}
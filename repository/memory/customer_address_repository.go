@@ -0,0 +1,67 @@
+// repository/memory/customer_address_repository.go
+package memory
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type CustomerAddressRepository struct {
+	store *Store
+}
+
+func (r *CustomerAddressRepository) Create(ctx context.Context, address *models.CustomerAddress) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	return r.store.customerAddresses.insert(address.ID, *address)
+}
+
+func (r *CustomerAddressRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.CustomerAddress, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	address, ok := r.store.customerAddresses.get(id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &address, nil
+}
+
+func (r *CustomerAddressRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.CustomerAddress], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return findPage(r.store.customerAddresses.find(nil), repository.CustomerAddressFields, query)
+}
+
+func (r *CustomerAddressRepository) ListByCustomers(ctx context.Context, customerIDs ...primitive.ObjectID) ([]models.CustomerAddress, error) {
+	wanted := make(map[primitive.ObjectID]bool, len(customerIDs))
+	for _, id := range customerIDs {
+		wanted[id] = true
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return r.store.customerAddresses.find(func(a models.CustomerAddress) bool { return wanted[a.CustomerID] }), nil
+}
+
+func (r *CustomerAddressRepository) Update(ctx context.Context, address *models.CustomerAddress) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	return r.store.customerAddresses.replace(address.ID, *address)
+}
+
+func (r *CustomerAddressRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	return r.store.customerAddresses.delete(id)
+}
+
+func (r *CustomerAddressRepository) DeleteByCustomer(ctx context.Context, customerID primitive.ObjectID) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	r.store.customerAddresses.deleteWhere(func(a models.CustomerAddress) bool { return a.CustomerID == customerID })
+	return nil
+}
@@ -0,0 +1,80 @@
+// repository/memory/customer_repository.go
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type CustomerRepository struct {
+	store *Store
+}
+
+func (r *CustomerRepository) Create(ctx context.Context, customer *models.Customer) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	return r.store.customers.insert(customer.ID, *customer)
+}
+
+func (r *CustomerRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Customer, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	customer, ok := r.store.customers.get(id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &customer, nil
+}
+
+func (r *CustomerRepository) FindByEmail(ctx context.Context, email string) (*models.Customer, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	customer, ok := r.store.customers.first(func(c models.Customer) bool { return c.Email == email })
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &customer, nil
+}
+
+func (r *CustomerRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.Customer], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return findPage(r.store.customers.find(nil), repository.CustomerFields, query)
+}
+
+func (r *CustomerRepository) Update(ctx context.Context, customer *models.Customer) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	if stored, ok := r.store.customers.get(customer.ID); !ok || stored.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+	return r.store.customers.replace(customer.ID, *customer)
+}
+
+func (r *CustomerRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	customer, ok := r.store.customers.get(id)
+	if !ok || customer.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+	now := time.Now()
+	customer.DeletedAt = &now
+	return r.store.customers.replace(id, customer)
+}
+
+func (r *CustomerRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	customer, ok := r.store.customers.get(id)
+	if !ok || customer.DeletedAt == nil {
+		return repository.ErrNotFound
+	}
+	customer.DeletedAt = nil
+	return r.store.customers.replace(id, customer)
+}
@@ -0,0 +1,68 @@
+// repository/memory/exchange_rate_repository.go
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type ExchangeRateRepository struct {
+	store *Store
+}
+
+func (r *ExchangeRateRepository) Create(ctx context.Context, rate *models.ExchangeRate) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	_, taken := r.store.exchangeRates.first(func(e models.ExchangeRate) bool {
+		return e.FromCurrency == rate.FromCurrency && e.ToCurrency == rate.ToCurrency && e.EffectiveFrom.Equal(rate.EffectiveFrom)
+	})
+	if taken {
+		return repository.ErrDuplicate
+	}
+	return r.store.exchangeRates.insert(rate.ID, *rate)
+}
+
+func (r *ExchangeRateRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ExchangeRate, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	rate, ok := r.store.exchangeRates.get(id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &rate, nil
+}
+
+func (r *ExchangeRateRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.ExchangeRate], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return findPage(r.store.exchangeRates.find(nil), repository.ExchangeRateFields, query)
+}
+
+func (r *ExchangeRateRepository) Effective(ctx context.Context, from, to string, at time.Time) (*models.ExchangeRate, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	var effective *models.ExchangeRate
+	for _, rate := range r.store.exchangeRates.find(func(e models.ExchangeRate) bool {
+		return e.FromCurrency == from && e.ToCurrency == to && !e.EffectiveFrom.After(at)
+	}) {
+		if effective == nil || rate.EffectiveFrom.After(effective.EffectiveFrom) {
+			rate := rate
+			effective = &rate
+		}
+	}
+	if effective == nil {
+		return nil, repository.ErrNotFound
+	}
+	return effective, nil
+}
+
+func (r *ExchangeRateRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	return r.store.exchangeRates.delete(id)
+}
@@ -0,0 +1,183 @@
+// repository/memory/memory.go
+package memory
+
+import (
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// Store keeps every collection in process memory. A single lock guards all
+// tables so that the repositories built on it are safe for concurrent use.
+type Store struct {
+	mu   sync.RWMutex
+	txMu sync.Mutex
+
+	customers           *table[models.Customer]
+	customerAddresses   *table[models.CustomerAddress]
+	products            *table[models.Product]
+	paymentMethods      *table[models.PaymentMethod]
+	transactions        *table[models.Transaction]
+	transactionDetails  *table[models.TransactionDetail]
+	transactionPayments *table[models.TransactionPayment]
+	stockMovements      *table[models.StockMovement]
+	productVersions     *table[models.ProductVersion]
+	exchangeRates       *table[models.ExchangeRate]
+	promotions          *table[models.Promotion]
+	apiKeys             *table[models.APIKey]
+	auditLog            *table[models.AuditEntry]
+}
+
+func NewStore() *Store {
+	return &Store{
+		customers:           newTable[models.Customer](),
+		customerAddresses:   newTable[models.CustomerAddress](),
+		products:            newTable[models.Product](),
+		paymentMethods:      newTable[models.PaymentMethod](),
+		transactions:        newTable[models.Transaction](),
+		transactionDetails:  newTable[models.TransactionDetail](),
+		transactionPayments: newTable[models.TransactionPayment](),
+		stockMovements:      newTable[models.StockMovement](),
+		productVersions:     newTable[models.ProductVersion](),
+		exchangeRates:       newTable[models.ExchangeRate](),
+		promotions:          newTable[models.Promotion](),
+		apiKeys:             newTable[models.APIKey](),
+		auditLog:            newTable[models.AuditEntry](),
+	}
+}
+
+// NewRepositories returns in-memory implementations of every store backed
+// by a fresh, empty Store.
+func NewRepositories() *repository.Repositories {
+	return NewStore().Repositories()
+}
+
+func (s *Store) Repositories() *repository.Repositories {
+	return &repository.Repositories{
+		Customers:           &CustomerRepository{store: s},
+		CustomerAddresses:   &CustomerAddressRepository{store: s},
+		Products:            &ProductRepository{store: s},
+		PaymentMethods:      &PaymentMethodRepository{store: s},
+		Transactions:        &TransactionRepository{store: s},
+		TransactionDetails:  &TransactionDetailRepository{store: s},
+		TransactionPayments: &TransactionPaymentRepository{store: s},
+		StockMovements:      &StockMovementRepository{store: s},
+		ProductVersions:     &ProductVersionRepository{store: s},
+		ExchangeRates:       &ExchangeRateRepository{store: s},
+		Promotions:          &PromotionRepository{store: s},
+		APIKeys:             &APIKeyRepository{store: s},
+		AuditLog:            &AuditRepository{store: s},
+		UnitOfWork:          &UnitOfWork{store: s},
+	}
+}
+
+// table is an insertion-ordered collection of documents keyed by ID.
+type table[T any] struct {
+	rows  map[primitive.ObjectID]T
+	order []primitive.ObjectID
+}
+
+func newTable[T any]() *table[T] {
+	return &table[T]{rows: make(map[primitive.ObjectID]T)}
+}
+
+// idSet indexes ids for membership tests.
+func idSet(ids []primitive.ObjectID) map[primitive.ObjectID]bool {
+	set := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+func (t *table[T]) clone() *table[T] {
+	rows := make(map[primitive.ObjectID]T, len(t.rows))
+	for id, row := range t.rows {
+		rows[id] = row
+	}
+	return &table[T]{rows: rows, order: append([]primitive.ObjectID(nil), t.order...)}
+}
+
+func (t *table[T]) get(id primitive.ObjectID) (T, bool) {
+	row, ok := t.rows[id]
+	return row, ok
+}
+
+func (t *table[T]) insert(id primitive.ObjectID, row T) error {
+	if _, exists := t.rows[id]; exists {
+		return repository.ErrDuplicate
+	}
+	t.rows[id] = row
+	t.order = append(t.order, id)
+	return nil
+}
+
+func (t *table[T]) replace(id primitive.ObjectID, row T) error {
+	if _, exists := t.rows[id]; !exists {
+		return repository.ErrNotFound
+	}
+	t.rows[id] = row
+	return nil
+}
+
+func (t *table[T]) delete(id primitive.ObjectID) error {
+	if _, exists := t.rows[id]; !exists {
+		return repository.ErrNotFound
+	}
+	delete(t.rows, id)
+	for i, existing := range t.order {
+		if existing == id {
+			t.order = append(t.order[:i:i], t.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// find returns, in insertion order, every row accepted by match.
+func (t *table[T]) find(match func(T) bool) []T {
+	var rows []T
+	for _, id := range t.order {
+		row := t.rows[id]
+		if match == nil || match(row) {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func (t *table[T]) first(match func(T) bool) (T, bool) {
+	for _, id := range t.order {
+		if row := t.rows[id]; match(row) {
+			return row, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+func (t *table[T]) count(match func(T) bool) int64 {
+	var n int64
+	for _, row := range t.rows {
+		if match(row) {
+			n++
+		}
+	}
+	return n
+}
+
+// deleteWhere removes every row accepted by match.
+func (t *table[T]) deleteWhere(match func(T) bool) {
+	kept := t.order[:0]
+	for _, id := range t.order {
+		if match(t.rows[id]) {
+			delete(t.rows, id)
+			continue
+		}
+		kept = append(kept, id)
+	}
+	t.order = kept
+}
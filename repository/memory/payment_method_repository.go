@@ -0,0 +1,88 @@
+// repository/memory/payment_method_repository.go
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type PaymentMethodRepository struct {
+	store *Store
+}
+
+func (r *PaymentMethodRepository) Create(ctx context.Context, paymentMethod *models.PaymentMethod) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	return r.store.paymentMethods.insert(paymentMethod.ID, *paymentMethod)
+}
+
+func (r *PaymentMethodRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.PaymentMethod, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	paymentMethod, ok := r.store.paymentMethods.get(id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &paymentMethod, nil
+}
+
+func (r *PaymentMethodRepository) FindByName(ctx context.Context, name string) (*models.PaymentMethod, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	paymentMethod, ok := r.store.paymentMethods.first(func(p models.PaymentMethod) bool { return p.Name == name })
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &paymentMethod, nil
+}
+
+func (r *PaymentMethodRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.PaymentMethod], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return findPage(r.store.paymentMethods.find(nil), repository.PaymentMethodFields, query)
+}
+
+func (r *PaymentMethodRepository) ListByIDs(ctx context.Context, ids ...primitive.ObjectID) ([]models.PaymentMethod, error) {
+	wanted := idSet(ids)
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return r.store.paymentMethods.find(func(p models.PaymentMethod) bool { return wanted[p.ID] }), nil
+}
+
+func (r *PaymentMethodRepository) Update(ctx context.Context, paymentMethod *models.PaymentMethod) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	if stored, ok := r.store.paymentMethods.get(paymentMethod.ID); !ok || stored.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+	return r.store.paymentMethods.replace(paymentMethod.ID, *paymentMethod)
+}
+
+func (r *PaymentMethodRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	paymentMethod, ok := r.store.paymentMethods.get(id)
+	if !ok || paymentMethod.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+	now := time.Now()
+	paymentMethod.DeletedAt = &now
+	return r.store.paymentMethods.replace(id, paymentMethod)
+}
+
+func (r *PaymentMethodRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	paymentMethod, ok := r.store.paymentMethods.get(id)
+	if !ok || paymentMethod.DeletedAt == nil {
+		return repository.ErrNotFound
+	}
+	paymentMethod.DeletedAt = nil
+	return r.store.paymentMethods.replace(id, paymentMethod)
+}
@@ -0,0 +1,105 @@
+// repository/memory/product_repository.go
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type ProductRepository struct {
+	store *Store
+}
+
+func (r *ProductRepository) Create(ctx context.Context, product *models.Product) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	return r.store.products.insert(product.ID, *product)
+}
+
+func (r *ProductRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Product, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	product, ok := r.store.products.get(id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &product, nil
+}
+
+func (r *ProductRepository) FindByCode(ctx context.Context, code string) (*models.Product, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	product, ok := r.store.products.first(func(p models.Product) bool { return p.Code == code })
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &product, nil
+}
+
+func (r *ProductRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.Product], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return findPage(r.store.products.find(nil), repository.ProductFields, query)
+}
+
+func (r *ProductRepository) ListByIDs(ctx context.Context, ids ...primitive.ObjectID) ([]models.Product, error) {
+	wanted := idSet(ids)
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return r.store.products.find(func(p models.Product) bool { return wanted[p.ID] }), nil
+}
+
+func (r *ProductRepository) Update(ctx context.Context, product *models.Product) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	stored, ok := r.store.products.get(product.ID)
+	if !ok || stored.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+	updated := *product
+	updated.StockQuantity = stored.StockQuantity
+	return r.store.products.replace(product.ID, updated)
+}
+
+func (r *ProductRepository) AdjustStock(ctx context.Context, id primitive.ObjectID, delta float64) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	product, ok := r.store.products.get(id)
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if delta < 0 && product.AvailableQuantity() < -delta {
+		return repository.ErrInsufficientStock
+	}
+	product.StockQuantity += delta
+	return r.store.products.replace(id, product)
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	product, ok := r.store.products.get(id)
+	if !ok || product.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+	now := time.Now()
+	product.DeletedAt = &now
+	return r.store.products.replace(id, product)
+}
+
+func (r *ProductRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	product, ok := r.store.products.get(id)
+	if !ok || product.DeletedAt == nil {
+		return repository.ErrNotFound
+	}
+	product.DeletedAt = nil
+	return r.store.products.replace(id, product)
+}
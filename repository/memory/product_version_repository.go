@@ -0,0 +1,31 @@
+// repository/memory/product_version_repository.go
+package memory
+
+import (
+	"context"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type ProductVersionRepository struct {
+	store *Store
+}
+
+func (r *ProductVersionRepository) Create(ctx context.Context, version *models.ProductVersion) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	_, taken := r.store.productVersions.first(func(v models.ProductVersion) bool {
+		return v.ProductID == version.ProductID && v.Version == version.Version
+	})
+	if taken {
+		return repository.ErrDuplicate
+	}
+	return r.store.productVersions.insert(version.ID, *version)
+}
+
+func (r *ProductVersionRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.ProductVersion], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return findPage(r.store.productVersions.find(nil), repository.ProductVersionFields, query)
+}
@@ -0,0 +1,124 @@
+// repository/memory/promotion_repository.go
+package memory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type PromotionRepository struct {
+	store *Store
+}
+
+func (r *PromotionRepository) Create(ctx context.Context, promotion *models.Promotion) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	if r.codeTaken(promotion) {
+		return repository.ErrDuplicate
+	}
+	return r.store.promotions.insert(promotion.ID, *promotion)
+}
+
+// codeTaken reports whether another promotion has the coupon code of
+// promotion, like the unique index of the database backends.
+func (r *PromotionRepository) codeTaken(promotion *models.Promotion) bool {
+	if promotion.Code == "" {
+		return false
+	}
+	_, taken := r.store.promotions.first(func(p models.Promotion) bool {
+		return p.Code == promotion.Code && p.ID != promotion.ID
+	})
+	return taken
+}
+
+func (r *PromotionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Promotion, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	promotion, ok := r.store.promotions.get(id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &promotion, nil
+}
+
+func (r *PromotionRepository) FindByCode(ctx context.Context, code string) (*models.Promotion, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	promotion, ok := r.store.promotions.first(func(p models.Promotion) bool { return p.Code == code })
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &promotion, nil
+}
+
+func (r *PromotionRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.Promotion], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return findPage(r.store.promotions.find(nil), repository.PromotionFields, query)
+}
+
+func (r *PromotionRepository) Automatic(ctx context.Context, at time.Time) ([]models.Promotion, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return r.store.promotions.find(func(p models.Promotion) bool { return p.Code == "" && p.ActiveAt(at) }), nil
+}
+
+func (r *PromotionRepository) Update(ctx context.Context, promotion *models.Promotion) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	stored, ok := r.store.promotions.get(promotion.ID)
+	if !ok || stored.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+	if r.codeTaken(promotion) {
+		return repository.ErrDuplicate
+	}
+	updated := *promotion
+	updated.UsageCount = stored.UsageCount
+	return r.store.promotions.replace(promotion.ID, updated)
+}
+
+func (r *PromotionRepository) AdjustUsage(ctx context.Context, id primitive.ObjectID, delta int) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	promotion, ok := r.store.promotions.get(id)
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if delta > 0 && promotion.UsageLimit > 0 && promotion.UsageCount+delta > promotion.UsageLimit {
+		return repository.ErrUsageLimit
+	}
+	promotion.UsageCount += delta
+	return r.store.promotions.replace(id, promotion)
+}
+
+func (r *PromotionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	promotion, ok := r.store.promotions.get(id)
+	if !ok || promotion.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+	now := time.Now()
+	promotion.DeletedAt = &now
+	return r.store.promotions.replace(id, promotion)
+}
+
+func (r *PromotionRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	promotion, ok := r.store.promotions.get(id)
+	if !ok || promotion.DeletedAt == nil {
+		return repository.ErrNotFound
+	}
+	if r.codeTaken(&promotion) {
+		return repository.ErrDuplicate
+	}
+	promotion.DeletedAt = nil
+	return r.store.promotions.replace(id, promotion)
+}
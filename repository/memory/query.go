@@ -0,0 +1,106 @@
+// repository/memory/query.go
+package memory
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mifaabiyyu/go-test.git/money"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// findPage filters, orders and slices items the way the database backends
+// do, reading fields through the same whitelist.
+func findPage[T any](items []T, fields repository.Fields[T], q repository.ListQuery) (*repository.Page[T], error) {
+	q, err := fields.Prepare(q)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := items[:0:0]
+	for _, item := range items {
+		if matchesAll(item, fields, q.Filters) {
+			matched = append(matched, item)
+		}
+	}
+	total := int64(len(matched))
+
+	sortValue, id := fields[q.Sort.Field].Value, fields["id"].Value
+	compare := func(a, b T) int {
+		if c := repository.Compare(sortValue(a), sortValue(b)); c != 0 {
+			return c
+		}
+		return repository.Compare(id(a), id(b))
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		if q.Sort.Descending {
+			return compare(matched[j], matched[i]) < 0
+		}
+		return compare(matched[i], matched[j]) < 0
+	})
+
+	start := q.Offset
+	if q.After != nil {
+		start = int64(sort.Search(len(matched), func(i int) bool {
+			c := repository.Compare(sortValue(matched[i]), q.After.Value)
+			if c == 0 {
+				c = repository.Compare(id(matched[i]), q.After.ID)
+			}
+			if q.Sort.Descending {
+				return c < 0
+			}
+			return c > 0
+		}))
+	}
+	if start > total {
+		start = total
+	}
+	end := total
+	if q.Limit > 0 && start+q.Limit+1 < end {
+		end = start + q.Limit + 1
+	}
+
+	return repository.NewPage(matched[start:end], total, q, fields), nil
+}
+
+func matchesAll[T any](item T, fields repository.Fields[T], filters []repository.Filter) bool {
+	for _, filter := range filters {
+		value := fields[filter.Field].Value(item)
+		if filter.Op == repository.OpUnset {
+			if value != nil {
+				return false
+			}
+			continue
+		}
+		if filter.Op == repository.OpContains {
+			if !strings.Contains(strings.ToLower(value.(string)), strings.ToLower(filter.Value.(string))) {
+				return false
+			}
+			continue
+		}
+
+		// Amounts in another currency are neither above nor below
+		if amount, isMoney := filter.Value.(money.Money); isMoney && value.(money.Money).Currency() != amount.Currency() {
+			return false
+		}
+
+		c := repository.Compare(value, filter.Value)
+		var ok bool
+		switch filter.Op {
+		case repository.OpGt:
+			ok = c > 0
+		case repository.OpGte:
+			ok = c >= 0
+		case repository.OpLt:
+			ok = c < 0
+		case repository.OpLte:
+			ok = c <= 0
+		default:
+			ok = c == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
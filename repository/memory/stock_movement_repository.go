@@ -0,0 +1,42 @@
+// repository/memory/stock_movement_repository.go
+package memory
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type StockMovementRepository struct {
+	store *Store
+}
+
+func (r *StockMovementRepository) Create(ctx context.Context, movements ...*models.StockMovement) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	for _, movement := range movements {
+		if err := r.store.stockMovements.insert(movement.ID, *movement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *StockMovementRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.StockMovement], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return findPage(r.store.stockMovements.find(nil), repository.StockMovementFields, query)
+}
+
+func (r *StockMovementRepository) SumByProduct(ctx context.Context) (map[primitive.ObjectID]float64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	sums := make(map[primitive.ObjectID]float64)
+	for _, movement := range r.store.stockMovements.find(nil) {
+		sums[movement.ProductID] += movement.Quantity
+	}
+	return sums, nil
+}
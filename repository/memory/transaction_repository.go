@@ -0,0 +1,167 @@
+// repository/memory/transaction_repository.go
+package memory
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type TransactionRepository struct {
+	store *Store
+}
+
+func (r *TransactionRepository) Create(ctx context.Context, transaction *models.Transaction) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	return r.store.transactions.insert(transaction.ID, *transaction)
+}
+
+func (r *TransactionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Transaction, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	transaction, ok := r.store.transactions.get(id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &transaction, nil
+}
+
+func (r *TransactionRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.Transaction], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return findPage(r.store.transactions.find(nil), repository.TransactionFields, query)
+}
+
+func (r *TransactionRepository) Update(ctx context.Context, transaction *models.Transaction) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	return r.store.transactions.replace(transaction.ID, *transaction)
+}
+
+func (r *TransactionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	return r.store.transactions.delete(id)
+}
+
+func (r *TransactionRepository) CountByCustomer(ctx context.Context, customerID primitive.ObjectID) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return r.store.transactions.count(func(t models.Transaction) bool { return t.CustomerID == customerID }), nil
+}
+
+type TransactionDetailRepository struct {
+	store *Store
+}
+
+func (r *TransactionDetailRepository) Create(ctx context.Context, detail *models.TransactionDetail) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	return r.store.transactionDetails.insert(detail.ID, *detail)
+}
+
+func (r *TransactionDetailRepository) ListByTransaction(ctx context.Context, transactionID primitive.ObjectID) ([]models.TransactionDetail, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return r.store.transactionDetails.find(func(d models.TransactionDetail) bool { return d.TransactionID == transactionID }), nil
+}
+
+func (r *TransactionDetailRepository) ListByTransactions(ctx context.Context, transactionIDs ...primitive.ObjectID) ([]models.TransactionDetail, error) {
+	wanted := idSet(transactionIDs)
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return r.store.transactionDetails.find(func(d models.TransactionDetail) bool { return wanted[d.TransactionID] }), nil
+}
+
+func (r *TransactionDetailRepository) Update(ctx context.Context, detail *models.TransactionDetail) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	existing, ok := r.store.transactionDetails.get(detail.ID)
+	if !ok {
+		return repository.ErrNotFound
+	}
+	existing.Quantity = detail.Quantity
+	existing.Subtotal = detail.Subtotal
+	existing.Price = detail.Price
+	existing.DiscountAmount = detail.DiscountAmount
+	existing.Discounts = detail.Discounts
+	existing.TaxRate = detail.TaxRate
+	existing.TaxableAmount = detail.TaxableAmount
+	existing.TaxAmount = detail.TaxAmount
+	existing.Total = detail.Total
+	return r.store.transactionDetails.replace(detail.ID, existing)
+}
+
+func (r *TransactionDetailRepository) DeleteByTransaction(ctx context.Context, transactionID primitive.ObjectID) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	r.store.transactionDetails.deleteWhere(func(d models.TransactionDetail) bool { return d.TransactionID == transactionID })
+	return nil
+}
+
+func (r *TransactionDetailRepository) CountByProduct(ctx context.Context, productID primitive.ObjectID) (int64, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return r.store.transactionDetails.count(func(d models.TransactionDetail) bool { return d.ProductID == productID }), nil
+}
+
+type TransactionPaymentRepository struct {
+	store *Store
+}
+
+func (r *TransactionPaymentRepository) Create(ctx context.Context, payment *models.TransactionPayment) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	return r.store.transactionPayments.insert(payment.ID, *payment)
+}
+
+func (r *TransactionPaymentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.TransactionPayment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	payment, ok := r.store.transactionPayments.get(id)
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &payment, nil
+}
+
+func (r *TransactionPaymentRepository) ListByTransaction(ctx context.Context, transactionID primitive.ObjectID) ([]models.TransactionPayment, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return r.store.transactionPayments.find(func(p models.TransactionPayment) bool { return p.TransactionID == transactionID }), nil
+}
+
+func (r *TransactionPaymentRepository) ListByTransactions(ctx context.Context, transactionIDs ...primitive.ObjectID) ([]models.TransactionPayment, error) {
+	wanted := idSet(transactionIDs)
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return r.store.transactionPayments.find(func(p models.TransactionPayment) bool { return wanted[p.TransactionID] }), nil
+}
+
+func (r *TransactionPaymentRepository) Update(ctx context.Context, payment *models.TransactionPayment) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	existing, ok := r.store.transactionPayments.get(payment.ID)
+	if !ok {
+		return repository.ErrNotFound
+	}
+	existing.Status = payment.Status
+	existing.PaidAmount = payment.PaidAmount
+	existing.SettledAmount = payment.SettledAmount
+	existing.ExchangeRate = payment.ExchangeRate
+	existing.PaymentDate = payment.PaymentDate
+	return r.store.transactionPayments.replace(payment.ID, existing)
+}
+
+func (r *TransactionPaymentRepository) DeleteByTransaction(ctx context.Context, transactionID primitive.ObjectID) error {
+	unlock := r.store.write(ctx)
+	defer unlock()
+	r.store.transactionPayments.deleteWhere(func(p models.TransactionPayment) bool { return p.TransactionID == transactionID })
+	return nil
+}
@@ -0,0 +1,87 @@
+// repository/memory/unit_of_work.go
+package memory
+
+import "context"
+
+type txKey struct{}
+
+// UnitOfWork gives the in-memory store all-or-nothing semantics by taking
+// a snapshot of every table before fn runs and restoring it on failure.
+// Transactions are serialised with one another, and writes made outside a
+// transaction wait for the running one to finish, so a rollback only ever
+// undoes the transaction's own writes.
+type UnitOfWork struct {
+	store *Store
+}
+
+func (u *UnitOfWork) WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx)
+	}
+
+	u.store.txMu.Lock()
+	defer u.store.txMu.Unlock()
+
+	snapshot := u.store.snapshot()
+	if err := fn(context.WithValue(ctx, txKey{}, true)); err != nil {
+		u.store.restore(snapshot)
+		return err
+	}
+	return nil
+}
+
+// write locks the store for a write made with ctx and returns the function
+// that unlocks it. A write outside a transaction also takes txMu, waiting
+// for a running transaction to commit or roll back first.
+func (s *Store) write(ctx context.Context) (unlock func()) {
+	if ctx.Value(txKey{}) != nil {
+		s.mu.Lock()
+		return s.mu.Unlock
+	}
+	s.txMu.Lock()
+	s.mu.Lock()
+	return func() {
+		s.mu.Unlock()
+		s.txMu.Unlock()
+	}
+}
+
+func (s *Store) snapshot() *Store {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return &Store{
+		customers:           s.customers.clone(),
+		customerAddresses:   s.customerAddresses.clone(),
+		products:            s.products.clone(),
+		paymentMethods:      s.paymentMethods.clone(),
+		transactions:        s.transactions.clone(),
+		transactionDetails:  s.transactionDetails.clone(),
+		transactionPayments: s.transactionPayments.clone(),
+		stockMovements:      s.stockMovements.clone(),
+		productVersions:     s.productVersions.clone(),
+		exchangeRates:       s.exchangeRates.clone(),
+		promotions:          s.promotions.clone(),
+		apiKeys:             s.apiKeys.clone(),
+		auditLog:            s.auditLog.clone(),
+	}
+}
+
+func (s *Store) restore(snapshot *Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.customers = snapshot.customers
+	s.customerAddresses = snapshot.customerAddresses
+	s.products = snapshot.products
+	s.paymentMethods = snapshot.paymentMethods
+	s.transactions = snapshot.transactions
+	s.transactionDetails = snapshot.transactionDetails
+	s.transactionPayments = snapshot.transactionPayments
+	s.stockMovements = snapshot.stockMovements
+	s.productVersions = snapshot.productVersions
+	s.exchangeRates = snapshot.exchangeRates
+	s.promotions = snapshot.promotions
+	s.apiKeys = snapshot.apiKeys
+	s.auditLog = snapshot.auditLog
+}
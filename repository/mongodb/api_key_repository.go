@@ -0,0 +1,46 @@
+// repository/mongodb/api_key_repository.go
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type APIKeyRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewAPIKeyRepository(db *mongo.Database) *APIKeyRepository {
+	return &APIKeyRepository{
+		Collection: db.Collection("api_keys"),
+	}
+}
+
+// Create relies on a unique index on hash to reject a key that is already
+// stored.
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	return insertOne(ctx, r.Collection, key)
+}
+
+func (r *APIKeyRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.APIKey, error) {
+	return findOne[models.APIKey](ctx, r.Collection, bson.M{"_id": id})
+}
+
+func (r *APIKeyRepository) FindByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return findOne[models.APIKey](ctx, r.Collection, bson.M{"hash": hash})
+}
+
+func (r *APIKeyRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.APIKey], error) {
+	return findPage(ctx, r.Collection, repository.APIKeyFields, query)
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	return updateOne(ctx, r.Collection, bson.M{"_id": id, "revoked_at": nil}, bson.M{"$set": bson.M{"revoked_at": at}})
+}
@@ -0,0 +1,29 @@
+// repository/mongodb/audit_repository.go
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type AuditRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewAuditRepository(db *mongo.Database) *AuditRepository {
+	return &AuditRepository{
+		Collection: db.Collection("audit_log"),
+	}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	return insertOne(ctx, r.Collection, entry)
+}
+
+func (r *AuditRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.AuditEntry], error) {
+	return findPage(ctx, r.Collection, repository.AuditFields, query)
+}
@@ -0,0 +1,55 @@
+// repository/mongodb/customer_address_repository.go
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type CustomerAddressRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewCustomerAddressRepository(db *mongo.Database) *CustomerAddressRepository {
+	return &CustomerAddressRepository{
+		Collection: db.Collection("customer_address"),
+	}
+}
+
+func (r *CustomerAddressRepository) Create(ctx context.Context, address *models.CustomerAddress) error {
+	return insertOne(ctx, r.Collection, address)
+}
+
+func (r *CustomerAddressRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.CustomerAddress, error) {
+	return findOne[models.CustomerAddress](ctx, r.Collection, bson.M{"_id": id})
+}
+
+func (r *CustomerAddressRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.CustomerAddress], error) {
+	return findPage(ctx, r.Collection, repository.CustomerAddressFields, query)
+}
+
+func (r *CustomerAddressRepository) ListByCustomers(ctx context.Context, customerIDs ...primitive.ObjectID) ([]models.CustomerAddress, error) {
+	if len(customerIDs) == 0 {
+		return nil, nil
+	}
+	return findAll[models.CustomerAddress](ctx, r.Collection, bson.M{"customer_id": bson.M{"$in": customerIDs}})
+}
+
+func (r *CustomerAddressRepository) Update(ctx context.Context, address *models.CustomerAddress) error {
+	return replaceOne(ctx, r.Collection, bson.M{"_id": address.ID}, address)
+}
+
+func (r *CustomerAddressRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return deleteOne(ctx, r.Collection, bson.M{"_id": id})
+}
+
+func (r *CustomerAddressRepository) DeleteByCustomer(ctx context.Context, customerID primitive.ObjectID) error {
+	_, err := r.Collection.DeleteMany(ctx, bson.M{"customer_id": customerID})
+	return err
+}
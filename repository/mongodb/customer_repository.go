@@ -0,0 +1,51 @@
+// repository/mongodb/customer_repository.go
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type CustomerRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewCustomerRepository(db *mongo.Database) *CustomerRepository {
+	return &CustomerRepository{
+		Collection: db.Collection("customers"),
+	}
+}
+
+func (r *CustomerRepository) Create(ctx context.Context, customer *models.Customer) error {
+	return insertOne(ctx, r.Collection, customer)
+}
+
+func (r *CustomerRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Customer, error) {
+	return findOne[models.Customer](ctx, r.Collection, bson.M{"_id": id})
+}
+
+func (r *CustomerRepository) FindByEmail(ctx context.Context, email string) (*models.Customer, error) {
+	return findOne[models.Customer](ctx, r.Collection, bson.M{"email": email})
+}
+
+func (r *CustomerRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.Customer], error) {
+	return findPage(ctx, r.Collection, repository.CustomerFields, query)
+}
+
+func (r *CustomerRepository) Update(ctx context.Context, customer *models.Customer) error {
+	return replaceOne(ctx, r.Collection, notDeleted(customer.ID), customer)
+}
+
+func (r *CustomerRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return softDelete(ctx, r.Collection, id)
+}
+
+func (r *CustomerRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	return restore(ctx, r.Collection, id)
+}
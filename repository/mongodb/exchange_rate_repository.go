@@ -0,0 +1,54 @@
+// repository/mongodb/exchange_rate_repository.go
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type ExchangeRateRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewExchangeRateRepository(db *mongo.Database) *ExchangeRateRepository {
+	return &ExchangeRateRepository{
+		Collection: db.Collection("exchange_rates"),
+	}
+}
+
+// Create relies on a unique index on the pair and effective_from to reject
+// a second rate taking effect at the same time.
+func (r *ExchangeRateRepository) Create(ctx context.Context, rate *models.ExchangeRate) error {
+	return insertOne(ctx, r.Collection, rate)
+}
+
+func (r *ExchangeRateRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ExchangeRate, error) {
+	return findOne[models.ExchangeRate](ctx, r.Collection, bson.M{"_id": id})
+}
+
+func (r *ExchangeRateRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.ExchangeRate], error) {
+	return findPage(ctx, r.Collection, repository.ExchangeRateFields, query)
+}
+
+func (r *ExchangeRateRepository) Effective(ctx context.Context, from, to string, at time.Time) (*models.ExchangeRate, error) {
+	filter := bson.M{"from_currency": from, "to_currency": to, "effective_from": bson.M{"$lte": at}}
+	latest := options.FindOne().SetSort(bson.D{{Key: "effective_from", Value: -1}})
+
+	var rate models.ExchangeRate
+	if err := r.Collection.FindOne(ctx, filter, latest).Decode(&rate); err != nil {
+		return nil, translateError(err)
+	}
+	return &rate, nil
+}
+
+func (r *ExchangeRateRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return deleteOne(ctx, r.Collection, bson.M{"_id": id})
+}
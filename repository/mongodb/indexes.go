@@ -0,0 +1,44 @@
+// repository/mongodb/indexes.go
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// uniqueIndexes lists, per collection, the keys that must be unique, and
+// for a partial index the documents it covers.
+var uniqueIndexes = []struct {
+	collection string
+	keys       bson.D
+	partial    bson.M
+}{
+	{"product_versions", bson.D{{Key: "product_id", Value: 1}, {Key: "version", Value: 1}}, nil},
+	{"api_keys", bson.D{{Key: "hash", Value: 1}}, nil},
+	{"exchange_rates", bson.D{{Key: "from_currency", Value: 1}, {Key: "to_currency", Value: 1}, {Key: "effective_from", Value: 1}}, nil},
+	// Only coupon codes are unique; promotions without one leave it out
+	{"promotions", bson.D{{Key: "code", Value: 1}}, bson.M{"code": bson.M{"$gt": ""}}},
+}
+
+// EnsureIndexes creates the indexes the repositories rely on for
+// uniqueness. Creating an index that already exists is a no-op, so it is
+// safe to call on every start.
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	for _, index := range uniqueIndexes {
+		opts := options.Index().SetUnique(true)
+		if index.partial != nil {
+			opts.SetPartialFilterExpression(index.partial)
+		}
+		_, err := db.Collection(index.collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    index.keys,
+			Options: opts,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
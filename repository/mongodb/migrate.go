@@ -0,0 +1,118 @@
+package mongodb
+
+import (
+	"context"
+	"math"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/mifaabiyyu/go-test.git/money"
+)
+
+// floatAmounts lists, per collection, the fields that held amounts as
+// plain numbers, in major units of the default currency, before amounts
+// were stored as minor units and a currency.
+var floatAmounts = []struct {
+	collection string
+	fields     []string
+}{
+	{"products", []string{"price"}},
+	{"transactions", []string{"total_amount"}},
+	{"transaction_details", []string{"subtotal", "price"}},
+	{"transaction_payments", []string{"paid_amount"}},
+	{"product_versions", []string{"price"}},
+}
+
+// MigrateAmounts rewrites amounts stored as plain numbers into the
+// {minor, currency} documents money.Money is stored as, so lists filter
+// and sort them along with newer ones. Documents already migrated are
+// left alone, so it is safe to call on every start.
+func MigrateAmounts(ctx context.Context, db *mongo.Database) error {
+	exponent, _ := money.Exponent(money.DefaultCurrency)
+	for _, amounts := range floatAmounts {
+		collection := db.Collection(amounts.collection)
+		for _, field := range amounts.fields {
+			minor := bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{"$" + field, math.Pow10(exponent)}}, 0}}}
+			_, err := collection.UpdateMany(ctx,
+				bson.M{field: bson.M{"$type": "number"}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.M{field: bson.M{"minor": minor, "currency": money.DefaultCurrency}}}}},
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// BackfillCurrencies fills in the currency of transactions, and the
+// settled amount and exchange rate of payments, stored before
+// transactions had a currency of their own: it is the currency of their
+// total, which their payments were always in. Run it after
+// MigrateAmounts.
+func BackfillCurrencies(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("transactions").UpdateMany(ctx,
+		bson.M{"currency": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"currency": "$total_amount.currency"}}}},
+	)
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("transaction_payments").UpdateMany(ctx,
+		bson.M{"settled_amount": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"settled_amount": "$paid_amount", "exchange_rate": 1}}}},
+	)
+	return err
+}
+
+// BackfillTaxes fills in the tax breakdown of transactions and details
+// stored before taxes were charged: untaxed, their subtotal and total are
+// the amounts they were sold for. Run it after MigrateAmounts.
+func BackfillTaxes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("transactions").UpdateMany(ctx,
+		bson.M{"subtotal": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"subtotal":   "$total_amount",
+			"tax_amount": bson.M{"minor": int64(0), "currency": "$total_amount.currency"},
+			"taxes":      bson.A{},
+		}}}},
+	)
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("transaction_details").UpdateMany(ctx,
+		bson.M{"total": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"tax_rate":       0,
+			"taxable_amount": "$subtotal",
+			"tax_amount":     bson.M{"minor": int64(0), "currency": "$subtotal.currency"},
+			"total":          "$subtotal",
+		}}}},
+	)
+	return err
+}
+
+// BackfillDiscounts sets the discount of transactions and details stored
+// before promotions to nothing, in their currency. Run it after
+// MigrateAmounts.
+func BackfillDiscounts(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("transactions").UpdateMany(ctx,
+		bson.M{"discount_amount": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"discount_amount": bson.M{"minor": int64(0), "currency": "$total_amount.currency"},
+			"discounts":       bson.A{},
+		}}}},
+	)
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("transaction_details").UpdateMany(ctx,
+		bson.M{"discount_amount": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"discount_amount": bson.M{"minor": int64(0), "currency": "$subtotal.currency"},
+			"discounts":       bson.A{},
+		}}}},
+	)
+	return err
+}
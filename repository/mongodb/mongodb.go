@@ -0,0 +1,141 @@
+// repository/mongodb/mongodb.go
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// NewRepositories returns MongoDB-backed implementations of every store,
+// all sharing the given database.
+func NewRepositories(db *mongo.Database) *repository.Repositories {
+	return &repository.Repositories{
+		Customers:           NewCustomerRepository(db),
+		CustomerAddresses:   NewCustomerAddressRepository(db),
+		Products:            NewProductRepository(db),
+		PaymentMethods:      NewPaymentMethodRepository(db),
+		Transactions:        NewTransactionRepository(db),
+		TransactionDetails:  NewTransactionDetailRepository(db),
+		TransactionPayments: NewTransactionPaymentRepository(db),
+		StockMovements:      NewStockMovementRepository(db),
+		ProductVersions:     NewProductVersionRepository(db),
+		ExchangeRates:       NewExchangeRateRepository(db),
+		Promotions:          NewPromotionRepository(db),
+		APIKeys:             NewAPIKeyRepository(db),
+		AuditLog:            NewAuditRepository(db),
+		UnitOfWork:          NewUnitOfWork(db.Client()),
+	}
+}
+
+// translateError maps driver errors onto the repository sentinel errors.
+func translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return repository.ErrNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return repository.ErrDuplicate
+	default:
+		return err
+	}
+}
+
+func findOne[T any](ctx context.Context, collection *mongo.Collection, filter interface{}) (*T, error) {
+	var document T
+	if err := collection.FindOne(ctx, filter).Decode(&document); err != nil {
+		return nil, translateError(err)
+	}
+	return &document, nil
+}
+
+func findAll[T any](ctx context.Context, collection *mongo.Collection, filter interface{}, opts ...*options.FindOptions) ([]T, error) {
+	cursor, err := collection.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var documents []T
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+func insertOne(ctx context.Context, collection *mongo.Collection, document interface{}) error {
+	_, err := collection.InsertOne(ctx, document)
+	return translateError(err)
+}
+
+func replaceOne(ctx context.Context, collection *mongo.Collection, filter interface{}, document interface{}) error {
+	result, err := collection.ReplaceOne(ctx, filter, document)
+	if err != nil {
+		return translateError(err)
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func updateOne(ctx context.Context, collection *mongo.Collection, filter interface{}, update interface{}) error {
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return translateError(err)
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// toFields converts a document to the fields it is stored with, so that
+// an update can set all but some of them.
+func toFields(document interface{}) (bson.M, error) {
+	data, err := bson.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	var fields bson.M
+	if err := bson.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// notDeleted matches the document with the given ID unless it is
+// soft-deleted.
+func notDeleted(id primitive.ObjectID) bson.M {
+	return bson.M{"_id": id, "deleted_at": nil}
+}
+
+// softDelete marks a document as deleted.
+func softDelete(ctx context.Context, collection *mongo.Collection, id primitive.ObjectID) error {
+	return updateOne(ctx, collection, notDeleted(id), bson.M{"$set": bson.M{"deleted_at": time.Now()}})
+}
+
+// restore clears the deletion mark of a soft-deleted document.
+func restore(ctx context.Context, collection *mongo.Collection, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}}
+	return updateOne(ctx, collection, filter, bson.M{"$unset": bson.M{"deleted_at": ""}})
+}
+
+func deleteOne(ctx context.Context, collection *mongo.Collection, filter interface{}) error {
+	result, err := collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// repository/mongodb/payment_method_repository.go
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type PaymentMethodRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewPaymentMethodRepository(db *mongo.Database) *PaymentMethodRepository {
+	return &PaymentMethodRepository{
+		Collection: db.Collection("payment_methods"),
+	}
+}
+
+func (r *PaymentMethodRepository) Create(ctx context.Context, paymentMethod *models.PaymentMethod) error {
+	return insertOne(ctx, r.Collection, paymentMethod)
+}
+
+func (r *PaymentMethodRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.PaymentMethod, error) {
+	return findOne[models.PaymentMethod](ctx, r.Collection, bson.M{"_id": id})
+}
+
+func (r *PaymentMethodRepository) FindByName(ctx context.Context, name string) (*models.PaymentMethod, error) {
+	return findOne[models.PaymentMethod](ctx, r.Collection, bson.M{"name": name})
+}
+
+func (r *PaymentMethodRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.PaymentMethod], error) {
+	return findPage(ctx, r.Collection, repository.PaymentMethodFields, query)
+}
+
+func (r *PaymentMethodRepository) ListByIDs(ctx context.Context, ids ...primitive.ObjectID) ([]models.PaymentMethod, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return findAll[models.PaymentMethod](ctx, r.Collection, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+func (r *PaymentMethodRepository) Update(ctx context.Context, paymentMethod *models.PaymentMethod) error {
+	return replaceOne(ctx, r.Collection, notDeleted(paymentMethod.ID), paymentMethod)
+}
+
+func (r *PaymentMethodRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return softDelete(ctx, r.Collection, id)
+}
+
+func (r *PaymentMethodRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	return restore(ctx, r.Collection, id)
+}
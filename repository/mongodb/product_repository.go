@@ -0,0 +1,92 @@
+// repository/mongodb/product_repository.go
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type ProductRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewProductRepository(db *mongo.Database) *ProductRepository {
+	return &ProductRepository{
+		Collection: db.Collection("products"),
+	}
+}
+
+func (r *ProductRepository) Create(ctx context.Context, product *models.Product) error {
+	return insertOne(ctx, r.Collection, product)
+}
+
+func (r *ProductRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Product, error) {
+	return findOne[models.Product](ctx, r.Collection, bson.M{"_id": id})
+}
+
+func (r *ProductRepository) FindByCode(ctx context.Context, code string) (*models.Product, error) {
+	return findOne[models.Product](ctx, r.Collection, bson.M{"code": code})
+}
+
+func (r *ProductRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.Product], error) {
+	return findPage(ctx, r.Collection, repository.ProductFields, query)
+}
+
+func (r *ProductRepository) ListByIDs(ctx context.Context, ids ...primitive.ObjectID) ([]models.Product, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return findAll[models.Product](ctx, r.Collection, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+func (r *ProductRepository) Update(ctx context.Context, product *models.Product) error {
+	fields, err := toFields(product)
+	if err != nil {
+		return err
+	}
+	delete(fields, "_id")
+	delete(fields, "stock_quantity")
+	return updateOne(ctx, r.Collection, notDeleted(product.ID), bson.M{"$set": fields})
+}
+
+func (r *ProductRepository) AdjustStock(ctx context.Context, id primitive.ObjectID, delta float64) error {
+	filter := bson.M{"_id": id}
+	if delta < 0 {
+		available := bson.M{"$subtract": bson.A{
+			bson.M{"$ifNull": bson.A{"$stock_quantity", 0}},
+			bson.M{"$ifNull": bson.A{"$reserved_quantity", 0}},
+		}}
+		filter["$expr"] = bson.M{"$gte": bson.A{available, -delta}}
+	}
+	result, err := r.Collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"stock_quantity": delta}})
+	if err != nil {
+		return translateError(err)
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	// Tell a missing product from one without enough stock
+	count, err := r.Collection.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return repository.ErrNotFound
+	}
+	return repository.ErrInsufficientStock
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return softDelete(ctx, r.Collection, id)
+}
+
+func (r *ProductRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	return restore(ctx, r.Collection, id)
+}
@@ -0,0 +1,32 @@
+// repository/mongodb/product_version_repository.go
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type ProductVersionRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewProductVersionRepository(db *mongo.Database) *ProductVersionRepository {
+	return &ProductVersionRepository{
+		Collection: db.Collection("product_versions"),
+	}
+}
+
+// Create relies on a unique index on {product_id, version} to reject a
+// version number that is already taken.
+func (r *ProductVersionRepository) Create(ctx context.Context, version *models.ProductVersion) error {
+	_, err := r.Collection.InsertOne(ctx, version)
+	return translateError(err)
+}
+
+func (r *ProductVersionRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.ProductVersion], error) {
+	return findPage(ctx, r.Collection, repository.ProductVersionFields, query)
+}
@@ -0,0 +1,117 @@
+// repository/mongodb/promotion_repository.go
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+// optionalPromotionFields are the fields a promotion leaves out when they
+// are empty. Update unsets the ones it leaves out, so that a cleared field
+// does not keep its stored value.
+var optionalPromotionFields = []string{"code", "product_ids", "percent", "buy_quantity", "get_quantity", "starts_at", "ends_at"}
+
+type PromotionRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewPromotionRepository(db *mongo.Database) *PromotionRepository {
+	return &PromotionRepository{
+		Collection: db.Collection("promotions"),
+	}
+}
+
+func (r *PromotionRepository) Create(ctx context.Context, promotion *models.Promotion) error {
+	return insertOne(ctx, r.Collection, promotion)
+}
+
+func (r *PromotionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Promotion, error) {
+	return findOne[models.Promotion](ctx, r.Collection, bson.M{"_id": id})
+}
+
+func (r *PromotionRepository) FindByCode(ctx context.Context, code string) (*models.Promotion, error) {
+	return findOne[models.Promotion](ctx, r.Collection, bson.M{"code": code})
+}
+
+func (r *PromotionRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.Promotion], error) {
+	return findPage(ctx, r.Collection, repository.PromotionFields, query)
+}
+
+func (r *PromotionRepository) Automatic(ctx context.Context, at time.Time) ([]models.Promotion, error) {
+	filter := bson.M{
+		"code":       bson.M{"$in": bson.A{nil, ""}},
+		"is_active":  true,
+		"deleted_at": nil,
+		"$and": bson.A{
+			bson.M{"$or": bson.A{bson.M{"starts_at": nil}, bson.M{"starts_at": bson.M{"$lte": at}}}},
+			bson.M{"$or": bson.A{bson.M{"ends_at": nil}, bson.M{"ends_at": bson.M{"$gt": at}}}},
+		},
+	}
+	return findAll[models.Promotion](ctx, r.Collection, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+}
+
+func (r *PromotionRepository) Update(ctx context.Context, promotion *models.Promotion) error {
+	fields, err := toFields(promotion)
+	if err != nil {
+		return err
+	}
+	delete(fields, "_id")
+	delete(fields, "usage_count")
+	update := bson.M{"$set": fields}
+
+	unset := bson.M{}
+	for _, field := range optionalPromotionFields {
+		if _, ok := fields[field]; !ok {
+			unset[field] = ""
+		}
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return updateOne(ctx, r.Collection, notDeleted(promotion.ID), update)
+}
+
+func (r *PromotionRepository) AdjustUsage(ctx context.Context, id primitive.ObjectID, delta int) error {
+	filter := bson.M{"_id": id}
+	if delta > 0 {
+		count := bson.M{"$ifNull": bson.A{"$usage_count", 0}}
+		limit := bson.M{"$ifNull": bson.A{"$usage_limit", 0}}
+		filter["$expr"] = bson.M{"$or": bson.A{
+			bson.M{"$eq": bson.A{limit, 0}},
+			bson.M{"$lte": bson.A{bson.M{"$add": bson.A{count, delta}}, limit}},
+		}}
+	}
+	result, err := r.Collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"usage_count": delta}})
+	if err != nil {
+		return translateError(err)
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	// Tell a missing promotion from one used up
+	count, err := r.Collection.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return repository.ErrNotFound
+	}
+	return repository.ErrUsageLimit
+}
+
+func (r *PromotionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return softDelete(ctx, r.Collection, id)
+}
+
+func (r *PromotionRepository) Restore(ctx context.Context, id primitive.ObjectID) error {
+	return restore(ctx, r.Collection, id)
+}
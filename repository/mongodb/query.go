@@ -0,0 +1,140 @@
+// repository/mongodb/query.go
+package mongodb
+
+import (
+	"context"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/mifaabiyyu/go-test.git/money"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+var operators = map[repository.Operator]string{
+	repository.OpEq:  "$eq",
+	repository.OpGt:  "$gt",
+	repository.OpGte: "$gte",
+	repository.OpLt:  "$lt",
+	repository.OpLte: "$lte",
+}
+
+// key returns the document key a listable field is stored under.
+func key[T any](fields repository.Fields[T], field string) string {
+	switch {
+	case field == "id":
+		return "_id"
+	case fields[field].Kind == repository.MoneyField:
+		return field + ".minor"
+	}
+	return field
+}
+
+// stored converts a filter or cursor value to the form stored under its
+// key.
+func stored(value interface{}) interface{} {
+	if amount, ok := value.(money.Money); ok {
+		return amount.Minor()
+	}
+	return value
+}
+
+// findPage runs a list query, counting every match and fetching one item
+// beyond the page so the next cursor can be set.
+func findPage[T any](ctx context.Context, collection *mongo.Collection, fields repository.Fields[T], q repository.ListQuery) (*repository.Page[T], error) {
+	q, err := fields.Prepare(q)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := bson.A{}
+	for _, filter := range q.Filters {
+		switch filter.Op {
+		case repository.OpUnset:
+			// Matches both null and missing fields
+			conditions = append(conditions, bson.M{key(fields, filter.Field): nil})
+			continue
+		case repository.OpContains:
+			pattern := containsPattern(filter.Value.(string))
+			conditions = append(conditions, bson.M{key(fields, filter.Field): pattern})
+			continue
+		}
+		if amount, ok := filter.Value.(money.Money); ok {
+			conditions = append(conditions, bson.M{filter.Field + ".currency": amount.Currency()})
+		}
+		conditions = append(conditions, bson.M{key(fields, filter.Field): bson.M{operators[filter.Op]: stored(filter.Value)}})
+	}
+	filter := bson.M{}
+	if len(conditions) > 0 {
+		filter["$and"] = conditions
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	direction, after := 1, "$gt"
+	if q.Sort.Descending {
+		direction, after = -1, "$lt"
+	}
+	var keys []string
+	var values []interface{}
+	if q.Sort.Field != "id" {
+		keys, values = sortKeys(fields, q.Sort.Field, q.After)
+	}
+	order := bson.D{}
+	for _, sortKey := range keys {
+		order = append(order, bson.E{Key: sortKey, Value: direction})
+	}
+	order = append(order, bson.E{Key: "_id", Value: direction})
+	opts := options.Find().SetSort(order)
+
+	if q.After != nil {
+		// Documents after the cursor's in the order of every sort key in turn
+		keyset := bson.M{"_id": bson.M{after: q.After.ID}}
+		for i := len(keys) - 1; i >= 0; i-- {
+			keyset = bson.M{"$or": bson.A{
+				bson.M{keys[i]: bson.M{after: values[i]}},
+				bson.M{"$and": bson.A{bson.M{keys[i]: values[i]}, keyset}},
+			}}
+		}
+		filter = bson.M{"$and": bson.A{filter, keyset}}
+	} else if q.Offset > 0 {
+		opts.SetSkip(q.Offset)
+	}
+	if q.Limit > 0 {
+		opts.SetLimit(q.Limit + 1)
+	}
+
+	items, err := findAll[T](ctx, collection, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	return repository.NewPage(items, total, q, fields), nil
+}
+
+// sortKeys returns the keys a list sorted by field is ordered by, ahead
+// of _id, with the stored value of each at cursor when there is one.
+// Amounts of money are ordered by currency and then by minor units.
+func sortKeys[T any](fields repository.Fields[T], field string, cursor *repository.Cursor) ([]string, []interface{}) {
+	var value interface{}
+	if cursor != nil {
+		value = cursor.Value
+	}
+	if fields[field].Kind != repository.MoneyField {
+		return []string{key(fields, field)}, []interface{}{stored(value)}
+	}
+	var currency interface{}
+	if amount, ok := value.(money.Money); ok {
+		currency = amount.Currency()
+	}
+	return []string{field + ".currency", key(fields, field)}, []interface{}{currency, stored(value)}
+}
+
+// containsPattern matches strings containing s, ignoring case.
+func containsPattern(s string) bson.M {
+	return bson.M{"$regex": regexp.QuoteMeta(s), "$options": "i"}
+}
@@ -0,0 +1,62 @@
+// repository/mongodb/stock_movement_repository.go
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type StockMovementRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewStockMovementRepository(db *mongo.Database) *StockMovementRepository {
+	return &StockMovementRepository{
+		Collection: db.Collection("stock_movements"),
+	}
+}
+
+func (r *StockMovementRepository) Create(ctx context.Context, movements ...*models.StockMovement) error {
+	if len(movements) == 0 {
+		return nil
+	}
+	documents := make([]interface{}, len(movements))
+	for i, movement := range movements {
+		documents[i] = movement
+	}
+	_, err := r.Collection.InsertMany(ctx, documents)
+	return translateError(err)
+}
+
+func (r *StockMovementRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.StockMovement], error) {
+	return findPage(ctx, r.Collection, repository.StockMovementFields, query)
+}
+
+func (r *StockMovementRepository) SumByProduct(ctx context.Context) (map[primitive.ObjectID]float64, error) {
+	cursor, err := r.Collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$product_id", "quantity": bson.M{"$sum": "$quantity"}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var totals []struct {
+		ProductID primitive.ObjectID `bson:"_id"`
+		Quantity  float64            `bson:"quantity"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil {
+		return nil, err
+	}
+	sums := make(map[primitive.ObjectID]float64, len(totals))
+	for _, total := range totals {
+		sums[total.ProductID] = total.Quantity
+	}
+	return sums, nil
+}
@@ -0,0 +1,145 @@
+// repository/mongodb/transaction_repository.go
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+)
+
+type TransactionRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewTransactionRepository(db *mongo.Database) *TransactionRepository {
+	return &TransactionRepository{
+		Collection: db.Collection("transactions"),
+	}
+}
+
+func (r *TransactionRepository) Create(ctx context.Context, transaction *models.Transaction) error {
+	return insertOne(ctx, r.Collection, transaction)
+}
+
+func (r *TransactionRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Transaction, error) {
+	return findOne[models.Transaction](ctx, r.Collection, bson.M{"_id": id})
+}
+
+func (r *TransactionRepository) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.Transaction], error) {
+	return findPage(ctx, r.Collection, repository.TransactionFields, query)
+}
+
+func (r *TransactionRepository) Update(ctx context.Context, transaction *models.Transaction) error {
+	return replaceOne(ctx, r.Collection, bson.M{"_id": transaction.ID}, transaction)
+}
+
+func (r *TransactionRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return deleteOne(ctx, r.Collection, bson.M{"_id": id})
+}
+
+func (r *TransactionRepository) CountByCustomer(ctx context.Context, customerID primitive.ObjectID) (int64, error) {
+	return r.Collection.CountDocuments(ctx, bson.M{"customer_id": customerID})
+}
+
+type TransactionDetailRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewTransactionDetailRepository(db *mongo.Database) *TransactionDetailRepository {
+	return &TransactionDetailRepository{
+		Collection: db.Collection("transaction_details"),
+	}
+}
+
+func (r *TransactionDetailRepository) Create(ctx context.Context, detail *models.TransactionDetail) error {
+	return insertOne(ctx, r.Collection, detail)
+}
+
+func (r *TransactionDetailRepository) ListByTransaction(ctx context.Context, transactionID primitive.ObjectID) ([]models.TransactionDetail, error) {
+	return findAll[models.TransactionDetail](ctx, r.Collection, bson.M{"transaction_id": transactionID})
+}
+
+func (r *TransactionDetailRepository) ListByTransactions(ctx context.Context, transactionIDs ...primitive.ObjectID) ([]models.TransactionDetail, error) {
+	if len(transactionIDs) == 0 {
+		return nil, nil
+	}
+	return findAll[models.TransactionDetail](ctx, r.Collection, bson.M{"transaction_id": bson.M{"$in": transactionIDs}})
+}
+
+func (r *TransactionDetailRepository) Update(ctx context.Context, detail *models.TransactionDetail) error {
+	update := bson.M{
+		"$set": bson.M{
+			"quantity":        detail.Quantity,
+			"subtotal":        detail.Subtotal,
+			"price":           detail.Price,
+			"discount_amount": detail.DiscountAmount,
+			"discounts":       detail.Discounts,
+			"tax_rate":        detail.TaxRate,
+			"taxable_amount":  detail.TaxableAmount,
+			"tax_amount":      detail.TaxAmount,
+			"total":           detail.Total,
+		},
+	}
+	return updateOne(ctx, r.Collection, bson.M{"_id": detail.ID}, update)
+}
+
+func (r *TransactionDetailRepository) DeleteByTransaction(ctx context.Context, transactionID primitive.ObjectID) error {
+	_, err := r.Collection.DeleteMany(ctx, bson.M{"transaction_id": transactionID})
+	return err
+}
+
+func (r *TransactionDetailRepository) CountByProduct(ctx context.Context, productID primitive.ObjectID) (int64, error) {
+	return r.Collection.CountDocuments(ctx, bson.M{"product_id": productID})
+}
+
+type TransactionPaymentRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewTransactionPaymentRepository(db *mongo.Database) *TransactionPaymentRepository {
+	return &TransactionPaymentRepository{
+		Collection: db.Collection("transaction_payments"),
+	}
+}
+
+func (r *TransactionPaymentRepository) Create(ctx context.Context, payment *models.TransactionPayment) error {
+	return insertOne(ctx, r.Collection, payment)
+}
+
+func (r *TransactionPaymentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.TransactionPayment, error) {
+	return findOne[models.TransactionPayment](ctx, r.Collection, bson.M{"_id": id})
+}
+
+func (r *TransactionPaymentRepository) ListByTransaction(ctx context.Context, transactionID primitive.ObjectID) ([]models.TransactionPayment, error) {
+	return findAll[models.TransactionPayment](ctx, r.Collection, bson.M{"transaction_id": transactionID})
+}
+
+func (r *TransactionPaymentRepository) ListByTransactions(ctx context.Context, transactionIDs ...primitive.ObjectID) ([]models.TransactionPayment, error) {
+	if len(transactionIDs) == 0 {
+		return nil, nil
+	}
+	return findAll[models.TransactionPayment](ctx, r.Collection, bson.M{"transaction_id": bson.M{"$in": transactionIDs}})
+}
+
+func (r *TransactionPaymentRepository) Update(ctx context.Context, payment *models.TransactionPayment) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":         payment.Status,
+			"paid_amount":    payment.PaidAmount,
+			"settled_amount": payment.SettledAmount,
+			"exchange_rate":  payment.ExchangeRate,
+			"payment_date":   payment.PaymentDate,
+		},
+	}
+	return updateOne(ctx, r.Collection, bson.M{"_id": payment.ID}, update)
+}
+
+func (r *TransactionPaymentRepository) DeleteByTransaction(ctx context.Context, transactionID primitive.ObjectID) error {
+	_, err := r.Collection.DeleteMany(ctx, bson.M{"transaction_id": transactionID})
+	return err
+}
@@ -0,0 +1,38 @@
+// repository/mongodb/unit_of_work.go
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UnitOfWork runs functions inside a MongoDB multi-document transaction.
+// This requires a replica set or sharded cluster (Atlas clusters qualify).
+type UnitOfWork struct {
+	Client *mongo.Client
+}
+
+func NewUnitOfWork(client *mongo.Client) *UnitOfWork {
+	return &UnitOfWork{Client: client}
+}
+
+// WithTransaction relies on the driver's session.WithTransaction, which
+// retries the whole function on TransientTransactionError and retries the
+// commit on UnknownTransactionCommitResult.
+func (u *UnitOfWork) WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := u.Client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+	return err
+}
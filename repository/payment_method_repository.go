@@ -0,0 +1,24 @@
+// repository/payment_method_repository.go
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+type PaymentMethodRepository interface {
+	Create(ctx context.Context, paymentMethod *models.PaymentMethod) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.PaymentMethod, error)
+	FindByName(ctx context.Context, name string) (*models.PaymentMethod, error)
+	List(ctx context.Context, query ListQuery) (*Page[models.PaymentMethod], error)
+	// ListByIDs returns the payment methods with any of the given IDs.
+	ListByIDs(ctx context.Context, ids ...primitive.ObjectID) ([]models.PaymentMethod, error)
+	Update(ctx context.Context, paymentMethod *models.PaymentMethod) error
+	// Delete soft-deletes the payment method.
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// Restore undoes Delete.
+	Restore(ctx context.Context, id primitive.ObjectID) error
+}
@@ -0,0 +1,27 @@
+// repository/product_repository.go
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+type ProductRepository interface {
+	Create(ctx context.Context, product *models.Product) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Product, error)
+	FindByCode(ctx context.Context, code string) (*models.Product, error)
+	List(ctx context.Context, query ListQuery) (*Page[models.Product], error)
+	// ListByIDs returns the products with any of the given IDs.
+	ListByIDs(ctx context.Context, ids ...primitive.ObjectID) ([]models.Product, error)
+	// Update overwrites every field except StockQuantity, which only AdjustStock changes.
+	Update(ctx context.Context, product *models.Product) error
+	// AdjustStock atomically adds delta to the stock, or fails with ErrInsufficientStock.
+	AdjustStock(ctx context.Context, id primitive.ObjectID, delta float64) error
+	// Delete soft-deletes the product.
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// Restore undoes Delete.
+	Restore(ctx context.Context, id primitive.ObjectID) error
+}
@@ -0,0 +1,17 @@
+// repository/product_version_repository.go
+package repository
+
+import (
+	"context"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+// ProductVersionRepository stores the version history of products.
+// Versions are only ever appended; there is no update or delete.
+type ProductVersionRepository interface {
+	// Create fails with ErrDuplicate if the product already has a version
+	// with the same number.
+	Create(ctx context.Context, version *models.ProductVersion) error
+	List(ctx context.Context, query ListQuery) (*Page[models.ProductVersion], error)
+}
@@ -0,0 +1,32 @@
+// repository/promotion_repository.go
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+// PromotionRepository stores promotions. Coupon codes are unique among
+// all promotions, deleted or not: Create, Update and Restore fail with
+// ErrDuplicate when another promotion has the code.
+type PromotionRepository interface {
+	Create(ctx context.Context, promotion *models.Promotion) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Promotion, error)
+	// FindByCode finds the promotion with the given coupon code, deleted or not.
+	FindByCode(ctx context.Context, code string) (*models.Promotion, error)
+	List(ctx context.Context, query ListQuery) (*Page[models.Promotion], error)
+	// Automatic returns the promotions without a code active at a time, usage aside.
+	Automatic(ctx context.Context, at time.Time) ([]models.Promotion, error)
+	// Update overwrites every field except UsageCount, which only AdjustUsage changes.
+	Update(ctx context.Context, promotion *models.Promotion) error
+	// AdjustUsage atomically adds delta to the usage count, or fails with ErrUsageLimit.
+	AdjustUsage(ctx context.Context, id primitive.ObjectID, delta int) error
+	// Delete soft-deletes the promotion, which then no longer applies.
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// Restore undoes Delete.
+	Restore(ctx context.Context, id primitive.ObjectID) error
+}
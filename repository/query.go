@@ -0,0 +1,414 @@
+// repository/query.go
+package repository
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/money"
+)
+
+// ErrInvalidCursor is returned for a cursor that is malformed or was
+// issued for a different sort order.
+var ErrInvalidCursor = errors.New("repository: invalid cursor")
+
+// Operator compares a stored field with a filter value.
+type Operator string
+
+const (
+	OpEq  Operator = "eq"
+	OpGt  Operator = "gt"
+	OpGte Operator = "gte"
+	OpLt  Operator = "lt"
+	OpLte Operator = "lte"
+	// OpContains matches strings containing the value, ignoring case.
+	OpContains Operator = "contains"
+	// OpUnset matches items whose field is missing or null; the value is
+	// ignored. Prepare adds it to hide soft-deleted items.
+	OpUnset Operator = "unset"
+)
+
+// FieldKind is the type of a listable field. It decides how filter and
+// cursor values are parsed and compared.
+type FieldKind int
+
+const (
+	StringField FieldKind = iota
+	NumberField
+	BoolField
+	TimeField
+	IDField
+	// MoneyField is an amount of money, ordered by currency and then by
+	// minor units. A filter on one only matches amounts in the currency of
+	// its value. The database backends store it as minor units and a
+	// currency.
+	MoneyField
+	// DeletedField is the soft-delete timestamp, read as nil or a
+	// time.Time. Lists cannot be filtered or sorted by it; they leave out
+	// items where it is set unless ListQuery.IncludeDeleted is true.
+	DeletedField
+)
+
+// Field is a field a list can be filtered or sorted by.
+type Field[T any] struct {
+	Kind  FieldKind
+	Value func(T) interface{}
+}
+
+// Fields whitelists the fields of T that lists accept, keyed by their JSON
+// name. Every backend stores them under that name, except "id", which
+// MongoDB stores as "_id", and amounts of money, whose minor units MongoDB
+// stores as name.minor and the SQL backends as name_minor.
+type Fields[T any] map[string]Field[T]
+
+var CustomerFields = Fields[models.Customer]{
+	"id":    {IDField, func(c models.Customer) interface{} { return c.ID }},
+	"name":  {StringField, func(c models.Customer) interface{} { return c.Name }},
+	"code":  {StringField, func(c models.Customer) interface{} { return c.Code }},
+	"email": {StringField, func(c models.Customer) interface{} { return c.Email }},
+
+	"deleted_at": {DeletedField, func(c models.Customer) interface{} { return deletedAt(c.DeletedAt) }},
+}
+
+var CustomerAddressFields = Fields[models.CustomerAddress]{
+	"id":          {IDField, func(a models.CustomerAddress) interface{} { return a.ID }},
+	"customer_id": {IDField, func(a models.CustomerAddress) interface{} { return a.CustomerID }},
+	"city":        {StringField, func(a models.CustomerAddress) interface{} { return a.City }},
+}
+
+var ProductFields = Fields[models.Product]{
+	"id":       {IDField, func(p models.Product) interface{} { return p.ID }},
+	"code":     {StringField, func(p models.Product) interface{} { return p.Code }},
+	"name":     {StringField, func(p models.Product) interface{} { return p.Name }},
+	"category": {StringField, func(p models.Product) interface{} { return p.Category }},
+	"price":    {MoneyField, func(p models.Product) interface{} { return p.Price }},
+
+	"stock_quantity": {NumberField, func(p models.Product) interface{} { return p.StockQuantity }},
+	"deleted_at":     {DeletedField, func(p models.Product) interface{} { return deletedAt(p.DeletedAt) }},
+}
+
+var PaymentMethodFields = Fields[models.PaymentMethod]{
+	"id":        {IDField, func(m models.PaymentMethod) interface{} { return m.ID }},
+	"name":      {StringField, func(m models.PaymentMethod) interface{} { return m.Name }},
+	"is_active": {BoolField, func(m models.PaymentMethod) interface{} { return m.IsActive }},
+
+	"deleted_at": {DeletedField, func(m models.PaymentMethod) interface{} { return deletedAt(m.DeletedAt) }},
+}
+
+var TransactionFields = Fields[models.Transaction]{
+	"id":               {IDField, func(t models.Transaction) interface{} { return t.ID }},
+	"customer_id":      {IDField, func(t models.Transaction) interface{} { return t.CustomerID }},
+	"currency":         {StringField, func(t models.Transaction) interface{} { return t.Currency }},
+	"total_amount":     {MoneyField, func(t models.Transaction) interface{} { return t.TotalAmount }},
+	"total_qty":        {NumberField, func(t models.Transaction) interface{} { return t.TotalQty }},
+	"transaction_date": {TimeField, func(t models.Transaction) interface{} { return t.TransactionDate }},
+}
+
+var StockMovementFields = Fields[models.StockMovement]{
+	"id":         {IDField, func(m models.StockMovement) interface{} { return m.ID }},
+	"product_id": {IDField, func(m models.StockMovement) interface{} { return m.ProductID }},
+	"type":       {StringField, func(m models.StockMovement) interface{} { return string(m.Type) }},
+	"created_at": {TimeField, func(m models.StockMovement) interface{} { return m.CreatedAt }},
+}
+
+var ProductVersionFields = Fields[models.ProductVersion]{
+	"id":         {IDField, func(v models.ProductVersion) interface{} { return v.ID }},
+	"product_id": {IDField, func(v models.ProductVersion) interface{} { return v.ProductID }},
+	"version":    {NumberField, func(v models.ProductVersion) interface{} { return float64(v.Version) }},
+	"created_at": {TimeField, func(v models.ProductVersion) interface{} { return v.CreatedAt }},
+}
+
+var AuditFields = Fields[models.AuditEntry]{
+	"id":          {IDField, func(e models.AuditEntry) interface{} { return e.ID }},
+	"actor":       {StringField, func(e models.AuditEntry) interface{} { return e.Actor }},
+	"action":      {StringField, func(e models.AuditEntry) interface{} { return string(e.Action) }},
+	"entity_type": {StringField, func(e models.AuditEntry) interface{} { return e.EntityType }},
+	"entity_id":   {IDField, func(e models.AuditEntry) interface{} { return e.EntityID }},
+	"created_at":  {TimeField, func(e models.AuditEntry) interface{} { return e.CreatedAt }},
+}
+
+var ExchangeRateFields = Fields[models.ExchangeRate]{
+	"id":             {IDField, func(r models.ExchangeRate) interface{} { return r.ID }},
+	"from_currency":  {StringField, func(r models.ExchangeRate) interface{} { return r.FromCurrency }},
+	"to_currency":    {StringField, func(r models.ExchangeRate) interface{} { return r.ToCurrency }},
+	"effective_from": {TimeField, func(r models.ExchangeRate) interface{} { return r.EffectiveFrom }},
+	"created_at":     {TimeField, func(r models.ExchangeRate) interface{} { return r.CreatedAt }},
+}
+
+var PromotionFields = Fields[models.Promotion]{
+	"id":        {IDField, func(p models.Promotion) interface{} { return p.ID }},
+	"name":      {StringField, func(p models.Promotion) interface{} { return p.Name }},
+	"code":      {StringField, func(p models.Promotion) interface{} { return p.Code }},
+	"type":      {StringField, func(p models.Promotion) interface{} { return string(p.Type) }},
+	"scope":     {StringField, func(p models.Promotion) interface{} { return string(p.Scope) }},
+	"is_active": {BoolField, func(p models.Promotion) interface{} { return p.IsActive }},
+
+	"usage_count": {NumberField, func(p models.Promotion) interface{} { return float64(p.UsageCount) }},
+	"deleted_at":  {DeletedField, func(p models.Promotion) interface{} { return deletedAt(p.DeletedAt) }},
+}
+
+var APIKeyFields = Fields[models.APIKey]{
+	"id":         {IDField, func(k models.APIKey) interface{} { return k.ID }},
+	"name":       {StringField, func(k models.APIKey) interface{} { return k.Name }},
+	"created_at": {TimeField, func(k models.APIKey) interface{} { return k.CreatedAt }},
+}
+
+// deletedAt reads a soft-delete timestamp as a DeletedField value.
+func deletedAt(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+// Filter restricts a list to items whose Field compares to Value with Op.
+// Value has the Go type of the field's kind: string, float64, bool,
+// time.Time, primitive.ObjectID or money.Money.
+type Filter struct {
+	Field string
+	Op    Operator
+	Value interface{}
+}
+
+// Cursor marks the last item of a page for keyset pagination: the next
+// page starts right after the item with this sort value and ID.
+type Cursor struct {
+	Sort  Sort
+	Value interface{}
+	ID    primitive.ObjectID
+}
+
+// ListQuery selects one page of a list. Items are ordered by Sort and then
+// by ID, so pages are stable even when sort values repeat; an empty
+// Sort.Field orders by ID alone. A zero Limit returns every matching item.
+// When After is set, Offset is ignored. Soft-deleted items are left out
+// unless IncludeDeleted is set.
+type ListQuery struct {
+	Filters        []Filter
+	Sort           Sort
+	Offset         int64
+	Limit          int64
+	After          *Cursor
+	IncludeDeleted bool
+}
+
+// Page is one page of a list.
+type Page[T any] struct {
+	Items []T
+	// Total counts every item matching the filters, on any page.
+	Total int64
+	// Next is set when more items follow this page.
+	Next *Cursor
+}
+
+// Prepare checks that every field the query refers to is whitelisted and
+// that filter and cursor values have the field's type, fills in the
+// default sort and adds the filter hiding soft-deleted items. Backends run
+// every list query through it.
+func (f Fields[T]) Prepare(q ListQuery) (ListQuery, error) {
+	for _, filter := range q.Filters {
+		field, ok := f[filter.Field]
+		if !ok || field.Kind == DeletedField {
+			return q, fmt.Errorf("repository: cannot filter by %q", filter.Field)
+		}
+		if !field.Kind.accepts(filter.Value) {
+			return q, fmt.Errorf("repository: invalid value %v for %q", filter.Value, filter.Field)
+		}
+		if filter.Op == OpContains && field.Kind != StringField {
+			return q, fmt.Errorf("repository: %q is not a text field", filter.Field)
+		}
+	}
+
+	if q.Sort.Field == "" {
+		q.Sort.Field = "id"
+	}
+	field, ok := f[q.Sort.Field]
+	if !ok || field.Kind == DeletedField {
+		return q, fmt.Errorf("repository: cannot sort by %q", q.Sort.Field)
+	}
+	if q.After != nil && (q.After.Sort != q.Sort || !field.Kind.accepts(q.After.Value)) {
+		return q, ErrInvalidCursor
+	}
+
+	if !q.IncludeDeleted {
+		filters := append([]Filter(nil), q.Filters...)
+		for name, field := range f {
+			if field.Kind == DeletedField {
+				filters = append(filters, Filter{Field: name, Op: OpUnset})
+			}
+		}
+		q.Filters = filters
+	}
+	return q, nil
+}
+
+// NewPage builds a page from items fetched with the prepared query q.
+// Backends fetch one item more than q.Limit to find out whether another
+// page follows.
+func NewPage[T any](items []T, total int64, q ListQuery, fields Fields[T]) *Page[T] {
+	page := &Page[T]{Items: items, Total: total}
+	if q.Limit > 0 && int64(len(items)) > q.Limit {
+		page.Items = items[:q.Limit]
+		last := page.Items[len(page.Items)-1]
+		page.Next = &Cursor{
+			Sort:  q.Sort,
+			Value: fields[q.Sort.Field].Value(last),
+			ID:    fields["id"].Value(last).(primitive.ObjectID),
+		}
+	}
+	return page
+}
+
+func (k FieldKind) accepts(value interface{}) bool {
+	switch value.(type) {
+	case string:
+		return k == StringField
+	case float64:
+		return k == NumberField
+	case bool:
+		return k == BoolField
+	case time.Time:
+		return k == TimeField
+	case primitive.ObjectID:
+		return k == IDField
+	case money.Money:
+		return k == MoneyField
+	}
+	return false
+}
+
+// Compare orders two values of the same kind, returning -1, 0 or 1.
+// ObjectIDs compare by their bytes, as MongoDB sorts them, and amounts of
+// money by their currency and then their minor units, as the backends
+// order them.
+func Compare(a, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, b.(string))
+	case float64:
+		b := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case bool:
+		b := b.(bool)
+		switch {
+		case a == b:
+			return 0
+		case !a:
+			return -1
+		}
+		return 1
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case primitive.ObjectID:
+		b := b.(primitive.ObjectID)
+		return bytes.Compare(a[:], b[:])
+	case money.Money:
+		b := b.(money.Money)
+		if c := strings.Compare(a.Currency(), b.Currency()); c != 0 {
+			return c
+		}
+		switch {
+		case a.Minor() < b.Minor():
+			return -1
+		case a.Minor() > b.Minor():
+			return 1
+		}
+		return 0
+	}
+	panic(fmt.Sprintf("repository: cannot compare %T", a))
+}
+
+type cursorJSON struct {
+	Field      string          `json:"f"`
+	Descending bool            `json:"d,omitempty"`
+	Value      json.RawMessage `json:"v"`
+	ID         string          `json:"id"`
+}
+
+// Encode returns the opaque string form of the cursor handed to clients.
+func (c *Cursor) Encode() string {
+	value := c.Value
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC().Format(time.RFC3339Nano)
+	}
+	if id, ok := value.(primitive.ObjectID); ok {
+		value = id.Hex()
+	}
+	raw, _ := json.Marshal(value)
+	data, _ := json.Marshal(cursorJSON{
+		Field:      c.Sort.Field,
+		Descending: c.Sort.Descending,
+		Value:      raw,
+		ID:         c.ID.Hex(),
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor produced by Encode, typing its value
+// according to the kind of the field it was sorted by.
+func DecodeCursor[T any](s string, fields Fields[T]) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var decoded cursorJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	field, ok := fields[decoded.Field]
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(decoded.ID)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &Cursor{Sort: Sort{Field: decoded.Field, Descending: decoded.Descending}, ID: id}
+	switch field.Kind {
+	case StringField:
+		var v string
+		err = json.Unmarshal(decoded.Value, &v)
+		cursor.Value = v
+	case NumberField:
+		var v float64
+		err = json.Unmarshal(decoded.Value, &v)
+		cursor.Value = v
+	case BoolField:
+		var v bool
+		err = json.Unmarshal(decoded.Value, &v)
+		cursor.Value = v
+	case TimeField:
+		var v string
+		if err = json.Unmarshal(decoded.Value, &v); err == nil {
+			cursor.Value, err = time.Parse(time.RFC3339Nano, v)
+		}
+	case IDField:
+		var v string
+		if err = json.Unmarshal(decoded.Value, &v); err == nil {
+			cursor.Value, err = primitive.ObjectIDFromHex(v)
+		}
+	case MoneyField:
+		var v money.Money
+		err = json.Unmarshal(decoded.Value, &v)
+		cursor.Value = v
+	}
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
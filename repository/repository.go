@@ -0,0 +1,52 @@
+// repository/repository.go
+package repository
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when the requested document does not exist.
+	//
+	// Customers, products, payment methods and promotions are soft-deleted:
+	// a deleted one is left out of lists but still found by ID, so the
+	// transactions referring to it still resolve, until Restore undoes the
+	// Delete. Updating a deleted one fails with ErrNotFound, as do Delete
+	// and Restore when there is nothing to delete or restore.
+	ErrNotFound = errors.New("repository: not found")
+	// ErrDuplicate is returned when a write violates a unique constraint.
+	ErrDuplicate = errors.New("repository: duplicate key")
+	// ErrForeignKey is returned when a write references a missing document
+	// or a delete would orphan documents that still reference it.
+	ErrForeignKey = errors.New("repository: foreign key constraint violated")
+	// ErrInsufficientStock is returned when a stock decrement would sell
+	// more than a product has available.
+	ErrInsufficientStock = errors.New("repository: insufficient stock")
+	// ErrUsageLimit is returned when a promotion is used more often than
+	// its usage limit allows.
+	ErrUsageLimit = errors.New("repository: usage limit reached")
+)
+
+// Repositories bundles every store the controllers depend on, so a storage
+// backend can be swapped by handing a different set to the controllers.
+type Repositories struct {
+	Customers           CustomerRepository
+	CustomerAddresses   CustomerAddressRepository
+	Products            ProductRepository
+	PaymentMethods      PaymentMethodRepository
+	Transactions        TransactionRepository
+	TransactionDetails  TransactionDetailRepository
+	TransactionPayments TransactionPaymentRepository
+	StockMovements      StockMovementRepository
+	ProductVersions     ProductVersionRepository
+	ExchangeRates       ExchangeRateRepository
+	Promotions          PromotionRepository
+	APIKeys             APIKeyRepository
+	AuditLog            AuditRepository
+
+	UnitOfWork UnitOfWork
+}
+
+// Sort describes the order of a list query.
+type Sort struct {
+	Field      string
+	Descending bool
+}
@@ -0,0 +1,20 @@
+// repository/stock_movement_repository.go
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+// StockMovementRepository stores the stock ledger. Movements are only ever
+// appended; there is no update or delete.
+type StockMovementRepository interface {
+	Create(ctx context.Context, movements ...*models.StockMovement) error
+	List(ctx context.Context, query ListQuery) (*Page[models.StockMovement], error)
+	// SumByProduct totals the quantities of every product's movements.
+	// Products without movements are left out.
+	SumByProduct(ctx context.Context) (map[primitive.ObjectID]float64, error)
+}
@@ -0,0 +1,41 @@
+// repository/transaction_repository.go
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+// TransactionRepository stores transaction headers. Details and payments
+// live in their own stores and are never embedded by these methods.
+type TransactionRepository interface {
+	Create(ctx context.Context, transaction *models.Transaction) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Transaction, error)
+	List(ctx context.Context, query ListQuery) (*Page[models.Transaction], error)
+	Update(ctx context.Context, transaction *models.Transaction) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	CountByCustomer(ctx context.Context, customerID primitive.ObjectID) (int64, error)
+}
+
+type TransactionDetailRepository interface {
+	Create(ctx context.Context, detail *models.TransactionDetail) error
+	ListByTransaction(ctx context.Context, transactionID primitive.ObjectID) ([]models.TransactionDetail, error)
+	// ListByTransactions returns the details of any of the given transactions.
+	ListByTransactions(ctx context.Context, transactionIDs ...primitive.ObjectID) ([]models.TransactionDetail, error)
+	Update(ctx context.Context, detail *models.TransactionDetail) error
+	DeleteByTransaction(ctx context.Context, transactionID primitive.ObjectID) error
+	CountByProduct(ctx context.Context, productID primitive.ObjectID) (int64, error)
+}
+
+type TransactionPaymentRepository interface {
+	Create(ctx context.Context, payment *models.TransactionPayment) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.TransactionPayment, error)
+	ListByTransaction(ctx context.Context, transactionID primitive.ObjectID) ([]models.TransactionPayment, error)
+	// ListByTransactions returns the payments of any of the given transactions.
+	ListByTransactions(ctx context.Context, transactionIDs ...primitive.ObjectID) ([]models.TransactionPayment, error)
+	Update(ctx context.Context, payment *models.TransactionPayment) error
+	DeleteByTransaction(ctx context.Context, transactionID primitive.ObjectID) error
+}
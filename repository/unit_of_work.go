@@ -0,0 +1,46 @@
+// repository/unit_of_work.go
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// UnitOfWork runs a group of repository calls atomically. Every repository
+// call made with txCtx joins the transaction; if fn returns an error the
+// transaction is rolled back and that error is returned unchanged.
+// Calling WithTransaction with a context that is already inside a
+// transaction simply runs fn as part of the outer transaction.
+type UnitOfWork interface {
+	WithTransaction(ctx context.Context, fn func(txCtx context.Context) error) error
+}
+
+// MaxTransactionAttempts bounds how often RetryTransient runs a transaction.
+const MaxTransactionAttempts = 3
+
+// transactionRetryDelay is the pause before the second attempt; it doubles
+// for every further attempt.
+const transactionRetryDelay = 20 * time.Millisecond
+
+// RetryTransient runs attempt until it succeeds, fails with an error that
+// isTransient rejects, or MaxTransactionAttempts is reached.
+func RetryTransient(ctx context.Context, isTransient func(error) bool, attempt func() error) error {
+	delay := transactionRetryDelay
+	var err error
+	for i := 0; i < MaxTransactionAttempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+
+		err = attempt()
+		if err == nil || !isTransient(err) {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/controllers"
+	"github.com/mifaabiyyu/go-test.git/models"
+)
+
+// TestStockLedger records every kind of movement for a new product and
+// checks its stock and history.
+func TestStockLedger(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			r, _ := backend.router(t, testConfig())
+
+			var product models.Product
+			decodeBody(t, performRequest(t, r, "POST", "/product", models.Product{Code: "P001", Name: "Kopi", Price: idr(12000), StockQuantity: 10}), &product)
+			assert.Equal(t, 10.0, product.StockQuantity)
+			productPath := "/product/" + product.ID.Hex()
+
+			w := performRequest(t, r, "POST", productPath+"/receipts", controllers.StockReceiptRequest{Quantity: 5, Reason: "supplier delivery"})
+			assert.Equal(t, http.StatusCreated, w.Code)
+			var received controllers.StockMovementResponse
+			decodeBody(t, w, &received)
+			assert.Equal(t, models.MovementReceipt, received.Movement.Type)
+			assert.Equal(t, 15.0, received.Product.StockQuantity)
+
+			w = performRequest(t, r, "POST", productPath+"/adjustments", controllers.StockAdjustmentRequest{Quantity: -3, Reason: "broken"})
+			assert.Equal(t, http.StatusCreated, w.Code)
+
+			// More than is in stock
+			w = performRequest(t, r, "POST", productPath+"/adjustments", controllers.StockAdjustmentRequest{Quantity: -100, Reason: "stocktake"})
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+			// Updating the product leaves its stock to the ledger
+			product.Name = "Kopi Susu"
+			product.StockQuantity = 1000
+			performRequest(t, r, "PUT", productPath, product)
+			decodeBody(t, performRequest(t, r, "GET", productPath, nil), &product)
+			assert.Equal(t, "Kopi Susu", product.Name)
+			assert.Equal(t, 12.0, product.StockQuantity)
+
+			w = performRequest(t, r, "GET", productPath+"/movements", nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+			var movements controllers.ListResponse[models.StockMovement]
+			decodeBody(t, w, &movements)
+			if assert.Len(t, movements.Data, 3) {
+				// Newest first
+				assert.Equal(t, models.MovementAdjustment, movements.Data[0].Type)
+				assert.Equal(t, -3.0, movements.Data[0].Quantity)
+				assert.Equal(t, "broken", movements.Data[0].Reason)
+				assert.Equal(t, models.MovementReceipt, movements.Data[2].Type)
+				assert.Equal(t, "opening stock", movements.Data[2].Reason)
+			}
+
+			w = performRequest(t, r, "GET", productPath+"/movements?type=receipt", nil)
+			decodeBody(t, w, &movements)
+			assert.Equal(t, int64(2), movements.Total)
+		})
+	}
+}
+
+func TestTransactionStockMovements(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	created := performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat))
+	var response controllers.CreateTransactionResponse
+	decodeBody(t, created, &response)
+	performRequest(t, r, "DELETE", "/transaction/"+response.Transaction.ID.Hex(), nil)
+
+	w := performRequest(t, r, "GET", "/product/"+cat.Products[0].ID.Hex()+"/movements?order_by=created_at&order_direction=asc", nil)
+	var movements controllers.ListResponse[models.StockMovement]
+	decodeBody(t, w, &movements)
+	if assert.Len(t, movements.Data, 2) {
+		sale, restored := movements.Data[0], movements.Data[1]
+		assert.Equal(t, models.MovementSale, sale.Type)
+		assert.Equal(t, -2.0, sale.Quantity)
+		assert.Equal(t, response.Transaction.Details[0].ID, sale.TransactionDetailID)
+		assert.Equal(t, models.MovementReturn, restored.Type)
+		assert.Equal(t, 2.0, restored.Quantity)
+		assert.Equal(t, sale.TransactionDetailID, restored.TransactionDetailID)
+	}
+
+	w = performRequest(t, r, "GET", "/product/"+primitive.NewObjectID().Hex()+"/movements", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestReconcileStock(t *testing.T) {
+	r, repos := newTestRouter()
+	ctx := context.Background()
+
+	var product models.Product
+	decodeBody(t, performRequest(t, r, "POST", "/product", models.Product{Code: "P001", Name: "Kopi", Price: idr(12000), StockQuantity: 10}), &product)
+	performRequest(t, r, "POST", "/product", models.Product{Code: "P002", Name: "Teh", Price: idr(15000)})
+
+	drifts, checked, err := reconcileStock(ctx, repos)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, checked)
+	assert.Empty(t, drifts)
+
+	// Stock changed behind the ledger's back
+	assert.NoError(t, repos.Products.AdjustStock(ctx, product.ID, -4))
+
+	drifts, checked, err = reconcileStock(ctx, repos)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, checked)
+	assert.Equal(t, []stockDrift{{ProductID: product.ID, Code: "P001", Stock: 6, Ledger: 10}}, drifts)
+
+	var report bytes.Buffer
+	assert.NoError(t, writeStockReport(&report, drifts, checked))
+	assert.Contains(t, report.String(), "P001")
+	assert.Contains(t, report.String(), "-4")
+	assert.Contains(t, report.String(), "2 product(s) checked, 1 drifted")
+}
@@ -0,0 +1,193 @@
+// Package tax works out the tax on the lines of a transaction from the
+// configured rates.
+//
+// A line is taxed at the rate of its product's category. Prices either
+// exclude the tax, which is then added on top, or include it, in which
+// case the tax is the part of the price above the taxable amount. Taxes
+// round to the nearest minor unit, halves away from zero, either line by
+// line or once per rate; the lines always add up to the totals.
+package tax
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/money"
+)
+
+// Table holds the configured rates.
+type Table struct {
+	rate       float64
+	categories map[string]float64
+	inclusive  bool
+	rounding   string
+}
+
+// New returns the table cfg configures. Categories match regardless of
+// case, and an exempt category has a rate of zero.
+func New(cfg config.TaxConfig) *Table {
+	t := &Table{
+		rate:       cfg.Rate,
+		categories: make(map[string]float64),
+		inclusive:  cfg.Inclusive,
+		rounding:   cfg.Rounding,
+	}
+	for category, rate := range cfg.Categories {
+		t.categories[strings.ToLower(category)] = rate
+	}
+	for _, category := range cfg.Exempt {
+		t.categories[strings.ToLower(category)] = 0
+	}
+	return t
+}
+
+// Rate returns the rate, in percent, of products in category.
+func (t *Table) Rate(category string) float64 {
+	if rate, ok := t.categories[strings.ToLower(category)]; ok {
+		return rate
+	}
+	return t.rate
+}
+
+// Inclusive reports whether prices include the tax.
+func (t *Table) Inclusive() bool {
+	return t.inclusive
+}
+
+// Line is an amount, at catalogue prices, taxed at Rate percent.
+type Line struct {
+	Amount money.Money
+	Rate   float64
+}
+
+// LineTax is the tax on a line. Taxable plus Tax is Total; one of them is
+// the line's amount, depending on whether prices include the tax.
+type LineTax struct {
+	Taxable money.Money
+	Tax     money.Money
+	Total   money.Money
+}
+
+// RateTotal sums the lines taxed at one rate.
+type RateTotal struct {
+	Rate    float64
+	Taxable money.Money
+	Tax     money.Money
+}
+
+// Summary is the tax on a set of lines: Lines in the order given, the
+// totals, and the totals by rate, lowest rate first.
+type Summary struct {
+	Lines   []LineTax
+	Taxable money.Money
+	Tax     money.Money
+	Total   money.Money
+	ByRate  []RateTotal
+}
+
+// Compute taxes lines, which must all be in currency.
+func (t *Table) Compute(currency string, lines []Line) (*Summary, error) {
+	summary := &Summary{
+		Lines:   make([]LineTax, len(lines)),
+		Taxable: money.Zero(currency),
+		Tax:     money.Zero(currency),
+		Total:   money.Zero(currency),
+	}
+	byRate := make(map[float64]*RateTotal)
+	// Running amounts and taxes per rate, so that tax is rounded once per
+	// rate: each line takes the rounded tax of the running amount less the
+	// tax already taken by the lines before it.
+	amounts := make(map[float64]money.Money)
+	taxes := make(map[float64]money.Money)
+
+	for i, line := range lines {
+		if line.Amount.Currency() != currency {
+			return nil, money.ErrCurrencyMismatch
+		}
+
+		// The tax is the amount times the rate over share, which is 100 when
+		// prices exclude tax and 100 plus the rate when they include it.
+		share := 100.0
+		if t.inclusive {
+			share += line.Rate
+		}
+
+		var tax money.Money
+		var err error
+		switch t.rounding {
+		case config.RoundingTotal:
+			amount, ok := amounts[line.Rate]
+			if !ok {
+				amount = money.Zero(currency)
+			}
+			before, ok := taxes[line.Rate]
+			if !ok {
+				before = money.Zero(currency)
+			}
+			if amount, err = amount.Add(line.Amount); err != nil {
+				return nil, err
+			}
+			after, err := amount.MulRatio(line.Rate, share)
+			if err != nil {
+				return nil, err
+			}
+			if tax, err = after.Sub(before); err != nil {
+				return nil, err
+			}
+			amounts[line.Rate], taxes[line.Rate] = amount, after
+		default:
+			if tax, err = line.Amount.MulRatio(line.Rate, share); err != nil {
+				return nil, err
+			}
+		}
+
+		lineTax := LineTax{Taxable: line.Amount, Tax: tax, Total: line.Amount}
+		if t.inclusive {
+			lineTax.Taxable, err = line.Amount.Sub(tax)
+		} else {
+			lineTax.Total, err = line.Amount.Add(tax)
+		}
+		if err != nil {
+			return nil, err
+		}
+		summary.Lines[i] = lineTax
+
+		rate, ok := byRate[line.Rate]
+		if !ok {
+			rate = &RateTotal{Rate: line.Rate, Taxable: money.Zero(currency), Tax: money.Zero(currency)}
+			byRate[line.Rate] = rate
+		}
+		if err := add(&rate.Taxable, lineTax.Taxable); err != nil {
+			return nil, err
+		}
+		if err := add(&rate.Tax, lineTax.Tax); err != nil {
+			return nil, err
+		}
+		if err := add(&summary.Taxable, lineTax.Taxable); err != nil {
+			return nil, err
+		}
+		if err := add(&summary.Tax, lineTax.Tax); err != nil {
+			return nil, err
+		}
+		if err := add(&summary.Total, lineTax.Total); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, rate := range byRate {
+		summary.ByRate = append(summary.ByRate, *rate)
+	}
+	sort.Slice(summary.ByRate, func(i, j int) bool { return summary.ByRate[i].Rate < summary.ByRate[j].Rate })
+	return summary, nil
+}
+
+// add adds amount to total.
+func add(total *money.Money, amount money.Money) error {
+	sum, err := total.Add(amount)
+	if err != nil {
+		return err
+	}
+	*total = sum
+	return nil
+}
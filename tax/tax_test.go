@@ -0,0 +1,88 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/money"
+)
+
+func TestComputeRounding(t *testing.T) {
+	sen := func(minor int64) money.Money { return money.New(minor, "IDR") }
+
+	tests := []struct {
+		name      string
+		cfg       config.TaxConfig
+		lines     []Line
+		lineTaxes []money.Money
+		tax       money.Money
+	}{
+		{
+			// 11% of 1.05 is 11.55 sen, which every line rounds up
+			name:      "per line",
+			cfg:       config.TaxConfig{Rounding: config.RoundingLine},
+			lines:     []Line{{sen(105), 11}, {sen(105), 11}, {sen(105), 11}},
+			lineTaxes: []money.Money{sen(12), sen(12), sen(12)},
+			tax:       sen(36),
+		},
+		{
+			// 34.65 sen rounds to 35 once, and the lines share it out
+			name:      "total",
+			cfg:       config.TaxConfig{Rounding: config.RoundingTotal},
+			lines:     []Line{{sen(105), 11}, {sen(105), 11}, {sen(105), 11}},
+			lineTaxes: []money.Money{sen(12), sen(11), sen(12)},
+			tax:       sen(35),
+		},
+		{
+			// Each rate rounds on its own: 3 × 0.5 sen at 10% and 2 × 2.5 sen at 50%
+			name:      "total per rate",
+			cfg:       config.TaxConfig{Rounding: config.RoundingTotal},
+			lines:     []Line{{sen(5), 10}, {sen(5), 50}, {sen(5), 10}, {sen(5), 50}, {sen(5), 10}},
+			lineTaxes: []money.Money{sen(1), sen(3), sen(0), sen(2), sen(1)},
+			tax:       sen(7),
+		},
+		{
+			// Negative amounts, such as refunds, round away from zero too
+			name:      "total negative",
+			cfg:       config.TaxConfig{Rounding: config.RoundingTotal},
+			lines:     []Line{{sen(-105), 11}, {sen(-105), 11}, {sen(-105), 11}},
+			lineTaxes: []money.Money{sen(-12), sen(-11), sen(-12)},
+			tax:       sen(-35),
+		},
+		{
+			// 11/111 of 1.05 is 10.41 sen, and of 3.15 is 31.22
+			name:      "total inclusive",
+			cfg:       config.TaxConfig{Rounding: config.RoundingTotal, Inclusive: true},
+			lines:     []Line{{sen(105), 11}, {sen(105), 11}, {sen(105), 11}},
+			lineTaxes: []money.Money{sen(10), sen(11), sen(10)},
+			tax:       sen(31),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := New(tt.cfg).Compute("IDR", tt.lines)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			lineTaxes := make([]money.Money, len(summary.Lines))
+			for i, line := range summary.Lines {
+				lineTaxes[i] = line.Tax
+				sum, err := line.Taxable.Add(line.Tax)
+				assert.NoError(t, err)
+				assert.Equal(t, line.Total, sum)
+			}
+			assert.Equal(t, tt.lineTaxes, lineTaxes)
+			assert.Equal(t, tt.tax, summary.Tax)
+
+			byRate := money.Zero("IDR")
+			for _, rate := range summary.ByRate {
+				byRate, err = byRate.Add(rate.Tax)
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, summary.Tax, byRate)
+		})
+	}
+}
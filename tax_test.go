@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/config"
+	"github.com/mifaabiyyu/go-test.git/controllers"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/money"
+	"github.com/mifaabiyyu/go-test.git/repository"
+	"github.com/mifaabiyyu/go-test.git/repository/gormdb"
+	"github.com/mifaabiyyu/go-test.git/repository/memory"
+	"github.com/mifaabiyyu/go-test.git/tax"
+)
+
+// ppn is PPN at 11%, with staples exempt and luxury goods at 20%.
+func ppn() config.TaxConfig {
+	return config.TaxConfig{
+		Rate:       11,
+		Rounding:   config.RoundingLine,
+		Categories: map[string]float64{"Luxury": 20},
+		Exempt:     []string{"staple"},
+	}
+}
+
+// seedTaxedProducts adds an exempt staple and a luxury product to the
+// catalogue, next to its uncategorised Kopi and Teh.
+func seedTaxedProducts(t *testing.T, repos *repository.Repositories) (staple, luxury models.Product) {
+	t.Helper()
+	staple = models.Product{ID: primitive.NewObjectID(), Code: "P003", Name: "Beras", Category: "staple", Price: idr(10000), StockQuantity: 100}
+	luxury = models.Product{ID: primitive.NewObjectID(), Code: "P004", Name: "Cerutu", Category: "luxury", Price: idr(50000), StockQuantity: 100}
+	for _, product := range []*models.Product{&staple, &luxury} {
+		if err := repos.Products.Create(context.Background(), product); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return staple, luxury
+}
+
+func TestTaxTableRates(t *testing.T) {
+	table := tax.New(ppn())
+
+	assert.Equal(t, 11.0, table.Rate(""))
+	assert.Equal(t, 11.0, table.Rate("drinks"))
+	assert.Equal(t, 0.0, table.Rate("Staple"))
+	assert.Equal(t, 20.0, table.Rate("LUXURY"))
+	assert.False(t, table.Inclusive())
+}
+
+func TestTaxMixedRateBasket(t *testing.T) {
+	lines := []tax.Line{
+		{Amount: idr(24000), Rate: 11},
+		{Amount: idr(10000), Rate: 0},
+		{Amount: idr(50000), Rate: 20},
+		{Amount: idr(15000), Rate: 11},
+	}
+
+	// Exclusive prices: the tax is added on top
+	summary, err := tax.New(ppn()).Compute("IDR", lines)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []tax.LineTax{
+			{Taxable: idr(24000), Tax: idr(2640), Total: idr(26640)},
+			{Taxable: idr(10000), Tax: idr(0), Total: idr(10000)},
+			{Taxable: idr(50000), Tax: idr(10000), Total: idr(60000)},
+			{Taxable: idr(15000), Tax: idr(1650), Total: idr(16650)},
+		}, summary.Lines)
+		assert.Equal(t, idr(99000), summary.Taxable)
+		assert.Equal(t, idr(14290), summary.Tax)
+		assert.Equal(t, idr(113290), summary.Total)
+		assert.Equal(t, []tax.RateTotal{
+			{Rate: 0, Taxable: idr(10000), Tax: idr(0)},
+			{Rate: 11, Taxable: idr(39000), Tax: idr(4290)},
+			{Rate: 20, Taxable: idr(50000), Tax: idr(10000)},
+		}, summary.ByRate)
+	}
+
+	// Inclusive prices: the tax is the part of the price above the taxable amount
+	cfg := ppn()
+	cfg.Inclusive = true
+	summary, err = tax.New(cfg).Compute("IDR", lines)
+	if assert.NoError(t, err) {
+		assert.Equal(t, tax.LineTax{Taxable: money.MustParse("21621.62", "IDR"), Tax: money.MustParse("2378.38", "IDR"), Total: idr(24000)}, summary.Lines[0])
+		assert.Equal(t, tax.LineTax{Taxable: idr(10000), Tax: idr(0), Total: idr(10000)}, summary.Lines[1])
+		assert.Equal(t, tax.LineTax{Taxable: money.MustParse("41666.67", "IDR"), Tax: money.MustParse("8333.33", "IDR"), Total: idr(50000)}, summary.Lines[2])
+		assert.Equal(t, idr(99000), summary.Total)
+		taxable, _ := summary.Taxable.Add(summary.Tax)
+		assert.Equal(t, summary.Total, taxable)
+	}
+
+	_, err = tax.New(ppn()).Compute("USD", lines)
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+}
+
+func TestTaxRounding(t *testing.T) {
+	// 11% of Rp1.05 is 11.55 sen
+	lines := []tax.Line{
+		{Amount: money.MustParse("1.05", "IDR"), Rate: 11},
+		{Amount: money.MustParse("1.05", "IDR"), Rate: 11},
+		{Amount: money.MustParse("1.05", "IDR"), Rate: 11},
+	}
+
+	// Per line, every line rounds up to 12 sen
+	summary, err := tax.New(ppn()).Compute("IDR", lines)
+	if assert.NoError(t, err) {
+		for _, line := range summary.Lines {
+			assert.Equal(t, money.New(12, "IDR"), line.Tax)
+		}
+		assert.Equal(t, money.New(36, "IDR"), summary.Tax)
+	}
+
+	// Once per rate, 34.65 sen rounds to 35, spread over the lines
+	cfg := ppn()
+	cfg.Rounding = config.RoundingTotal
+	summary, err = tax.New(cfg).Compute("IDR", lines)
+	if assert.NoError(t, err) {
+		assert.Equal(t, money.New(12, "IDR"), summary.Lines[0].Tax)
+		assert.Equal(t, money.New(11, "IDR"), summary.Lines[1].Tax)
+		assert.Equal(t, money.New(12, "IDR"), summary.Lines[2].Tax)
+		assert.Equal(t, money.New(35, "IDR"), summary.Tax)
+		assert.Equal(t, []tax.RateTotal{{Rate: 11, Taxable: money.New(315, "IDR"), Tax: money.New(35, "IDR")}}, summary.ByRate)
+	}
+
+	// Currencies without decimals round to whole units
+	summary, err = tax.New(ppn()).Compute("JPY", []tax.Line{{Amount: money.New(150, "JPY"), Rate: 11}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, money.New(17, "JPY"), summary.Tax)
+	}
+}
+
+func TestTaxConfigValidation(t *testing.T) {
+	cfg := testConfig()
+	cfg.Tax = config.TaxConfig{Rate: 120, Rounding: "nearest", Categories: map[string]float64{"luxury": -5}}
+
+	err := cfg.Validate()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "tax.rate")
+		assert.Contains(t, err.Error(), "tax.categories")
+		assert.Contains(t, err.Error(), "tax.rounding")
+	}
+}
+
+func TestTransactionTaxes(t *testing.T) {
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Tax = ppn()
+			r, repos := backend.router(t, cfg)
+			cat := seedCatalogue(t, repos)
+			staple, luxury := seedTaxedProducts(t, repos)
+
+			w := performRequest(t, r, "POST", "/transaction", transactionRequest{
+				Transaction: models.Transaction{CustomerID: cat.Customer.ID},
+				Details: []models.TransactionDetail{
+					{ProductID: cat.Products[0].ID, Quantity: 2},
+					{ProductID: staple.ID, Quantity: 1},
+					{ProductID: luxury.ID, Quantity: 1},
+				},
+			})
+			assert.Equal(t, http.StatusCreated, w.Code)
+			var response controllers.CreateTransactionResponse
+			decodeBody(t, w, &response)
+
+			transaction := response.Transaction
+			if assert.Len(t, transaction.Details, 3) {
+				kopi := transaction.Details[0]
+				assert.Equal(t, 11.0, kopi.TaxRate)
+				assert.Equal(t, idr(24000), kopi.Subtotal)
+				assert.Equal(t, idr(24000), kopi.TaxableAmount)
+				assert.Equal(t, idr(2640), kopi.TaxAmount)
+				assert.Equal(t, idr(26640), kopi.Total)
+				assert.Equal(t, 0.0, transaction.Details[1].TaxRate)
+				assert.Equal(t, idr(10000), transaction.Details[1].Total)
+				assert.Equal(t, 20.0, transaction.Details[2].TaxRate)
+				assert.Equal(t, idr(60000), transaction.Details[2].Total)
+			}
+			assert.Equal(t, idr(84000), transaction.Subtotal)
+			assert.Equal(t, idr(12640), transaction.TaxAmount)
+			assert.Equal(t, idr(96640), transaction.TotalAmount)
+			assert.False(t, transaction.TaxInclusive)
+			assert.Equal(t, []models.TaxTotal{
+				{Rate: 0, TaxableAmount: idr(10000), TaxAmount: idr(0)},
+				{Rate: 11, TaxableAmount: idr(24000), TaxAmount: idr(2640)},
+				{Rate: 20, TaxableAmount: idr(50000), TaxAmount: idr(10000)},
+			}, transaction.Taxes)
+			// Payments settle the grand total
+			assert.Equal(t, idr(96640), transaction.BalanceDue)
+
+			// Replacing the details taxes them again
+			transactionPath := "/transaction/" + transaction.ID.Hex()
+			w = performRequest(t, r, "PUT", transactionPath, controllers.TransactionUpdate{
+				Transaction: models.Transaction{CustomerID: cat.Customer.ID},
+				Details:     []models.TransactionDetail{{ProductID: cat.Products[1].ID, Quantity: 1}},
+			})
+			assert.Equal(t, http.StatusOK, w.Code)
+			decodeBody(t, performRequest(t, r, "GET", transactionPath, nil), &transaction)
+			assert.Equal(t, idr(15000), transaction.Subtotal)
+			assert.Equal(t, idr(1650), transaction.TaxAmount)
+			assert.Equal(t, idr(16650), transaction.TotalAmount)
+			assert.Equal(t, []models.TaxTotal{{Rate: 11, TaxableAmount: idr(15000), TaxAmount: idr(1650)}}, transaction.Taxes)
+
+			// Products are filtered by category
+			var list controllers.ListResponse[models.Product]
+			decodeBody(t, performRequest(t, r, "GET", "/products?category=luxury", nil), &list)
+			if assert.Len(t, list.Data, 1) {
+				assert.Equal(t, luxury.ID, list.Data[0].ID)
+			}
+		})
+	}
+}
+
+func TestTransactionTaxInclusivePrices(t *testing.T) {
+	cfg := testConfig()
+	cfg.Tax = ppn()
+	cfg.Tax.Inclusive = true
+	repos := memory.NewRepositories()
+	r := mustSetupRouter(repos, cfg)
+	cat := seedCatalogue(t, repos)
+	_, luxury := seedTaxedProducts(t, repos)
+
+	var response controllers.CreateTransactionResponse
+	decodeBody(t, performRequest(t, r, "POST", "/transaction", transactionRequest{
+		Transaction: models.Transaction{CustomerID: cat.Customer.ID},
+		Details: []models.TransactionDetail{
+			{ProductID: cat.Products[1].ID, Quantity: 1},
+			{ProductID: luxury.ID, Quantity: 1},
+		},
+	}), &response)
+
+	// The catalogue prices are what the customer pays
+	transaction := response.Transaction
+	assert.Equal(t, idr(65000), transaction.TotalAmount)
+	assert.Equal(t, money.MustParse("9819.82", "IDR"), transaction.TaxAmount)
+	assert.Equal(t, money.MustParse("55180.18", "IDR"), transaction.Subtotal)
+	assert.True(t, transaction.TaxInclusive)
+	if assert.Len(t, transaction.Details, 2) {
+		assert.Equal(t, money.MustParse("13513.51", "IDR"), transaction.Details[0].TaxableAmount)
+		assert.Equal(t, money.MustParse("1486.49", "IDR"), transaction.Details[0].TaxAmount)
+		assert.Equal(t, idr(15000), transaction.Details[0].Total)
+	}
+}
+
+func TestTransactionUntaxedByDefault(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	var response controllers.CreateTransactionResponse
+	decodeBody(t, performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat)), &response)
+
+	transaction := response.Transaction
+	assert.Equal(t, idr(54000), transaction.Subtotal)
+	assert.Equal(t, idr(0), transaction.TaxAmount)
+	assert.Equal(t, idr(54000), transaction.TotalAmount)
+	assert.Equal(t, []models.TaxTotal{{Rate: 0, TaxableAmount: idr(54000), TaxAmount: idr(0)}}, transaction.Taxes)
+}
+
+// TestSQLiteBackfillsTaxes checks that migrating a database from before
+// taxes leaves its transactions untaxed, at their total.
+func TestSQLiteBackfillsTaxes(t *testing.T) {
+	cfg := testConfig()
+	cfg.Tax = ppn()
+	db := openSQLite(t, cfg)
+	repos := gormdb.NewRepositories(db)
+	r := mustSetupRouter(repos, cfg)
+	cat := seedCatalogue(t, repos)
+	_, luxury := seedTaxedProducts(t, repos)
+
+	var response controllers.CreateTransactionResponse
+	decodeBody(t, performRequest(t, r, "POST", "/transaction", transactionRequest{
+		Transaction: models.Transaction{CustomerID: cat.Customer.ID},
+		Details: []models.TransactionDetail{
+			{ProductID: cat.Products[0].ID, Quantity: 1},
+			{ProductID: luxury.ID, Quantity: 1},
+		},
+	}), &response)
+	transactionPath := "/transaction/" + response.Transaction.ID.Hex()
+
+	var transaction models.Transaction
+	decodeBody(t, performRequest(t, r, "GET", transactionPath, nil), &transaction)
+	assert.Equal(t, idr(62000), transaction.Subtotal)
+	assert.Equal(t, idr(11320), transaction.TaxAmount)
+	assert.Equal(t, idr(73320), transaction.TotalAmount)
+
+	// Clear the columns a database from before taxes did not have
+	assert.NoError(t, db.Exec("UPDATE transactions SET subtotal_minor = 0, subtotal_currency = '', tax_amount_minor = 0, tax_amount_currency = '', total_amount_minor = 6200000").Error)
+	assert.NoError(t, db.Exec("UPDATE transaction_details SET tax_amount_minor = 0, tax_amount_currency = '', total_minor = 0, total_currency = ''").Error)
+	assert.NoError(t, gormdb.Migrate(db))
+
+	decodeBody(t, performRequest(t, r, "GET", transactionPath, nil), &transaction)
+	assert.Equal(t, idr(62000), transaction.Subtotal)
+	assert.Equal(t, idr(0), transaction.TaxAmount)
+	if assert.Len(t, transaction.Details, 2) {
+		assert.Equal(t, idr(12000), transaction.Details[0].Total)
+		assert.Equal(t, idr(0), transaction.Details[0].TaxAmount)
+	}
+}
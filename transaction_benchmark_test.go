@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/repository"
+	"github.com/mifaabiyyu/go-test.git/repository/memory"
+)
+
+// roundTrip simulates the network latency of a database query and counts
+// the queries made.
+type roundTrip struct {
+	latency time.Duration
+	queries atomic.Int64
+}
+
+func (rt *roundTrip) wait() {
+	rt.queries.Add(1)
+	time.Sleep(rt.latency)
+}
+
+type slowTransactions struct {
+	repository.TransactionRepository
+	rt *roundTrip
+}
+
+func (s slowTransactions) List(ctx context.Context, query repository.ListQuery) (*repository.Page[models.Transaction], error) {
+	s.rt.wait()
+	return s.TransactionRepository.List(ctx, query)
+}
+
+// slowDetails answers ListByTransactions with one query per transaction
+// when perTransaction is set, which is how transactions used to be loaded.
+type slowDetails struct {
+	repository.TransactionDetailRepository
+	rt             *roundTrip
+	perTransaction bool
+}
+
+func (s slowDetails) ListByTransactions(ctx context.Context, ids ...primitive.ObjectID) ([]models.TransactionDetail, error) {
+	if !s.perTransaction {
+		s.rt.wait()
+		return s.TransactionDetailRepository.ListByTransactions(ctx, ids...)
+	}
+	var details []models.TransactionDetail
+	for _, id := range ids {
+		s.rt.wait()
+		found, err := s.TransactionDetailRepository.ListByTransaction(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, found...)
+	}
+	return details, nil
+}
+
+type slowPayments struct {
+	repository.TransactionPaymentRepository
+	rt             *roundTrip
+	perTransaction bool
+}
+
+func (s slowPayments) ListByTransactions(ctx context.Context, ids ...primitive.ObjectID) ([]models.TransactionPayment, error) {
+	if !s.perTransaction {
+		s.rt.wait()
+		return s.TransactionPaymentRepository.ListByTransactions(ctx, ids...)
+	}
+	var payments []models.TransactionPayment
+	for _, id := range ids {
+		s.rt.wait()
+		found, err := s.TransactionPaymentRepository.ListByTransaction(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, found...)
+	}
+	return payments, nil
+}
+
+// BenchmarkGetTransactions lists a page of 100 transactions with a
+// simulated 100µs round trip per query, loading details and payments per
+// transaction (the old N+1 pattern) and in batches.
+func BenchmarkGetTransactions(b *testing.B) {
+	for _, bm := range []struct {
+		name           string
+		perTransaction bool
+	}{
+		{"per-transaction", true},
+		{"batched", false},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			gin.SetMode(gin.ReleaseMode)
+			repos := memory.NewRepositories()
+			seedTransactions(b, repos, 100)
+
+			rt := &roundTrip{latency: 100 * time.Microsecond}
+			repos.Transactions = slowTransactions{repos.Transactions, rt}
+			repos.TransactionDetails = slowDetails{repos.TransactionDetails, rt, bm.perTransaction}
+			repos.TransactionPayments = slowPayments{repos.TransactionPayments, rt, bm.perTransaction}
+			r := mustSetupRouter(repos, testConfig())
+			req := httptest.NewRequest("GET", "/transactions?page_size=100", nil)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				if w.Code != http.StatusOK {
+					b.Fatalf("GET /transactions returned %d", w.Code)
+				}
+			}
+			b.ReportMetric(float64(rt.queries.Load())/float64(b.N), "queries/op")
+		})
+	}
+}
+
+// seedTransactions stores n transactions with two details and one payment each.
+func seedTransactions(tb testing.TB, repos *repository.Repositories, n int) {
+	tb.Helper()
+	ctx := context.Background()
+
+	for i := 0; i < n; i++ {
+		transaction := models.Transaction{ID: primitive.NewObjectID(), CustomerID: primitive.NewObjectID(), TransactionDate: time.Now()}
+		if err := repos.Transactions.Create(ctx, &transaction); err != nil {
+			tb.Fatal(err)
+		}
+		for j := 0; j < 2; j++ {
+			detail := models.TransactionDetail{ID: primitive.NewObjectID(), TransactionID: transaction.ID, ProductID: primitive.NewObjectID(), Quantity: 1}
+			if err := repos.TransactionDetails.Create(ctx, &detail); err != nil {
+				tb.Fatal(err)
+			}
+		}
+		payment := models.TransactionPayment{ID: primitive.NewObjectID(), TransactionID: transaction.ID, PaymentMethodID: primitive.NewObjectID()}
+		if err := repos.TransactionPayments.Create(ctx, &payment); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
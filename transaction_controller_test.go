@@ -1,92 +1,615 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mifaabiyyu/go-test.git/controllers"
 	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/mifaabiyyu/go-test.git/money"
+	"github.com/mifaabiyyu/go-test.git/repository"
+	"github.com/mifaabiyyu/go-test.git/repository/memory"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func TestGetTransactions(t *testing.T) {
-	gin.SetMode(gin.TestMode) // Set Gin to test mode
+type transactionRequest struct {
+	Transaction models.Transaction          `json:"transaction"`
+	Details     []models.TransactionDetail  `json:"details"`
+	Payments    []models.TransactionPayment `json:"payments"`
+}
 
-	r := gin.Default()
-	r.GET("/transactions")
+// catalogue holds the documents a transaction request refers to.
+type catalogue struct {
+	Customer      models.Customer
+	Products      []models.Product
+	PaymentMethod models.PaymentMethod
+}
 
-	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("GET", "/transactions", nil)
-	r.ServeHTTP(w, req)
+// seedCatalogue stores a customer, two products and a payment method.
+func seedCatalogue(t *testing.T, repos *repository.Repositories) catalogue {
+	t.Helper()
+	ctx := context.Background()
 
-	assert.Equal(t, http.StatusOK, w.Code)
-	// Add more assertions as needed
+	cat := catalogue{
+		Customer: models.Customer{ID: primitive.NewObjectID(), Name: "Budi", Code: "C001", Email: "budi@example.com"},
+		Products: []models.Product{
+			{ID: primitive.NewObjectID(), Code: "P001", Name: "Kopi", Price: idr(12000), StockQuantity: 100},
+			{ID: primitive.NewObjectID(), Code: "P002", Name: "Teh", Price: idr(15000), StockQuantity: 100},
+		},
+		PaymentMethod: models.PaymentMethod{ID: primitive.NewObjectID(), Name: "Cash", IsActive: true},
+	}
+
+	if err := repos.Customers.Create(ctx, &cat.Customer); err != nil {
+		t.Fatal(err)
+	}
+	for i := range cat.Products {
+		if err := repos.Products.Create(ctx, &cat.Products[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repos.PaymentMethods.Create(ctx, &cat.PaymentMethod); err != nil {
+		t.Fatal(err)
+	}
+	return cat
 }
 
-func TestCreateTransaction(t *testing.T) {
-	// Define the JSON data for the new transaction
-	newEntry := struct {
-		Transaction models.Transaction          `json:"transaction"`
-		Details     []models.TransactionDetail  `json:"details"`
-		Payments    []models.TransactionPayment `json:"payments"`
-	}{
+func newTransactionRequest(cat catalogue) transactionRequest {
+	return transactionRequest{
 		Transaction: models.Transaction{
-			CustomerID: primitive.NewObjectID(),
+			CustomerID: cat.Customer.ID,
 		},
 		Details: []models.TransactionDetail{
 			{
-				ProductID: primitive.NewObjectID(),
-				Price:     12000,
+				ProductID: cat.Products[0].ID,
+				Price:     idr(12000),
 				Quantity:  2,
-				Subtotal:  24000,
+				Subtotal:  idr(24000),
 			},
 			{
-				ProductID: primitive.NewObjectID(),
-				Price:     15000,
+				ProductID: cat.Products[1].ID,
+				Price:     idr(15000),
 				Quantity:  2,
-				Subtotal:  30000,
+				Subtotal:  idr(30000),
 			},
 		},
 		Payments: []models.TransactionPayment{
 			{
-				PaymentMethodID: primitive.NewObjectID(),
+				PaymentMethodID: cat.PaymentMethod.ID,
 				Status:          0,
-				PaidAmount:      0,
+				PaidAmount:      idr(0),
 			},
 		},
 	}
+}
 
-	r := gin.Default()
+func TestGetTransactions(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
 
-	r.POST("/transaction")
-	jsonData, err := json.Marshal(newEntry)
-	if err != nil {
-		t.Fatalf("Failed to marshal JSON data: %v", err)
-	}
+	performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat))
+	performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat))
 
-	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("POST", "/transaction", bytes.NewBuffer(jsonData))
-	r.ServeHTTP(w, req)
+	w := performRequest(t, r, "GET", "/transactions", nil)
 
 	assert.Equal(t, http.StatusOK, w.Code)
-	// Add more assertions as needed
+	var transactions controllers.ListResponse[models.Transaction]
+	decodeBody(t, w, &transactions)
+	assert.Len(t, transactions.Data, 2)
+	for _, transaction := range transactions.Data {
+		assert.Len(t, transaction.Details, 2)
+		assert.Len(t, transaction.Payments, 1)
+	}
+}
+
+func TestCreateTransaction(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	w := performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response controllers.CreateTransactionResponse
+	decodeBody(t, w, &response)
+	assert.Equal(t, idr(54000), response.Transaction.TotalAmount)
+	assert.Equal(t, 4.0, response.Transaction.TotalQty)
+	assert.Len(t, response.Transaction.Details, 2)
+	assert.Len(t, response.Transaction.Payments, 1)
+
+	details, err := repos.TransactionDetails.ListByTransaction(context.Background(), response.Transaction.ID)
+	assert.NoError(t, err)
+	assert.Len(t, details, 2)
 }
 
 func TestGetTransaction(t *testing.T) {
-	gin.SetMode(gin.TestMode) // Set Gin to test mode
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	created := performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat))
+	var response controllers.CreateTransactionResponse
+	decodeBody(t, created, &response)
+	idData := response.Transaction.ID.Hex()
+
+	w := performRequest(t, r, "GET", "/transaction/"+idData, nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var transaction models.Transaction
+	decodeBody(t, w, &transaction)
+	assert.Equal(t, response.Transaction.ID, transaction.ID)
+	assert.Len(t, transaction.Details, 2)
+
+	w = performRequest(t, r, "GET", "/transaction/"+primitive.NewObjectID().Hex(), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDeleteTransaction(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	created := performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat))
+	var response controllers.CreateTransactionResponse
+	decodeBody(t, created, &response)
+
+	w := performRequest(t, r, "DELETE", "/transaction/"+response.Transaction.ID.Hex(), nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	details, _ := repos.TransactionDetails.ListByTransaction(context.Background(), response.Transaction.ID)
+	payments, _ := repos.TransactionPayments.ListByTransaction(context.Background(), response.Transaction.ID)
+	assert.Empty(t, details)
+	assert.Empty(t, payments)
+}
+
+func TestCreateTransactionPricesFromCatalogue(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	request := newTransactionRequest(cat)
+	for i := range request.Details {
+		request.Details[i].Price = money.Money{}
+		request.Details[i].Subtotal = money.Money{}
+	}
+
+	w := performRequest(t, r, "POST", "/transaction", request)
 
-	idData := "64dc291883f42d242a656d61"
-	r := gin.Default()
-	r.GET("/transaction/" + idData)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response controllers.CreateTransactionResponse
+	decodeBody(t, w, &response)
+	assert.Equal(t, idr(12000), response.Transaction.Details[0].Price)
+	assert.Equal(t, idr(24000), response.Transaction.Details[0].Subtotal)
+	assert.Equal(t, idr(54000), response.Transaction.TotalAmount)
+}
+
+func TestCreateTransactionRejectsPriceMismatch(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	request := newTransactionRequest(cat)
+	request.Details[1].Price = idr(1)
+	request.Details[1].Subtotal = idr(2)
+
+	w := performRequest(t, r, "POST", "/transaction", request)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "pricing_failed", decodeError(t, w).Code)
+	var mismatches []controllers.PriceMismatch
+	decodeErrorDetail(t, w, "mismatches", &mismatches)
+	assert.Equal(t, []controllers.PriceMismatch{
+		{Index: 1, ProductID: cat.Products[1].ID, Field: "price", Sent: idr(1), Expected: idr(15000)},
+		{Index: 1, ProductID: cat.Products[1].ID, Field: "subtotal", Sent: idr(2), Expected: idr(30000)},
+	}, mismatches)
 
-	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("GET", "/transaction/"+idData, nil)
-	r.ServeHTTP(w, req)
+	transactions, _ := repos.Transactions.List(context.Background(), repository.ListQuery{})
+	assert.Empty(t, transactions)
+}
+
+func TestCreateTransactionRejectsUnknownProduct(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	request := newTransactionRequest(cat)
+	unknown := primitive.NewObjectID()
+	request.Details[0].ProductID = unknown
 
+	w := performRequest(t, r, "POST", "/transaction", request)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "details[0].product_id")
+	assert.Contains(t, w.Body.String(), unknown.Hex())
+}
+
+func TestCreateTransactionRejectsBrokenReferences(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	inactive := models.PaymentMethod{ID: primitive.NewObjectID(), Name: "Voucher", IsActive: false}
+	repos.PaymentMethods.Create(context.Background(), &inactive)
+
+	request := newTransactionRequest(cat)
+	request.Transaction.CustomerID = primitive.NewObjectID()
+	request.Payments[0].PaymentMethodID = inactive.ID
+
+	w := performRequest(t, r, "POST", "/transaction", request)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var references []controllers.BrokenReference
+	decodeErrorDetail(t, w, "references", &references)
+	assert.Equal(t, []controllers.BrokenReference{
+		{Field: "transaction.customer_id", ID: request.Transaction.CustomerID, Reason: controllers.ReferenceNotFound},
+		{Field: "payments[0].payment_method_id", ID: inactive.ID, Reason: controllers.ReferenceInactive},
+	}, references)
+}
+
+func TestCreateTransactionRejectsDeletedReferences(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	w := performRequest(t, r, "DELETE", "/product/"+cat.Products[1].ID.Hex(), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = performRequest(t, r, "DELETE", "/payment-method/"+cat.PaymentMethod.ID.Hex(), nil)
 	assert.Equal(t, http.StatusOK, w.Code)
-	// Add more assertions as needed
+
+	request := newTransactionRequest(cat)
+	w = performRequest(t, r, "POST", "/transaction", request)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var references []controllers.BrokenReference
+	decodeErrorDetail(t, w, "references", &references)
+	assert.Equal(t, []controllers.BrokenReference{
+		{Field: "details[1].product_id", ID: cat.Products[1].ID, Reason: controllers.ReferenceDeleted},
+		{Field: "payments[0].payment_method_id", ID: cat.PaymentMethod.ID, Reason: controllers.ReferenceDeleted},
+	}, references)
+
+	w = performRequest(t, r, "POST", "/product/"+cat.Products[1].ID.Hex()+"/restore", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = performRequest(t, r, "POST", "/payment-method/"+cat.PaymentMethod.ID.Hex()+"/restore", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = performRequest(t, r, "POST", "/transaction", request)
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+// failingPayments rejects every payment so tests can force a failure
+// after the transaction and its details were written.
+type failingPayments struct {
+	repository.TransactionPaymentRepository
+}
+
+func (failingPayments) Create(ctx context.Context, payment *models.TransactionPayment) error {
+	return errors.New("payment store unavailable")
+}
+
+func TestCreateTransactionRollsBackOnFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repos := memory.NewRepositories()
+	repos.TransactionPayments = failingPayments{repos.TransactionPayments}
+	r := mustSetupRouter(repos, testConfig())
+	cat := seedCatalogue(t, repos)
+
+	w := performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	transactions, err := repos.Transactions.List(context.Background(), repository.ListQuery{})
+	assert.NoError(t, err)
+	assert.Empty(t, transactions)
+	count, err := repos.TransactionDetails.CountByProduct(context.Background(), cat.Products[0].ID)
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+	product, err := repos.Products.FindByID(context.Background(), cat.Products[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, product.StockQuantity)
+}
+
+func TestPaymentLifecycle(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	created := performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat))
+	var response controllers.CreateTransactionResponse
+	decodeBody(t, created, &response)
+	payment := response.Transaction.Payments[0]
+	assert.Equal(t, models.PaymentPending, payment.Status)
+	paymentPath := "/transaction/" + response.Transaction.ID.Hex() + "/payments/" + payment.ID.Hex()
+
+	w := performRequest(t, r, "POST", paymentPath+"/capture", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var captured models.TransactionPayment
+	decodeBody(t, w, &captured)
+	assert.Equal(t, models.PaymentPaid, captured.Status)
+	assert.False(t, captured.PaymentDate.IsZero())
+
+	w = performRequest(t, r, "POST", paymentPath+"/fail", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	body := decodeError(t, w)
+	assert.Equal(t, "invalid_transition", body.Code)
+	assert.Equal(t, "payment cannot move from paid to failed", body.Message)
+	assert.Equal(t, map[string]interface{}{"from": "paid", "to": "failed"}, body.Details)
+
+	w = performRequest(t, r, "POST", paymentPath+"/refund", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = performRequest(t, r, "POST", paymentPath+"/refund", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	stored, err := repos.TransactionPayments.FindByID(context.Background(), payment.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.PaymentRefunded, stored.Status)
+
+	// The payment belongs to a different transaction
+	w = performRequest(t, r, "POST", "/transaction/"+primitive.NewObjectID().Hex()+"/payments/"+payment.ID.Hex()+"/capture", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCapturedPaymentCannotBeEditedBack(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	for i := 0; i < 20; i++ {
+		var response controllers.CreateTransactionResponse
+		decodeBody(t, performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat)), &response)
+		payment := response.Transaction.Payments[0]
+		paymentPath := "/transaction/" + response.Transaction.ID.Hex() + "/payments/" + payment.ID.Hex()
+
+		// An edit racing a capture either lands first or is refused; it
+		// never puts the captured payment back to pending
+		var wg sync.WaitGroup
+		var edit *httptest.ResponseRecorder
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			performRequest(t, r, "POST", paymentPath+"/capture", nil)
+		}()
+		go func() {
+			defer wg.Done()
+			edit = performRequest(t, r, "PUT", paymentPath, controllers.PaymentUpdateRequest{PaidAmount: idr(1000)})
+		}()
+		wg.Wait()
+
+		stored, err := repos.TransactionPayments.FindByID(context.Background(), payment.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, models.PaymentPaid, stored.Status)
+		}
+		if edit.Code != http.StatusOK {
+			assert.Equal(t, http.StatusConflict, edit.Code)
+			assert.Equal(t, "payment_settled", decodeError(t, edit).Code)
+		}
+
+		w := performRequest(t, r, "PUT", paymentPath, controllers.PaymentUpdateRequest{PaidAmount: idr(2000)})
+		assert.Equal(t, http.StatusConflict, w.Code)
+		stored, err = repos.TransactionPayments.FindByID(context.Background(), payment.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, models.PaymentPaid, stored.Status)
+			assert.NotEqual(t, idr(2000), stored.PaidAmount)
+		}
+	}
+}
+
+func TestCreateTransactionRejectsFinalPaymentStatus(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	request := newTransactionRequest(cat)
+	request.Payments[0].Status = models.PaymentRefunded
+
+	w := performRequest(t, r, "POST", "/transaction", request)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "payments[0].status")
+}
+
+func TestTransactionPaymentBalance(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	created := performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat))
+	var response controllers.CreateTransactionResponse
+	decodeBody(t, created, &response)
+	transactionPath := "/transaction/" + response.Transaction.ID.Hex()
+	assert.Equal(t, models.BalanceUnpaid, response.Transaction.PaymentStatus)
+	assert.Equal(t, idr(54000), response.Transaction.BalanceDue)
+
+	balance := func() models.Transaction {
+		var transaction models.Transaction
+		decodeBody(t, performRequest(t, r, "GET", transactionPath, nil), &transaction)
+		return transaction
+	}
+
+	w := performRequest(t, r, "POST", transactionPath+"/payments", controllers.PaymentRequest{
+		PaymentMethodID: cat.PaymentMethod.ID, Status: models.PaymentPaid, PaidAmount: idr(20000),
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var first models.TransactionPayment
+	decodeBody(t, w, &first)
+	assert.False(t, first.PaymentDate.IsZero())
+
+	transaction := balance()
+	assert.Equal(t, models.BalancePartial, transaction.PaymentStatus)
+	assert.Equal(t, idr(20000), transaction.PaidAmount)
+	assert.Equal(t, idr(34000), transaction.BalanceDue)
+
+	performRequest(t, r, "POST", transactionPath+"/payments", controllers.PaymentRequest{
+		PaymentMethodID: cat.PaymentMethod.ID, Status: models.PaymentPaid, PaidAmount: idr(40000),
+	})
+	transaction = balance()
+	assert.Equal(t, models.BalanceOverpaid, transaction.PaymentStatus)
+	assert.Equal(t, idr(-6000), transaction.BalanceDue)
+
+	// Settled payments cannot be voided or edited, only refunded
+	w = performRequest(t, r, "DELETE", transactionPath+"/payments/"+first.ID.Hex(), nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = performRequest(t, r, "PUT", transactionPath+"/payments/"+first.ID.Hex(), controllers.PaymentUpdateRequest{PaidAmount: idr(1)})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = performRequest(t, r, "POST", transactionPath+"/payments/"+first.ID.Hex()+"/refund", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	transaction = balance()
+	assert.Equal(t, models.BalancePartial, transaction.PaymentStatus)
+	assert.Equal(t, idr(40000), transaction.PaidAmount)
+
+	// The pending payment from the original request can still be corrected and voided
+	pending := response.Transaction.Payments[0]
+	w = performRequest(t, r, "PUT", transactionPath+"/payments/"+pending.ID.Hex(), controllers.PaymentUpdateRequest{PaidAmount: idr(14000)})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = performRequest(t, r, "DELETE", transactionPath+"/payments/"+pending.ID.Hex(), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var payments []models.TransactionPayment
+	decodeBody(t, performRequest(t, r, "GET", transactionPath+"/payments", nil), &payments)
+	assert.Len(t, payments, 3)
+
+	w = performRequest(t, r, "GET", "/transaction/"+primitive.NewObjectID().Hex()+"/payments", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestUpdateTransactionKeepsNewPayments(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	created := performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat))
+	var response controllers.CreateTransactionResponse
+	decodeBody(t, created, &response)
+
+	request := newTransactionRequest(cat)
+	request.Payments = append(response.Transaction.Payments, models.TransactionPayment{
+		PaymentMethodID: cat.PaymentMethod.ID, Status: models.PaymentPaid, PaidAmount: idr(54000),
+	})
+	w := performRequest(t, r, "PUT", "/transaction/"+response.Transaction.ID.Hex(), request)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var transaction models.Transaction
+	decodeBody(t, performRequest(t, r, "GET", "/transaction/"+response.Transaction.ID.Hex(), nil), &transaction)
+	assert.Len(t, transaction.Payments, 2)
+	assert.Equal(t, models.BalancePaid, transaction.PaymentStatus)
+}
+
+func TestGetTransactionsEmbedsNames(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat))
+
+	w := performRequest(t, r, "GET", "/transactions?embed=product,payment_method", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var page controllers.ListResponse[models.Transaction]
+	decodeBody(t, w, &page)
+	transaction := page.Data[0]
+	assert.Equal(t, "Kopi", transaction.Details[0].ProductName)
+	assert.Equal(t, "Teh", transaction.Details[1].ProductName)
+	assert.Equal(t, "Cash", transaction.Payments[0].PaymentMethodName)
+
+	w = performRequest(t, r, "GET", "/transaction/"+transaction.ID.Hex()+"?embed=product", nil)
+	var single models.Transaction
+	decodeBody(t, w, &single)
+	assert.Equal(t, "Kopi", single.Details[0].ProductName)
+	assert.Empty(t, single.Payments[0].PaymentMethodName)
+
+	w = performRequest(t, r, "GET", "/transactions?embed=customer", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// stockOf returns the stock quantity of every seeded product.
+func stockOf(t *testing.T, repos *repository.Repositories, cat catalogue) []float64 {
+	t.Helper()
+	var stock []float64
+	for _, product := range cat.Products {
+		stored, err := repos.Products.FindByID(context.Background(), product.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stock = append(stock, stored.StockQuantity)
+	}
+	return stock
+}
+
+func TestTransactionStock(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	created := performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat))
+	assert.Equal(t, http.StatusCreated, created.Code)
+	var response controllers.CreateTransactionResponse
+	decodeBody(t, created, &response)
+	assert.Equal(t, []float64{98, 98}, stockOf(t, repos, cat))
+
+	// Sell one more Kopi and no Teh
+	request := newTransactionRequest(cat)
+	request.Details = []models.TransactionDetail{{ProductID: cat.Products[0].ID, Quantity: 3}}
+	w := performRequest(t, r, "PUT", "/transaction/"+response.Transaction.ID.Hex(), request)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []float64{97, 100}, stockOf(t, repos, cat))
+
+	w = performRequest(t, r, "DELETE", "/transaction/"+response.Transaction.ID.Hex(), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []float64{100, 100}, stockOf(t, repos, cat))
+}
+
+func TestCreateTransactionRejectsOverselling(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	// Only 10 Teh can be sold
+	teh := cat.Products[1]
+	teh.ReservedQuantity = 90
+	assert.NoError(t, repos.Products.Update(context.Background(), &teh))
+
+	request := newTransactionRequest(cat)
+	request.Details = []models.TransactionDetail{
+		{ProductID: cat.Products[0].ID, Quantity: 60},
+		{ProductID: cat.Products[1].ID, Quantity: 11},
+		{ProductID: cat.Products[0].ID, Quantity: 60},
+	}
+
+	w := performRequest(t, r, "POST", "/transaction", request)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var shortages []controllers.StockShortage
+	decodeErrorDetail(t, w, "shortages", &shortages)
+	assert.ElementsMatch(t, []controllers.StockShortage{
+		{ProductID: cat.Products[0].ID, Requested: 120, Available: 100},
+		{ProductID: cat.Products[1].ID, Requested: 11, Available: 10},
+	}, shortages)
+	assert.Equal(t, []float64{100, 100}, stockOf(t, repos, cat))
+
+	// Selling exactly what is available succeeds
+	request.Details = []models.TransactionDetail{{ProductID: cat.Products[1].ID, Quantity: 10}}
+	w = performRequest(t, r, "POST", "/transaction", request)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var product models.Product
+	decodeBody(t, performRequest(t, r, "GET", "/product/"+teh.ID.Hex(), nil), &product)
+	assert.Equal(t, 90.0, product.StockQuantity)
+	var derived struct {
+		AvailableQuantity float64 `json:"available_quantity"`
+		NeedsReorder      bool    `json:"needs_reorder"`
+	}
+	decodeBody(t, performRequest(t, r, "GET", "/product/"+teh.ID.Hex(), nil), &derived)
+	assert.Zero(t, derived.AvailableQuantity)
+	assert.True(t, derived.NeedsReorder)
+}
+
+func TestCreateTransactionsConcurrentlyNeverOversell(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	// Each request takes 2 of each product, so 50 of them empty the stock
+	var wg sync.WaitGroup
+	var created atomic.Int64
+	for i := 0; i < 60; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if w := performRequest(t, r, "POST", "/transaction", newTransactionRequest(cat)); w.Code == http.StatusCreated {
+				created.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(50), created.Load())
+	assert.Equal(t, []float64{0, 0}, stockOf(t, repos, cat))
 }
@@ -0,0 +1,181 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Language is a language messages are available in.
+type Language string
+
+const (
+	English    Language = "en"
+	Indonesian Language = "id"
+)
+
+// FromAcceptLanguage picks the first supported language an
+// Accept-Language header lists, English when it lists none. Languages are
+// taken in the order they are listed.
+func FromAcceptLanguage(header string) Language {
+	for _, part := range strings.Split(header, ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.Split(part, ";")[0]))
+		primary := strings.Split(tag, "-")[0]
+		switch primary {
+		case "id", "in":
+			return Indonesian
+		case "en":
+			return English
+		}
+	}
+	return English
+}
+
+// invalidAmountKey holds the message for an amount of money that could not
+// be read, whichever rule rejected it.
+const invalidAmountKey = "amount"
+
+// sized are the rules whose message depends on what was measured: the
+// length of a string, the number of items or a number itself.
+var sized = map[string]bool{"max": true, "min": true, "len": true}
+
+// messages holds the message formats by language and rule. A sized rule
+// has one format per measure, keyed rule.string, rule.items and
+// rule.number. Formats take the rule's parameter.
+var messages = map[Language]map[string]string{
+	English: {
+		"required":       "is required",
+		"email":          "must be a valid email address",
+		"max.string":     "must be at most %s characters long",
+		"max.items":      "must have at most %s items",
+		"max.number":     "must be at most %s",
+		"min.string":     "must be at least %s characters long",
+		"min.items":      "must have at least %s items",
+		"min.number":     "must be at least %s",
+		"len.string":     "must be exactly %s characters long",
+		"len.items":      "must have exactly %s items",
+		"len.number":     "must be %s",
+		"gt":             "must be greater than %s",
+		"gte":            "must be at least %s",
+		"lt":             "must be less than %s",
+		"lte":            "must be at most %s",
+		"oneof":          "must be one of: %s",
+		TagMoney:         "must be an amount of zero or more",
+		TagPositiveMoney: "must be an amount above zero",
+		TagObjectID:      "must be a valid ID",
+		TagPostalCode:    "must be a valid postal code",
+		TagCurrency:      "must be a supported currency code, such as IDR",
+		invalidAmountKey: "must be an amount in a supported currency, with no more decimals than the currency has",
+		"":               "failed the %q rule",
+	},
+	Indonesian: {
+		"required":       "wajib diisi",
+		"email":          "harus berupa alamat email yang valid",
+		"max.string":     "maksimal %s karakter",
+		"max.items":      "maksimal %s item",
+		"max.number":     "tidak boleh lebih dari %s",
+		"min.string":     "minimal %s karakter",
+		"min.items":      "minimal %s item",
+		"min.number":     "tidak boleh kurang dari %s",
+		"len.string":     "harus tepat %s karakter",
+		"len.items":      "harus tepat %s item",
+		"len.number":     "harus bernilai %s",
+		"gt":             "harus lebih besar dari %s",
+		"gte":            "tidak boleh kurang dari %s",
+		"lt":             "harus lebih kecil dari %s",
+		"lte":            "tidak boleh lebih dari %s",
+		"oneof":          "harus salah satu dari: %s",
+		TagMoney:         "harus berupa nominal nol atau lebih",
+		TagPositiveMoney: "harus berupa nominal di atas nol",
+		TagObjectID:      "harus berupa ID yang valid",
+		TagPostalCode:    "harus berupa kode pos yang valid",
+		TagCurrency:      "harus berupa kode mata uang yang didukung, misalnya IDR",
+		invalidAmountKey: "harus berupa nominal dalam mata uang yang didukung, tanpa desimal melebihi mata uangnya",
+		"":               "tidak memenuhi aturan %q",
+	},
+}
+
+// Message describes a failed rule in language.
+func Message(err validator.FieldError, language Language) string {
+	formats, ok := messages[language]
+	if !ok {
+		formats = messages[English]
+	}
+
+	key := err.Tag()
+	if _, ok := err.Value().(invalidAmount); ok {
+		key = invalidAmountKey
+	}
+	if sized[key] {
+		key += "." + measure(err.Kind())
+	}
+	format, ok := formats[key]
+	if !ok {
+		return fmt.Sprintf(formats[""], err.Tag())
+	}
+	if !strings.Contains(format, "%s") {
+		return format
+	}
+	param := err.Param()
+	if err.Tag() == "oneof" {
+		param = strings.Join(strings.Fields(param), ", ")
+	}
+	return fmt.Sprintf(format, param)
+}
+
+func measure(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "items"
+	}
+	return "number"
+}
+
+// typeMessages holds, by language, the message for a JSON value of the
+// wrong type, keyed by the JSON type the field needs.
+var typeMessages = map[Language]map[string]string{
+	English: {
+		"string": "must be a string",
+		"number": "must be a number",
+		"bool":   "must be true or false",
+		"items":  "must be an array",
+		"object": "must be an object",
+	},
+	Indonesian: {
+		"string": "harus berupa teks",
+		"number": "harus berupa angka",
+		"bool":   "harus berupa true atau false",
+		"items":  "harus berupa array",
+		"object": "harus berupa objek",
+	},
+}
+
+// TypeMessage describes a JSON value of the wrong type for a field of
+// type t in language.
+func TypeMessage(t reflect.Type, language Language) string {
+	formats, ok := typeMessages[language]
+	if !ok {
+		formats = typeMessages[English]
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	name := "object"
+	switch t.Kind() {
+	case reflect.String:
+		name = "string"
+	case reflect.Bool:
+		name = "bool"
+	case reflect.Slice, reflect.Array:
+		name = "items"
+	case reflect.Struct, reflect.Map:
+	default:
+		name = "number"
+	}
+	return formats[name]
+}
@@ -0,0 +1,140 @@
+// Package validation holds the request validation rules shared by every
+// endpoint: custom validators for the binding tags used on models and
+// request bodies, and the messages reported for each rule, in English and
+// Indonesian.
+package validation
+
+import (
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/mifaabiyyu/go-test.git/money"
+)
+
+// Custom binding tags. Alongside these, models use the validator's own
+// required, email, max, min, gt, gte and oneof.
+const (
+	// TagMoney is an amount of zero or more. On a number rather than a
+	// money.Money, it also allows at most two decimals. Every money.Money
+	// read from a request needs it or TagPositiveMoney, since they are
+	// what reject one that could not be read.
+	TagMoney = "money"
+	// TagPositiveMoney is an amount above zero, with the same decimals as
+	// TagMoney.
+	TagPositiveMoney = "positive_money"
+	// TagObjectID is a set ObjectID, or a string holding one in hex.
+	TagObjectID = "objectid"
+	// TagPostalCode is a postal code of the country given as the
+	// parameter, Indonesia when there is none.
+	TagPostalCode = "postal_code"
+	// TagCurrency is the ISO 4217 code of a supported currency, in
+	// capitals.
+	TagCurrency = "currency"
+)
+
+// postalCodes are the postal code formats by ISO 3166 country code.
+var postalCodes = map[string]*regexp.Regexp{
+	"ID": regexp.MustCompile(`^[1-9][0-9]{4}$`),
+	"MY": regexp.MustCompile(`^[0-9]{5}$`),
+	"SG": regexp.MustCompile(`^[0-9]{6}$`),
+	"US": regexp.MustCompile(`^[0-9]{5}(-[0-9]{4})?$`),
+}
+
+// Register adds the custom validators to v. Call it once, before v
+// validates anything.
+func Register(v *validator.Validate) error {
+	// Rules see an amount of money as its minor units, so the validator's
+	// own rules such as required and gte apply to it as well
+	v.RegisterCustomTypeFunc(minorUnits, money.Money{})
+
+	validators := map[string]validator.Func{
+		TagMoney:         isMoney,
+		TagPositiveMoney: isPositiveMoney,
+		TagObjectID:      isObjectID,
+		TagPostalCode:    isPostalCode,
+		TagCurrency:      isCurrency,
+	}
+	for tag, fn := range validators {
+		// Empty values still run the validator, so objectid can reject a
+		// missing ID without needing required as well
+		if err := v.RegisterValidation(tag, fn, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// invalidAmount is what rules see of an amount that could not be read.
+// No rule accepts it.
+type invalidAmount struct{}
+
+func minorUnits(field reflect.Value) interface{} {
+	amount := field.Interface().(money.Money)
+	if !amount.Valid() {
+		return invalidAmount{}
+	}
+	return amount.Minor()
+}
+
+func amount(fl validator.FieldLevel) (float64, bool) {
+	switch field := fl.Field(); field.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return field.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(field.Int()), true
+	}
+	return 0, false
+}
+
+// hasCents reports whether value has no more than two decimals, allowing
+// for the error float64 adds.
+func hasCents(value float64) bool {
+	cents := value * 100
+	return math.Abs(cents-math.Round(cents)) < 1e-6
+}
+
+func isMoney(fl validator.FieldLevel) bool {
+	value, ok := amount(fl)
+	return ok && value >= 0 && hasCents(value)
+}
+
+func isPositiveMoney(fl validator.FieldLevel) bool {
+	value, ok := amount(fl)
+	return ok && value > 0 && hasCents(value)
+}
+
+func isObjectID(fl validator.FieldLevel) bool {
+	switch value := fl.Field().Interface().(type) {
+	case primitive.ObjectID:
+		return !value.IsZero()
+	case string:
+		return primitive.IsValidObjectID(value)
+	}
+	return false
+}
+
+func isPostalCode(fl validator.FieldLevel) bool {
+	country := fl.Param()
+	if country == "" {
+		country = "ID"
+	}
+	pattern, ok := postalCodes[country]
+	if !ok || fl.Field().Kind() != reflect.String {
+		return false
+	}
+	return pattern.MatchString(fl.Field().String())
+}
+
+func isCurrency(fl validator.FieldLevel) bool {
+	if fl.Field().Kind() != reflect.String {
+		return false
+	}
+	code := fl.Field().String()
+	_, ok := money.Exponent(code)
+	return ok && code == strings.ToUpper(code)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mifaabiyyu/go-test.git/apperror"
+	"github.com/mifaabiyyu/go-test.git/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fieldErrors maps each invalid field of a validation error response to
+// its message.
+func fieldErrors(body apperror.Body) map[string]string {
+	fields := make(map[string]string, len(body.Fields))
+	for _, field := range body.Fields {
+		fields[field.Field] = field.Message
+	}
+	return fields
+}
+
+func TestValidationReportsEveryField(t *testing.T) {
+	r, _ := newTestRouter()
+
+	w := performRequest(t, r, "POST", "/product", models.Product{
+		Code:          strings.Repeat("P", 21),
+		Price:         idr(-1500),
+		StockQuantity: -1,
+	})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	body := decodeError(t, w)
+	assert.Equal(t, apperror.CodeValidation, body.Code)
+	assert.Equal(t, map[string]string{
+		"code":           "must be at most 20 characters long",
+		"name":           "is required",
+		"price":          "must be an amount above zero",
+		"stock_quantity": "must be at least 0",
+	}, fieldErrors(body))
+}
+
+func TestValidationMessagesFollowAcceptLanguage(t *testing.T) {
+	r, _ := newTestRouter()
+	customer := models.Customer{Code: "C001", Email: "not-an-email"}
+
+	for _, tc := range []struct {
+		language string
+		fields   map[string]string
+	}{
+		{"", map[string]string{"name": "is required", "email": "must be a valid email address"}},
+		{"en-US,en;q=0.9", map[string]string{"name": "is required", "email": "must be a valid email address"}},
+		{"id-ID,id;q=0.9,en;q=0.8", map[string]string{"name": "wajib diisi", "email": "harus berupa alamat email yang valid"}},
+		{"fr, id", map[string]string{"name": "wajib diisi", "email": "harus berupa alamat email yang valid"}},
+	} {
+		w := performRequestWithHeader(t, r, "POST", "/customers", customer, "Accept-Language", tc.language)
+		assert.Equal(t, http.StatusBadRequest, w.Code, tc.language)
+		assert.Equal(t, tc.fields, fieldErrors(decodeError(t, w)), tc.language)
+	}
+}
+
+func TestValidationChecksFormats(t *testing.T) {
+	r, repos := newTestRouter()
+	cat := seedCatalogue(t, repos)
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		body   interface{}
+		fields map[string]string
+	}{
+		{
+			"postal code",
+			"/customer-addresses",
+			models.CustomerAddress{CustomerID: cat.Customer.ID, City: "Jakarta", PostalCode: "0123"},
+			map[string]string{"postal_code": "must be a valid postal code"},
+		},
+		{
+			"missing customer ID",
+			"/customer-addresses",
+			models.CustomerAddress{City: "Jakarta", PostalCode: "10110"},
+			map[string]string{"customer_id": "must be a valid ID"},
+		},
+		{
+			"fractions of a cent",
+			"/product",
+			map[string]interface{}{"code": "P100", "name": "Teh", "price": 1500.125},
+			map[string]string{"price": "must be an amount in a supported currency, with no more decimals than the currency has"},
+		},
+		{
+			"unknown currency",
+			"/product",
+			map[string]interface{}{"code": "P100", "name": "Teh", "price": map[string]string{"amount": "1500", "currency": "XYZ"}},
+			map[string]string{"price": "must be an amount in a supported currency, with no more decimals than the currency has"},
+		},
+		{
+			"zero price",
+			"/product",
+			models.Product{Code: "P100", Name: "Teh"},
+			map[string]string{"price": "must be an amount above zero"},
+		},
+		{
+			"long payment method name",
+			"/payment-method",
+			models.PaymentMethod{Name: strings.Repeat("x", 51), IsActive: true},
+			map[string]string{"name": "must be at most 50 characters long"},
+		},
+		{
+			"nested details",
+			"/transaction",
+			transactionRequest{
+				Transaction: models.Transaction{CustomerID: cat.Customer.ID},
+				Details: []models.TransactionDetail{
+					{ProductID: cat.Products[0].ID, Quantity: 1},
+					{ProductID: primitive.NilObjectID, Quantity: 0},
+				},
+			},
+			map[string]string{
+				"details[1].product_id": "must be a valid ID",
+				"details[1].quantity":   "must be greater than 0",
+			},
+		},
+		{
+			"no details",
+			"/transaction",
+			transactionRequest{Transaction: models.Transaction{CustomerID: cat.Customer.ID}},
+			map[string]string{"details": "is required"},
+		},
+	} {
+		w := performRequest(t, r, "POST", tc.path, tc.body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, tc.name)
+		assert.Equal(t, tc.fields, fieldErrors(decodeError(t, w)), tc.name)
+	}
+}
+
+func TestValidationReportsTypeErrorsWithOtherFields(t *testing.T) {
+	r, _ := newTestRouter()
+
+	w := performRequestWithHeader(t, r, "POST", "/product", map[string]interface{}{
+		"code":  "P100",
+		"price": "murah",
+	}, "Accept-Language", "id")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, map[string]string{
+		"price": "harus berupa nominal dalam mata uang yang didukung, tanpa desimal melebihi mata uangnya",
+		"name":  "wajib diisi",
+	}, fieldErrors(decodeError(t, w)))
+}